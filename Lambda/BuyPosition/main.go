@@ -6,17 +6,23 @@ import (
 	"Investing-API/common/types"
 	"Investing-API/common/utils"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
-var newTradeValue = 0.0
-var positionAlreadyExists = false
+var store database.PortfolioStore
+
+// now is the clock used to timestamp trades. Unit tests replace it with a fixed time.
+var now = time.Now
 
 func main() {
+	store = database.NewDynamoStore(database.Login())
 	lambda.Start(Process)
 }
 
@@ -32,59 +38,67 @@ func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResp
 		return lambdaHandler.Response(http.StatusInternalServerError, unmarshallErr)
 	}
 
-	svc := database.Login()
-	openPositions, dbQueryErr := database.GetAllOpenPositions(svc)
+	// Fractional quantities are allowed, up to the configured number of decimal places.
+	if quantityErr := utils.ValidateQuantity(input.Quantity); quantityErr != nil {
+		log.Println(quantityErr)
+		return lambdaHandler.Response(http.StatusBadRequest, quantityErr.Error())
+	}
+	if input.Price.Sign() <= 0 {
+		log.Printf("Error - price must be more than 0, but got: %v\n", input.Price)
+		return lambdaHandler.Response(http.StatusBadRequest, fmt.Sprintf("price must be more than 0, but got: %v", input.Price))
+	}
+	if feeErr := utils.ValidateFees(input); feeErr != nil {
+		log.Println(feeErr)
+		return lambdaHandler.Response(http.StatusBadRequest, feeErr.Error())
+	}
+
+	// Fill in the trade's fees from the fee schedule of its exchange, unless the request already gives them.
+	input, scheduleErr := utils.ApplyFeeSchedule(input, database.SideBuy)
+	if scheduleErr != nil {
+		log.Printf("Error applying fee schedule: %v\n", scheduleErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, scheduleErr.Error())
+	}
+
+	return lambdaHandler.RetryOnConflict(lambdaHandler.ConflictAttempts, func() (int, interface{}, error) {
+		return executeTrade(input, request.RequestContext.RequestID)
+	})
+}
+
+// executeTrade reads the portfolio, applies the trade, and writes the result back to the database.
+// It is retried by lambdaHandler.RetryOnConflict when another request modifies the portfolio first.
+func executeTrade(input types.NewStockTrade, requestID string) (int, interface{}, error) {
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
 	if dbQueryErr != nil {
 		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
-		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+		return http.StatusInternalServerError, dbQueryErr, nil
 	}
 
-	// Calculate how much the new trade will cost (will subtract this value from the CASH position).
-	newTradeValue = utils.RoundToPrecision(input.Price*float64(input.Quantity), 2)
+	// The ledger entry's timestamp also dates the lot of shares bought by the trade.
+	tradeEntry := database.NewTradeEntry(input, database.SideBuy, requestID, now())
 
-	// Check that there is enough cash in the portfolio to make the trade.
-	if !canAffordTrade(openPositions) {
+	// Add the shares to the position, and remove the trade cost, including fees, from the cash value.
+	openPositions, buyErr := utils.ApplyBuy(openPositions, input, tradeEntry.Timestamp)
+	if errors.Is(buyErr, utils.ErrInsufficientCash) {
 		log.Printf("Error - not enough cash to enter position")
-		return lambdaHandler.Response(http.StatusBadRequest, "not enough cash to enter position!")
+		return http.StatusBadRequest, "not enough cash to enter position!", nil
 	}
 
-	// If a position in the new stock exists, combine the two records.
-	for index, position := range openPositions {
-		if position.SK == input.Symbol {
-			positionAlreadyExists = true
-			openPositions[index] = utils.CombinePositions(position, input)
-		}
-	}
+	// Update the position ratio's data.
+	updatedRecords := utils.CalculateMarketRatio(openPositions)
 
-	// If the position doesn't exist, create a new portfolio record.
-	if !positionAlreadyExists {
-		newPosition := database.OpenStockPosition{
-			SK:                  input.Symbol,
-			PurchaseValue:       utils.RoundToPrecision(float64(input.Quantity)*input.Price, 2),
-			PortfolioPercentage: 0,
-			AveragePrice:        utils.RoundToPrecision(input.Price, 2),
-			PercentageReturn:    0,
-			Shares:              input.Quantity,
-			CurrentStockPrice:   utils.RoundToPrecision(input.Price, 2),
-		}
-		if addRecordErr := database.AddNewPosition(svc, newPosition); addRecordErr != nil {
-			log.Printf("Error adding new position into database: %v\n", addRecordErr)
-			return lambdaHandler.Response(http.StatusInternalServerError, addRecordErr)
-		}
-		openPositions = append(openPositions, newPosition)
+	// Write the position, the cash, the ratio updates & the ledger entry to the DynamoDB table as a single transaction.
+	transaction := database.Transaction{
+		Puts:   updatedRecords,
+		Ledger: []database.LedgerEntry{tradeEntry},
 	}
-
-	// Remove the trade cost from the cash value, and update the position ratio's data.
-	updatedRecords := utils.CalculatePortfolioRatio(recalculateCashValue(openPositions))
-
-	// Insert the updated records into the DynamoDB table.
-	for _, position := range updatedRecords {
-		if updateErr := database.UpdateOpenPosition(svc, position); updateErr != nil {
-			log.Printf("Error updating position %v in database: %v\n", position.SK, updateErr)
-			return lambdaHandler.Response(http.StatusInternalServerError, updateErr)
+	if commitErr := store.CommitTransaction(transaction); commitErr != nil {
+		if errors.Is(commitErr, database.ErrVersionConflict) {
+			return http.StatusConflict, nil, commitErr
 		}
+		log.Printf("Error committing trade to database: %v\n", commitErr)
+		return http.StatusInternalServerError, commitErr, nil
 	}
 
 	log.Println("Successfully added new stock position!")
-	return lambdaHandler.Response(http.StatusOK, "Successfully added new stock position!")
+	return http.StatusOK, "Successfully added new stock position!", nil
 }
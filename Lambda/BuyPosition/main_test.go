@@ -0,0 +1,243 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess runs buy requests against an in-memory portfolio and checks the stored positions afterwards.
+func TestProcess(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 4, 13, 14, 30, 0, 0, time.UTC) }
+	const tradeTime = "2022-04-13T14:30:00.000000000Z"
+
+	tests := map[string]struct {
+		openPositions     []database.OpenStockPosition
+		request           events.APIGatewayProxyRequest
+		expectedStatus    int
+		expectedPositions []database.OpenStockPosition
+	}{
+		"New Position": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 100}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: tradeTime, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8"), Version: 1},
+			},
+		},
+		"Existing Position": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: "2022-01-04T15:00:00.000000000Z", Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 150}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.5"), AveragePrice: types.MustParseDecimal("125"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: "2022-01-04T15:00:00.000000000Z", Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}, {Acquired: tradeTime, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("300")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.5"), Version: 1},
+			},
+		},
+		"Ratio By Market Value": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.7273")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("300"), PercentageReturn: types.MustParseDecimal("0.5"), PortfolioPercentage: types.MustParseDecimal("0.2727"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("150"),
+					Lots: []database.Lot{{Acquired: "2022-01-04T15:00:00.000000000Z", Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 150}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("600"), PercentageReturn: types.MustParseDecimal("0.2"), PortfolioPercentage: types.MustParseDecimal("0.5455"), AveragePrice: types.MustParseDecimal("125"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("150"),
+					Lots: []database.Lot{{Acquired: "2022-01-04T15:00:00.000000000Z", Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}, {Acquired: tradeTime, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("300")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.4545"), Version: 1},
+			},
+		},
+		"Fractional Shares": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: "2022-01-04T15:00:00.000000000Z", Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 0.25, "Price": 150}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("237.5"), PortfolioPercentage: types.MustParseDecimal("0.2375"), AveragePrice: types.MustParseDecimal("105.56"), Shares: types.MustParseDecimal("2.25"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: "2022-01-04T15:00:00.000000000Z", Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}, {Acquired: tradeTime, Quantity: types.MustParseDecimal("0.25"), Cost: types.MustParseDecimal("37.5")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("762.5"), CurrentValue: types.MustParseDecimal("762.5"), PortfolioPercentage: types.MustParseDecimal("0.7625"), Version: 1},
+			},
+		},
+		"Fees Added To Cost": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 100, "Commission": 5, "StampDuty": 1}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("206"), PortfolioPercentage: types.MustParseDecimal("0.206"), AveragePrice: types.MustParseDecimal("103"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: tradeTime, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("206")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("794"), CurrentValue: types.MustParseDecimal("794"), PortfolioPercentage: types.MustParseDecimal("0.794"), Version: 1},
+			},
+		},
+		"Not Enough Cash For Fees": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 100, "Commission": 1}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Negative Fee": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 100, "Commission": -5}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Too Many Decimal Places": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 0.123456789, "Price": 100}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Zero Price": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 0}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Negative Price": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": -100}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Fractional Price": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "LLOY", "Quantity": 3, "Price": 0.1}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("999.7"), CurrentValue: types.MustParseDecimal("999.7"), PortfolioPercentage: types.MustParseDecimal("0.9997"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "LLOY", PurchaseValue: types.MustParseDecimal("0.3"), PortfolioPercentage: types.MustParseDecimal("0.0003"), AveragePrice: types.MustParseDecimal("0.1"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("0.1"),
+					Lots: []database.Lot{{Acquired: tradeTime, Quantity: types.MustParseDecimal("3"), Cost: types.MustParseDecimal("0.3")}}, Version: 1},
+			},
+		},
+		"Not Enough Cash": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("100"), CurrentValue: types.MustParseDecimal("100"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 100}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("100"), CurrentValue: types.MustParseDecimal("100"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Incorrect HTTP Method": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "GET"},
+			http.StatusInternalServerError,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = database.NewMemoryStore(testCase.openPositions...)
+
+			response, err := Process(testCase.request)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+
+			// Only a successful trade is recorded in the ledger.
+			ledger, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeTrade})
+			assert.Equal(t, testCase.expectedStatus == http.StatusOK, len(ledger.Entries) == 1)
+		})
+	}
+}
+
+// racingStore simulates another request updating the CASH record between this request reading & writing the portfolio.
+type racingStore struct {
+	*database.MemoryStore
+	races int // The number of upcoming commits which lose the race to another request.
+}
+
+func (s *racingStore) CommitTransaction(tx database.Transaction) error {
+	if s.races > 0 {
+		s.races--
+		cash, _ := s.GetOpenPosition("CASH")
+		_ = s.UpdateOpenPosition(cash)
+	}
+	return s.MemoryStore.CommitTransaction(tx)
+}
+
+// TestProcessConflict checks that a trade is retried when the portfolio changes underneath it, and gives up with a 409.
+func TestProcessConflict(t *testing.T) {
+	tests := map[string]struct {
+		races          int
+		expectedStatus int
+		expectedCash   database.OpenStockPosition
+	}{
+		"Retry Succeeds": {
+			1,
+			http.StatusOK,
+			database.OpenStockPosition{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8"), Version: 2},
+		},
+		"Retries Exhausted": {
+			lambdaHandler.ConflictAttempts,
+			http.StatusConflict,
+			database.OpenStockPosition{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1"), Version: 3},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = &racingStore{
+				MemoryStore: database.NewMemoryStore(database.OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")}),
+				races:       testCase.races,
+			}
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 2, "Price": 100}`})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			cash, _ := store.GetOpenPosition("CASH")
+			assert.Equal(t, testCase.expectedCash, cash)
+		})
+	}
+}
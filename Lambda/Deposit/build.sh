@@ -0,0 +1,6 @@
+rm -rf dist
+mkdir dist
+env GOOS=linux go build -ldflags="-s -w" -o main .
+zip Deposit.zip main
+mv Deposit.zip ./dist/
+rm main
\ No newline at end of file
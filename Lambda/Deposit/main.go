@@ -0,0 +1,79 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"Investing-API/common/utils"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+// now is the clock used to timestamp deposits. Unit tests replace it with a fixed time.
+var now = time.Now
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process adds money paid into the portfolio to CASH, and records it in the ledger as an external cash flow, so performance
+// calculations count it as a contribution rather than a gain.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != "POST" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: POST")
+	}
+
+	var input = types.NewCashFlow{}
+	if unmarshallErr := json.Unmarshal([]byte(request.Body), &input); unmarshallErr != nil {
+		log.Printf("Error reading request body into struct: %v\n", unmarshallErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, unmarshallErr)
+	}
+
+	if amountErr := utils.ValidateCashAmount(input.Amount); amountErr != nil {
+		log.Println(amountErr)
+		return lambdaHandler.Response(http.StatusBadRequest, amountErr.Error())
+	}
+
+	return lambdaHandler.RetryOnConflict(lambdaHandler.ConflictAttempts, func() (int, interface{}, error) {
+		return executeDeposit(input, request.RequestContext.RequestID)
+	})
+}
+
+// executeDeposit reads the portfolio, adds the deposit to CASH, and writes the result back to the database.
+// It is retried by lambdaHandler.RetryOnConflict when another request modifies the portfolio first.
+func executeDeposit(input types.NewCashFlow, requestID string) (int, interface{}, error) {
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
+		return http.StatusInternalServerError, dbQueryErr, nil
+	}
+
+	// Update the cash, and the position ratio's data, as the portfolio is now worth more.
+	updatedRecords := utils.CalculateMarketRatio(utils.ApplyDeposit(openPositions, input.Amount))
+
+	// Write the cash, the ratio updates & the ledger entry to the DynamoDB table as a single transaction.
+	transaction := database.Transaction{
+		Puts:   updatedRecords,
+		Ledger: []database.LedgerEntry{database.NewCashFlowEntry(input, database.SideDeposit, requestID, now())},
+	}
+	if commitErr := store.CommitTransaction(transaction); commitErr != nil {
+		if errors.Is(commitErr, database.ErrVersionConflict) {
+			return http.StatusConflict, nil, commitErr
+		}
+		log.Printf("Error committing deposit to database: %v\n", commitErr)
+		return http.StatusInternalServerError, commitErr, nil
+	}
+
+	log.Println("Successfully deposited cash!")
+	return http.StatusOK, "Successfully deposited cash!", nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess runs deposit requests against an in-memory portfolio and checks the stored positions & ledger afterwards.
+func TestProcess(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 4, 13, 14, 30, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		openPositions     []database.OpenStockPosition
+		request           events.APIGatewayProxyRequest
+		expectedStatus    int
+		expectedPositions []database.OpenStockPosition
+	}{
+		"First Deposit": {
+			nil,
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 1000}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1"), Version: 1},
+			},
+		},
+		"Existing Portfolio": {
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100")},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 1000.5}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.1"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1800.5"), CurrentValue: types.MustParseDecimal("1800.5"), PortfolioPercentage: types.MustParseDecimal("0.9"), Version: 1},
+			},
+		},
+		"Negative Amount": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": -100}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Fractions Of A Penny": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 100.001}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Incorrect HTTP Method": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "GET"},
+			http.StatusInternalServerError,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = database.NewMemoryStore(testCase.openPositions...)
+
+			response, err := Process(testCase.request)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+
+			// Only a successful deposit is recorded in the ledger.
+			ledger, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeCashFlow})
+			assert.Equal(t, testCase.expectedStatus == http.StatusOK, len(ledger.Entries) == 1)
+			if len(ledger.Entries) == 1 {
+				var deposit types.NewCashFlow
+				assert.NoError(t, json.Unmarshal([]byte(testCase.request.Body), &deposit))
+				assert.Equal(t, database.SideDeposit, ledger.Entries[0].Side)
+				assert.Equal(t, deposit.Amount, ledger.Entries[0].Amount)
+			}
+		})
+	}
+}
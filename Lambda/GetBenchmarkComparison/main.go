@@ -0,0 +1,93 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/API"
+	"Investing-API/common/calendar"
+	"Investing-API/common/database"
+	"Investing-API/common/performance"
+	"Investing-API/common/risk"
+	"Investing-API/common/types"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+// getAdjustedBars fetches the daily bars of a symbol from a date, with their closes adjusted for splits & dividends. Unit tests
+// replace it with fixed bars.
+var getAdjustedBars = API.GetSymbolAdjustedBars
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process compares the portfolio against a benchmark, by following its trades & dividends in the benchmark, and returns both cumulative
+// return curves with the excess return. The optional query parameters are: benchmark (the BENCHMARK_SYMBOL by default), and
+// period (1M, 3M, YTD, 1Y or ALL, the default), or from & to (YYYY-MM-DD, inclusive) for any other period.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
+
+	if request.HTTPMethod != "GET" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: GET")
+	}
+
+	params := request.QueryStringParameters
+	benchmark := risk.BenchmarkSymbol()
+	if symbol, exists := params["benchmark"]; exists && symbol != "" {
+		benchmark = symbol
+	}
+	from, to := params["from"], params["to"]
+	if dateErr := lambdaHandler.ValidateDateRange(from, to); dateErr != nil {
+		log.Printf("Error reading query parameters: %v\n", dateErr)
+		return lambdaHandler.Response(http.StatusBadRequest, dateErr.Error())
+	}
+
+	snapshots, dbQueryErr := store.GetSnapshots("", to)
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for portfolio snapshots: %v\n", dbQueryErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+	}
+	if len(snapshots) == 0 {
+		return lambdaHandler.Response(http.StatusNotFound, performance.ErrNoSnapshots.Error())
+	}
+
+	_, from, periodErr := performance.ResolvePeriod(params["period"], from, snapshots[len(snapshots)-1].Date)
+	if periodErr != nil {
+		log.Printf("Error reading query parameters: %v\n", periodErr)
+		return lambdaHandler.Response(http.StatusBadRequest, periodErr.Error())
+	}
+
+	// Dividends are money paid out of the positions, the same as a sell, and are already in the benchmark's adjusted closes.
+	var flows []database.LedgerEntry
+	for _, entryType := range []string{database.EntryTypeTrade, database.EntryTypeDividend} {
+		entries, dbQueryErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: entryType, To: to})
+		if dbQueryErr != nil {
+			log.Printf("Error querying database for %v ledger entries: %v\n", entryType, dbQueryErr)
+			return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+		}
+		flows = append(flows, entries...)
+	}
+
+	// The benchmark is bought at its close on the day of the snapshot the comparison starts from, or on the trading day before it.
+	// Its closes are adjusted for splits & dividends, so a split isn't compared as a fall in the benchmark.
+	start, _ := calendar.ParseDate(performance.MeasuredFrom(snapshots, from))
+	pricesFrom := calendar.FormatDate(calendar.ForSymbol(benchmark).TradingDayOnOrBefore(start))
+	benchmarkBars, priceErr := getAdjustedBars(benchmark, pricesFrom)
+	if priceErr != nil {
+		log.Printf("Error fetching prices of %v: %v\n", benchmark, priceErr)
+		return lambdaHandler.Response(lambdaHandler.PriceErrorStatus(priceErr), priceErr.Error())
+	}
+
+	comparison, compareErr := performance.CompareBenchmark(snapshots, flows, benchmark, types.AdjustedClosingPrices(benchmarkBars), from, to)
+	if compareErr != nil {
+		log.Printf("Error comparing portfolio against %v: %v\n", benchmark, compareErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, compareErr.Error())
+	}
+
+	return lambdaHandler.Response(http.StatusOK, comparison)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"Investing-API/common/performance"
+	"Investing-API/common/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess checks that the portfolio can be compared against the default benchmark, or a chosen one.
+func TestProcess(t *testing.T) {
+	var snapshots []database.PortfolioSnapshot
+	for _, day := range []struct{ date, totalValue, cash string }{
+		{"2022-03-31", "1000", "800"},
+		{"2022-04-11", "1100", "800"},
+	} {
+		snapshot := database.NewSnapshot(day.date)
+		snapshot.TotalValue, snapshot.Cash = types.MustParseDecimal(day.totalValue), types.MustParseDecimal(day.cash)
+		snapshots = append(snapshots, snapshot)
+	}
+	buy := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("50")}, database.SideBuy, "request-1", time.Date(2022, 4, 4, 15, 0, 0, 0, time.UTC))
+
+	prices := map[string]map[string]types.Decimal{
+		"SPY":  {"2022-03-31": types.MustParseDecimal("400"), "2022-04-04": types.MustParseDecimal("400"), "2022-04-11": types.MustParseDecimal("440")},
+		"VUSA": {"2022-03-31": types.MustParseDecimal("60"), "2022-04-04": types.MustParseDecimal("60"), "2022-04-11": types.MustParseDecimal("57")},
+	}
+	var pricesFrom string
+	getAdjustedBars = func(symbol, from string) ([]types.AdjustedBar, error) {
+		if symbol == "LIMITED" {
+			return nil, fmt.Errorf("%w: too many requests", API.ErrRateLimited)
+		}
+		symbolPrices, exists := prices[symbol]
+		if !exists {
+			return nil, fmt.Errorf("%w: %v", API.ErrUnknownSymbol, symbol)
+		}
+		pricesFrom = from
+		var bars []types.AdjustedBar
+		for date, price := range symbolPrices {
+			if date >= from {
+				bars = append(bars, types.AdjustedBar{Bar: types.Bar{Date: date, Close: price}, AdjustedClose: price})
+			}
+		}
+		types.SortAdjustedBars(bars)
+		return bars, nil
+	}
+
+	tests := map[string]struct {
+		params            map[string]string
+		expectedStatus    int
+		expectedBenchmark string
+		expectedExcess    string
+	}{
+		"Default Benchmark": {map[string]string{}, http.StatusOK, "SPY", "0.1"},
+		"Chosen Benchmark":  {map[string]string{"benchmark": "VUSA"}, http.StatusOK, "VUSA", "0.25"},
+		"Named Period":      {map[string]string{"period": "1M"}, http.StatusOK, "SPY", "0.1"},
+		"Unknown Benchmark": {map[string]string{"benchmark": "FTSE"}, http.StatusNotFound, "", ""},
+		"Rate Limited":      {map[string]string{"benchmark": "LIMITED"}, http.StatusServiceUnavailable, "", ""},
+		"Incorrect Date":    {map[string]string{"from": "2022-4-01"}, http.StatusBadRequest, "", ""},
+		"Before Snapshots":  {map[string]string{"to": "2022-01-01"}, http.StatusNotFound, "", ""},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			memoryStore := database.NewMemoryStore()
+			assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: []database.LedgerEntry{buy}, Snapshots: snapshots}))
+			store = memoryStore
+			t.Setenv("BENCHMARK_SYMBOL", "")
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: testCase.params})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var comparison performance.Comparison
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &comparison))
+			assert.Equal(t, testCase.expectedBenchmark, comparison.Benchmark)
+			assert.Len(t, comparison.Curve, 2)
+			assert.Equal(t, types.MustParseDecimal(testCase.expectedExcess), comparison.ExcessReturn)
+			// Prices are fetched from the first snapshot, however long ago it was.
+			assert.Equal(t, "2022-03-31", pricesFrom)
+		})
+	}
+}
+
+// TestProcessSplit checks that a 2-for-1 split of the benchmark isn't compared as a fall in the benchmark.
+func TestProcessSplit(t *testing.T) {
+	start, end := database.NewSnapshot("2022-03-31"), database.NewSnapshot("2022-04-11")
+	start.TotalValue, start.Cash = types.MustParseDecimal("1000"), types.MustParseDecimal("800")
+	end.TotalValue, end.Cash = types.MustParseDecimal("1100"), types.MustParseDecimal("800")
+	memoryStore := database.NewMemoryStore()
+	assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Snapshots: []database.PortfolioSnapshot{start, end}}))
+	store = memoryStore
+	t.Setenv("BENCHMARK_SYMBOL", "")
+	getAdjustedBars = func(symbol, from string) ([]types.AdjustedBar, error) {
+		return []types.AdjustedBar{
+			{Bar: types.Bar{Date: "2022-03-31", Close: types.MustParseDecimal("400")}, AdjustedClose: types.MustParseDecimal("200")},
+			{Bar: types.Bar{Date: "2022-04-04", Close: types.MustParseDecimal("200")}, AdjustedClose: types.MustParseDecimal("200"), SplitCoefficient: types.MustParseDecimal("2")},
+			{Bar: types.Bar{Date: "2022-04-11", Close: types.MustParseDecimal("220")}, AdjustedClose: types.MustParseDecimal("220")},
+		}, nil
+	}
+
+	response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var comparison performance.Comparison
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &comparison))
+	assert.Equal(t, types.MustParseDecimal("0.1"), comparison.BenchmarkReturn)
+	assert.Equal(t, types.MustParseDecimal("0.4"), comparison.ExcessReturn)
+}
@@ -0,0 +1,6 @@
+rm -rf dist
+mkdir dist
+env GOOS=linux go build -ldflags="-s -w" -o main .
+zip GetCapitalGainsReport.zip main
+mv GetCapitalGainsReport.zip ./dist/
+rm main
\ No newline at end of file
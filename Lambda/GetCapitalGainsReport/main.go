@@ -0,0 +1,58 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"Investing-API/common/tax"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process returns the UK capital gains report of every tax year with a disposal. The optional taxYear query parameter
+// (e.g. 2021/22) returns the report of a single tax year.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
+
+	if request.HTTPMethod != "GET" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: GET")
+	}
+
+	// Every trade & split is needed, as a disposal can be matched against shares bought in any earlier tax year.
+	var entries []database.LedgerEntry
+	for _, entryType := range []string{database.EntryTypeTrade, database.EntryTypeCorporateAction} {
+		typeEntries, dbQueryErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: entryType})
+		if dbQueryErr != nil {
+			log.Printf("Error querying database for %v ledger entries: %v\n", entryType, dbQueryErr)
+			return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+		}
+		entries = append(entries, typeEntries...)
+	}
+
+	reports, reportErr := tax.BuildReports(entries)
+	if reportErr != nil {
+		log.Printf("Error matching disposals: %v\n", reportErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, reportErr.Error())
+	}
+
+	taxYear, exists := request.QueryStringParameters["taxYear"]
+	if !exists {
+		return lambdaHandler.Response(http.StatusOK, reports)
+	}
+	for _, report := range reports {
+		if report.TaxYear == taxYear {
+			return lambdaHandler.Response(http.StatusOK, report)
+		}
+	}
+	return lambdaHandler.Response(http.StatusNotFound, fmt.Sprintf("no disposals were made in tax year %v", taxYear))
+}
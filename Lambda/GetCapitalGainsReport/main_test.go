@@ -0,0 +1,82 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/tax"
+	"Investing-API/common/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess checks that the capital gains report can be returned for every tax year, or a single tax year.
+func TestProcess(t *testing.T) {
+	trades := []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "VUSA", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("50")}, database.SideBuy, "request-1", time.Date(2021, 1, 4, 12, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "VUSA", Quantity: types.MustParseDecimal("5"), Price: types.MustParseDecimal("60")}, database.SideSell, "request-2", time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "VUSA", Quantity: types.MustParseDecimal("5"), Price: types.MustParseDecimal("40")}, database.SideSell, "request-3", time.Date(2021, 5, 4, 12, 0, 0, 0, time.UTC)),
+	}
+
+	tests := map[string]struct {
+		params           map[string]string
+		expectedStatus   int
+		expectedTaxYears []string
+	}{
+		"Every Tax Year":  {map[string]string{}, http.StatusOK, []string{"2020/21", "2021/22"}},
+		"Single Tax Year": {map[string]string{"taxYear": "2021/22"}, http.StatusOK, []string{"2021/22"}},
+		"No Disposals":    {map[string]string{"taxYear": "2019/20"}, http.StatusNotFound, nil},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			memoryStore := database.NewMemoryStore()
+			assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: trades}))
+			store = memoryStore
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: testCase.params})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var reports []tax.TaxYearReport
+			if _, single := testCase.params["taxYear"]; single {
+				var report tax.TaxYearReport
+				assert.NoError(t, json.Unmarshal([]byte(response.Body), &report))
+				reports = append(reports, report)
+			} else {
+				assert.NoError(t, json.Unmarshal([]byte(response.Body), &reports))
+			}
+			var taxYears []string
+			for _, report := range reports {
+				taxYears = append(taxYears, report.TaxYear)
+			}
+			assert.Equal(t, testCase.expectedTaxYears, taxYears)
+		})
+	}
+}
+
+// TestProcessSplit checks that shares sold after a split are matched against the shares held before it.
+func TestProcessSplit(t *testing.T) {
+	memoryStore := database.NewMemoryStore()
+	assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("100")}, database.SideBuy, "request-1", time.Date(2022, 1, 4, 15, 0, 0, 0, time.UTC)),
+		database.NewCorporateActionEntry(types.CorporateAction{Symbol: "AAPL", Date: "2022-03-01", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")}, types.MustParseDecimal("40"), time.Date(2022, 3, 2, 6, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("30"), Price: types.MustParseDecimal("30")}, database.SideSell, "request-2", time.Date(2022, 5, 4, 15, 0, 0, 0, time.UTC)),
+	}}))
+	store = memoryStore
+
+	response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: map[string]string{"taxYear": "2022/23"}})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var report tax.TaxYearReport
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &report))
+	assert.Equal(t, types.MustParseDecimal("750"), report.TotalAllowableCosts)
+	assert.Equal(t, types.MustParseDecimal("150"), report.NetGain)
+}
@@ -10,16 +10,17 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+var store database.PortfolioStore
+
 func main() {
+	store = database.NewDynamoStore(database.Login())
 	lambda.Start(Process)
 }
 
 func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
 	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
 
-	svc := database.Login()
-
-	openPositions, dbQueryErr := database.GetAllOpenPositions(svc)
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
 	if dbQueryErr != nil {
 		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
 		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
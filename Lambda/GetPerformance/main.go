@@ -0,0 +1,83 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"Investing-API/common/performance"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process returns the time-weighted & money-weighted returns of the portfolio, and of each symbol. The optional query parameters
+// are: period (1M, 3M, YTD, 1Y or ALL, the default), or from & to (YYYY-MM-DD, inclusive) for any other period, and symbol to
+// only return the performance of a single symbol.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
+
+	if request.HTTPMethod != "GET" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: GET")
+	}
+
+	params := request.QueryStringParameters
+	from, to := params["from"], params["to"]
+	if dateErr := lambdaHandler.ValidateDateRange(from, to); dateErr != nil {
+		log.Printf("Error reading query parameters: %v\n", dateErr)
+		return lambdaHandler.Response(http.StatusBadRequest, dateErr.Error())
+	}
+
+	snapshots, dbQueryErr := store.GetSnapshots("", to)
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for portfolio snapshots: %v\n", dbQueryErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+	}
+	if len(snapshots) == 0 {
+		return lambdaHandler.Response(http.StatusNotFound, performance.ErrNoSnapshots.Error())
+	}
+
+	period, from, periodErr := performance.ResolvePeriod(params["period"], from, snapshots[len(snapshots)-1].Date)
+	if periodErr != nil {
+		log.Printf("Error reading query parameters: %v\n", periodErr)
+		return lambdaHandler.Response(http.StatusBadRequest, periodErr.Error())
+	}
+
+	// Every trade, dividend, deposit & withdrawal up to the end of the period is read, as the period starts from the snapshot before
+	// the from date.
+	var flows []database.LedgerEntry
+	for _, entryType := range []string{database.EntryTypeTrade, database.EntryTypeDividend, database.EntryTypeCashFlow} {
+		entries, dbQueryErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: entryType, To: to})
+		if dbQueryErr != nil {
+			log.Printf("Error querying database for %v ledger entries: %v\n", entryType, dbQueryErr)
+			return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+		}
+		flows = append(flows, entries...)
+	}
+
+	report, measureErr := performance.Measure(snapshots, flows, from, to)
+	if measureErr != nil {
+		return lambdaHandler.Response(http.StatusNotFound, measureErr.Error())
+	}
+	report.Period = period
+
+	symbol, singleSymbol := params["symbol"]
+	if !singleSymbol {
+		return lambdaHandler.Response(http.StatusOK, report)
+	}
+	for _, symbolReturn := range report.Symbols {
+		if symbolReturn.Symbol == symbol {
+			report.Symbols = []performance.Return{symbolReturn}
+			return lambdaHandler.Response(http.StatusOK, report)
+		}
+	}
+	return lambdaHandler.Response(http.StatusNotFound, fmt.Sprintf("%v was not held or traded between %v and %v", symbol, report.From, report.To))
+}
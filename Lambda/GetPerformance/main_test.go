@@ -0,0 +1,91 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/performance"
+	"Investing-API/common/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess checks that performance can be requested by a named period, a date range, or for a single symbol.
+func TestProcess(t *testing.T) {
+	var snapshots []database.PortfolioSnapshot
+	for _, day := range []struct{ date, totalValue, aaplValue string }{
+		{"2021-12-31", "1000", "0"},
+		{"2022-03-11", "1050", "250"},
+		{"2022-04-12", "1100", "300"},
+	} {
+		snapshot := database.NewSnapshot(day.date)
+		snapshot.TotalValue = types.MustParseDecimal(day.totalValue)
+		if day.aaplValue != "0" {
+			snapshot.Positions = []database.SnapshotPosition{{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal(day.aaplValue)}}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	buy := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("100")}, database.SideBuy, "request-1", time.Date(2022, 1, 4, 15, 0, 0, 0, time.UTC))
+
+	tests := map[string]struct {
+		params         map[string]string
+		expectedStatus int
+		expectedFrom   string
+		expectedTWR    string
+		expectedAAPL   bool
+	}{
+		"Since Inception":  {map[string]string{}, http.StatusOK, "2021-12-31", "0.1", true},
+		"Year To Date":     {map[string]string{"period": "YTD"}, http.StatusOK, "2021-12-31", "0.1", true},
+		"One Month":        {map[string]string{"period": "1M"}, http.StatusOK, "2022-03-11", "0.0476", true},
+		"Date Range":       {map[string]string{"from": "2022-01-01", "to": "2022-03-31"}, http.StatusOK, "2021-12-31", "0.05", true},
+		"Single Symbol":    {map[string]string{"symbol": "AAPL"}, http.StatusOK, "2021-12-31", "0.1", true},
+		"Unknown Symbol":   {map[string]string{"symbol": "TSLA"}, http.StatusNotFound, "", "", false},
+		"Unknown Period":   {map[string]string{"period": "2W"}, http.StatusBadRequest, "", "", false},
+		"Period And From":  {map[string]string{"period": "1M", "from": "2022-01-01"}, http.StatusBadRequest, "", "", false},
+		"Incorrect Date":   {map[string]string{"to": "2022-4-12"}, http.StatusBadRequest, "", "", false},
+		"Before Snapshots": {map[string]string{"to": "2021-06-30"}, http.StatusNotFound, "", "", false},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			memoryStore := database.NewMemoryStore()
+			assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: []database.LedgerEntry{buy}, Snapshots: snapshots}))
+			store = memoryStore
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: testCase.params})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var report performance.Report
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &report))
+			assert.Equal(t, testCase.expectedFrom, report.From)
+			assert.Equal(t, types.MustParseDecimal(testCase.expectedTWR), report.Portfolio.TimeWeightedReturn)
+			assert.Equal(t, testCase.expectedAAPL, len(report.Symbols) == 1 && report.Symbols[0].Symbol == "AAPL")
+		})
+	}
+}
+
+// TestProcessDeposits checks that money deposited into the portfolio isn't reported as a gain.
+func TestProcessDeposits(t *testing.T) {
+	start, end := database.NewSnapshot("2021-12-31"), database.NewSnapshot("2022-04-12")
+	start.TotalValue, end.TotalValue = types.MustParseDecimal("1000"), types.MustParseDecimal("1500")
+	deposit := database.NewCashFlowEntry(types.NewCashFlow{Amount: types.MustParseDecimal("500")}, database.SideDeposit, "request-1", time.Date(2022, 1, 4, 15, 0, 0, 0, time.UTC))
+	memoryStore := database.NewMemoryStore()
+	assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: []database.LedgerEntry{deposit}, Snapshots: []database.PortfolioSnapshot{start, end}}))
+	store = memoryStore
+
+	response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var report performance.Report
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &report))
+	assert.Equal(t, types.MustParseDecimal("500"), report.Portfolio.NetInflow)
+	assert.Equal(t, types.MustParseDecimal("0"), report.Portfolio.TimeWeightedReturn)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process returns the daily snapshots of the portfolio's value, oldest first, for charting the portfolio over time. The optional
+// query parameters from & to (YYYY-MM-DD, inclusive) limit the series to a date range.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
+
+	if request.HTTPMethod != "GET" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: GET")
+	}
+
+	from, to := request.QueryStringParameters["from"], request.QueryStringParameters["to"]
+	if dateErr := lambdaHandler.ValidateDateRange(from, to); dateErr != nil {
+		log.Printf("Error reading query parameters: %v\n", dateErr)
+		return lambdaHandler.Response(http.StatusBadRequest, dateErr.Error())
+	}
+
+	snapshots, dbQueryErr := store.GetSnapshots(from, to)
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for portfolio snapshots: %v\n", dbQueryErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+	}
+
+	// Return an empty series, rather than null, when there are no snapshots in the range.
+	if snapshots == nil {
+		snapshots = []database.PortfolioSnapshot{}
+	}
+	return lambdaHandler.Response(http.StatusOK, snapshots)
+}
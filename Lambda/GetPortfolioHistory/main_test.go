@@ -0,0 +1,58 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess checks that the portfolio history is filtered by the request's date range, and returned oldest first.
+func TestProcess(t *testing.T) {
+	var snapshots []database.PortfolioSnapshot
+	for date, totalValue := range map[string]string{"2022-04-08": "1000", "2022-04-11": "1040", "2022-04-12": "1025.5"} {
+		snapshot := database.NewSnapshot(date)
+		snapshot.TotalValue, snapshot.Cash = types.MustParseDecimal(totalValue), types.MustParseDecimal("500")
+		snapshots = append(snapshots, snapshot)
+	}
+
+	tests := map[string]struct {
+		params         map[string]string
+		expectedStatus int
+		expectedDates  []string
+	}{
+		"Every Snapshot":  {map[string]string{}, http.StatusOK, []string{"2022-04-08", "2022-04-11", "2022-04-12"}},
+		"Filter By Date":  {map[string]string{"from": "2022-04-09", "to": "2022-04-11"}, http.StatusOK, []string{"2022-04-11"}},
+		"Open Ended":      {map[string]string{"from": "2022-04-11"}, http.StatusOK, []string{"2022-04-11", "2022-04-12"}},
+		"No Snapshots":    {map[string]string{"to": "2022-01-01"}, http.StatusOK, []string{}},
+		"Incorrect Date":  {map[string]string{"from": "2022-4-11"}, http.StatusBadRequest, nil},
+		"Backwards Range": {map[string]string{"from": "2022-04-12", "to": "2022-04-08"}, http.StatusBadRequest, nil},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			memoryStore := database.NewMemoryStore()
+			assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Snapshots: snapshots}))
+			store = memoryStore
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: testCase.params})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var history []database.PortfolioSnapshot
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &history))
+			dates := []string{}
+			for _, snapshot := range history {
+				dates = append(dates, snapshot.Date)
+			}
+			assert.Equal(t, testCase.expectedDates, dates)
+		})
+	}
+}
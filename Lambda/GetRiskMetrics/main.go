@@ -0,0 +1,90 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"Investing-API/common/risk"
+	"Investing-API/common/types"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+// getAdjustedBars fetches the daily bars of a symbol with their closes adjusted for splits & dividends. Unit tests replace it with
+// fixed bars.
+var getAdjustedBars = API.GetSymbolAdjustedBars
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process returns the volatility, Sharpe & Sortino ratios, maximum drawdown and beta of every open position, and of the whole
+// portfolio. Beta is measured against the BENCHMARK_SYMBOL, unless the optional benchmark query parameter names another symbol.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
+
+	if request.HTTPMethod != "GET" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: GET")
+	}
+
+	riskFreeRate, configErr := risk.RiskFreeRate()
+	if configErr != nil {
+		log.Printf("Error reading risk configuration: %v\n", configErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, configErr.Error())
+	}
+	benchmark := risk.BenchmarkSymbol()
+	if symbol, exists := request.QueryStringParameters["benchmark"]; exists && symbol != "" {
+		benchmark = symbol
+	}
+
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+	}
+
+	symbols := stockSymbols(openPositions)
+	if len(symbols) == 0 {
+		return lambdaHandler.Response(http.StatusNotFound, "no open stock positions to measure")
+	}
+
+	// Fetch the recent prices of every open position, and of the benchmark. The closes are adjusted for splits & dividends, so a
+	// split isn't measured as a fall in the price.
+	prices := make(map[string]risk.Prices)
+	for _, symbol := range append(symbols, benchmark) {
+		if _, fetched := prices[symbol]; fetched {
+			continue
+		}
+		bars, priceErr := getAdjustedBars(symbol, "")
+		if priceErr != nil {
+			log.Printf("Error fetching prices of %v: %v\n", symbol, priceErr)
+			return lambdaHandler.Response(lambdaHandler.PriceErrorStatus(priceErr), priceErr.Error())
+		}
+		prices[symbol] = types.AdjustedClosingPrices(bars)
+	}
+
+	report, reportErr := risk.BuildReport(openPositions, prices, benchmark, riskFreeRate)
+	if reportErr != nil {
+		log.Printf("Error measuring portfolio risk: %v\n", reportErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, reportErr.Error())
+	}
+
+	return lambdaHandler.Response(http.StatusOK, report)
+}
+
+// stockSymbols lists the symbol of every open position, apart from CASH.
+func stockSymbols(openPositions []database.OpenStockPosition) []string {
+	var symbols []string
+	for _, position := range openPositions {
+		if position.SK != "CASH" {
+			symbols = append(symbols, position.SK)
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"Investing-API/common/risk"
+	"Investing-API/common/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess checks that risk is measured for every open position against the configured benchmark.
+func TestProcess(t *testing.T) {
+	prices := map[string]map[string]types.Decimal{
+		"AAPL": {"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("110"), "2022-04-06": types.MustParseDecimal("99")},
+		"SPY":  {"2022-04-04": types.MustParseDecimal("400"), "2022-04-05": types.MustParseDecimal("404"), "2022-04-06": types.MustParseDecimal("400")},
+		"VUSA": {"2022-04-04": types.MustParseDecimal("60"), "2022-04-05": types.MustParseDecimal("61"), "2022-04-06": types.MustParseDecimal("60.5")},
+	}
+	getAdjustedBars = func(symbol, from string) ([]types.AdjustedBar, error) {
+		if symbol == "LIMITED" {
+			return nil, fmt.Errorf("%w: too many requests", API.ErrRateLimited)
+		}
+		symbolPrices, exists := prices[symbol]
+		if !exists {
+			return nil, fmt.Errorf("%w: %v", API.ErrUnknownSymbol, symbol)
+		}
+		var bars []types.AdjustedBar
+		for date, price := range symbolPrices {
+			bars = append(bars, types.AdjustedBar{Bar: types.Bar{Date: date, Close: price}, AdjustedClose: price})
+		}
+		types.SortAdjustedBars(bars)
+		return bars, nil
+	}
+	portfolio := []database.OpenStockPosition{
+		{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), Shares: types.MustParseDecimal("2")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("100"), CurrentValue: types.MustParseDecimal("100")},
+	}
+
+	tests := map[string]struct {
+		openPositions     []database.OpenStockPosition
+		params            map[string]string
+		riskFreeRate      string
+		expectedStatus    int
+		expectedBenchmark string
+	}{
+		"Default Benchmark": {portfolio, map[string]string{}, "", http.StatusOK, "SPY"},
+		"Chosen Benchmark":  {portfolio, map[string]string{"benchmark": "VUSA"}, "0.02", http.StatusOK, "VUSA"},
+		"Unknown Benchmark": {portfolio, map[string]string{"benchmark": "FTSE"}, "", http.StatusNotFound, ""},
+		"Rate Limited":      {portfolio, map[string]string{"benchmark": "LIMITED"}, "", http.StatusServiceUnavailable, ""},
+		"Only Cash":         {portfolio[1:], map[string]string{}, "", http.StatusNotFound, ""},
+		"Invalid Rate":      {portfolio, map[string]string{}, "two", http.StatusInternalServerError, ""},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = database.NewMemoryStore(testCase.openPositions...)
+			t.Setenv("BENCHMARK_SYMBOL", "")
+			t.Setenv("RISK_FREE_RATE", testCase.riskFreeRate)
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: testCase.params})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var report risk.Report
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &report))
+			assert.Equal(t, testCase.expectedBenchmark, report.Benchmark)
+			assert.Len(t, report.Positions, 1)
+			assert.NotNil(t, report.Positions[0].Beta)
+			assert.NotNil(t, report.Portfolio.Beta)
+		})
+	}
+}
+
+// TestProcessSplit checks that a 2-for-1 split isn't measured as a fall in the price, as risk is measured on adjusted closes.
+func TestProcessSplit(t *testing.T) {
+	getAdjustedBars = func(symbol, from string) ([]types.AdjustedBar, error) {
+		return []types.AdjustedBar{
+			{Bar: types.Bar{Date: "2022-04-04", Close: types.MustParseDecimal("200")}, AdjustedClose: types.MustParseDecimal("100")},
+			{Bar: types.Bar{Date: "2022-04-05", Close: types.MustParseDecimal("204")}, AdjustedClose: types.MustParseDecimal("102")},
+			{Bar: types.Bar{Date: "2022-04-06", Close: types.MustParseDecimal("103")}, AdjustedClose: types.MustParseDecimal("103"), SplitCoefficient: types.MustParseDecimal("2")},
+			{Bar: types.Bar{Date: "2022-04-07", Close: types.MustParseDecimal("104")}, AdjustedClose: types.MustParseDecimal("104")},
+		}, nil
+	}
+	store = database.NewMemoryStore(
+		database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), Shares: types.MustParseDecimal("2")},
+	)
+	t.Setenv("BENCHMARK_SYMBOL", "")
+	t.Setenv("RISK_FREE_RATE", "")
+
+	response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	var report risk.Report
+	assert.NoError(t, json.Unmarshal([]byte(response.Body), &report))
+	assert.Len(t, report.Positions, 1)
+	assert.True(t, report.Positions[0].MaxDrawdown.IsZero())
+	assert.True(t, report.Portfolio.MaxDrawdown.IsZero())
+}
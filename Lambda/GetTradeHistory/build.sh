@@ -0,0 +1,6 @@
+rm -rf dist
+mkdir dist
+env GOOS=linux go build -ldflags="-s -w" -o main .
+zip GetTradeHistory.zip main
+mv GetTradeHistory.zip ./dist/
+rm main
\ No newline at end of file
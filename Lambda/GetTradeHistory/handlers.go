@@ -0,0 +1,47 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultPageSize is the number of trades returned when the request doesn't set a limit.
+	defaultPageSize = 50
+
+	// maxPageSize is the largest number of trades which can be returned in a single page.
+	maxPageSize = 500
+)
+
+// buildLedgerQuery validates the request's query parameters, and converts them into a query for the trade ledger.
+func buildLedgerQuery(params map[string]string) (database.LedgerQuery, error) {
+	query := database.LedgerQuery{
+		EntryType: database.EntryTypeTrade,
+		Symbol:    params["symbol"],
+		From:      params["from"],
+		To:        params["to"],
+		Limit:     defaultPageSize,
+		Cursor:    params["cursor"],
+	}
+
+	for _, date := range []string{query.From, query.To} {
+		if _, dateErr := time.Parse("2006-01-02", date); date != "" && dateErr != nil {
+			return query, fmt.Errorf("incorrect date format. expecting YYYY-MM-DD, but got: %v", date)
+		}
+	}
+	if query.From != "" && query.To != "" && query.From > query.To {
+		return query, fmt.Errorf("from date %v is after to date %v", query.From, query.To)
+	}
+
+	if limit, exists := params["limit"]; exists {
+		pageSize, parseErr := strconv.ParseInt(limit, 10, 64)
+		if parseErr != nil || pageSize < 1 || pageSize > maxPageSize {
+			return query, fmt.Errorf("limit must be a number between 1 and %v, but got: %v", maxPageSize, limit)
+		}
+		query.Limit = pageSize
+	}
+
+	return query, nil
+}
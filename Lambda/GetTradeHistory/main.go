@@ -0,0 +1,42 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process returns a page of trades from the ledger. The optional query parameters are:
+// symbol, from & to (YYYY-MM-DD, inclusive), limit, and cursor (the NextCursor of the previous page).
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	log.Printf("Incoming request from: %v\n", request.RequestContext.Identity.SourceIP)
+
+	if request.HTTPMethod != "GET" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: GET")
+	}
+
+	query, queryErr := buildLedgerQuery(request.QueryStringParameters)
+	if queryErr != nil {
+		log.Printf("Error reading query parameters: %v\n", queryErr)
+		return lambdaHandler.Response(http.StatusBadRequest, queryErr.Error())
+	}
+
+	tradeHistory, dbQueryErr := store.GetLedgerEntries(query)
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for trade history: %v\n", dbQueryErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+	}
+
+	return lambdaHandler.Response(http.StatusOK, tradeHistory)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess checks that the trade history is filtered & paginated by the request's query parameters.
+func TestProcess(t *testing.T) {
+	trades := []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("150")}, database.SideBuy, "request-1", time.Date(2022, 1, 3, 14, 30, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "TSLA", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("900")}, database.SideBuy, "request-2", time.Date(2022, 1, 4, 15, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("170")}, database.SideSell, "request-3", time.Date(2022, 2, 1, 16, 0, 0, 0, time.UTC)),
+	}
+
+	tests := map[string]struct {
+		params             map[string]string
+		expectedStatus     int
+		expectedRequestIDs []string
+		expectNextPage     bool
+	}{
+		"All Trades":       {map[string]string{}, http.StatusOK, []string{"request-1", "request-2", "request-3"}, false},
+		"Filter By Symbol": {map[string]string{"symbol": "AAPL"}, http.StatusOK, []string{"request-1", "request-3"}, false},
+		"Filter By Date":   {map[string]string{"from": "2022-01-04", "to": "2022-02-01"}, http.StatusOK, []string{"request-2", "request-3"}, false},
+		"Single Day":       {map[string]string{"from": "2022-01-03", "to": "2022-01-03"}, http.StatusOK, []string{"request-1"}, false},
+		"First Page":       {map[string]string{"limit": "2"}, http.StatusOK, []string{"request-1", "request-2"}, true},
+		"Incorrect Date":   {map[string]string{"from": "2022-1-4"}, http.StatusBadRequest, nil, false},
+		"Incorrect Limit":  {map[string]string{"limit": "0"}, http.StatusBadRequest, nil, false},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			memoryStore := database.NewMemoryStore()
+			assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: trades}))
+			store = memoryStore
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: testCase.params})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+			if testCase.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var page database.LedgerPage
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &page))
+			var requestIDs []string
+			for _, entry := range page.Entries {
+				requestIDs = append(requestIDs, entry.RequestID)
+			}
+			assert.Equal(t, testCase.expectedRequestIDs, requestIDs)
+			assert.Equal(t, testCase.expectNextPage, page.NextCursor != "")
+		})
+	}
+}
+
+// TestPagination follows the cursor of each page until the whole trade history has been read.
+func TestPagination(t *testing.T) {
+	memoryStore := database.NewMemoryStore()
+	var transaction database.Transaction
+	for day := 1; day <= 5; day++ {
+		trade := types.NewStockTrade{Symbol: "AAPL", Quantity: types.DecimalFromInt(int64(day)), Price: types.MustParseDecimal("150")}
+		transaction.Ledger = append(transaction.Ledger, database.NewTradeEntry(trade, database.SideBuy, "", time.Date(2022, 3, day, 12, 0, 0, 0, time.UTC)))
+	}
+	assert.NoError(t, memoryStore.CommitTransaction(transaction))
+	store = memoryStore
+
+	var quantities []string
+	params := map[string]string{"limit": "2"}
+	for pages := 1; pages <= 5; pages++ {
+		response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: params})
+		assert.NoError(t, err)
+
+		var page database.LedgerPage
+		assert.NoError(t, json.Unmarshal([]byte(response.Body), &page))
+		for _, entry := range page.Entries {
+			quantities = append(quantities, entry.Quantity.String())
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params["cursor"] = page.NextCursor
+	}
+
+	assert.Equal(t, []string{"1", "2", "3", "4", "5"}, quantities)
+}
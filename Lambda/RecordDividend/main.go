@@ -0,0 +1,131 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"Investing-API/common/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+// now is the clock used to timestamp dividends. Unit tests replace it with a fixed time.
+var now = time.Now
+
+// getPrice looks up the closing price of a symbol on a date, or on its exchange's previous trading day if it was closed on the
+// date. Unit tests replace it with fixed prices.
+var getPrice = API.GetSymbolPriceOnOrBefore
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process credits CASH with a dividend received on a position, after withholding tax, and records it in the ledger. When the
+// dividend is reinvested, the net amount buys more shares of the symbol, the same as the BuyPosition Lambda.
+func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+
+	if request.HTTPMethod != "POST" {
+		return lambdaHandler.Response(http.StatusInternalServerError, "Incorrect HTTP method supplied. Need: POST")
+	}
+
+	var input = types.NewDividend{}
+	if unmarshallErr := json.Unmarshal([]byte(request.Body), &input); unmarshallErr != nil {
+		log.Printf("Error reading request body into struct: %v\n", unmarshallErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, unmarshallErr)
+	}
+
+	return lambdaHandler.RetryOnConflict(lambdaHandler.ConflictAttempts, func() (int, interface{}, error) {
+		return recordDividend(input, request.RequestContext.RequestID)
+	})
+}
+
+// recordDividend reads the portfolio, applies the dividend, and writes the result back to the database.
+// It is retried by lambdaHandler.RetryOnConflict when another request modifies the portfolio first.
+func recordDividend(input types.NewDividend, requestID string) (int, interface{}, error) {
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
+		return http.StatusInternalServerError, dbQueryErr, nil
+	}
+
+	// A dividend is only paid once per ex-date, so a second request for it is a mistake, or was already found by RevaluePortfolio.
+	recorded, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: database.EntryTypeDividend, Symbol: input.Symbol})
+	if ledgerErr != nil {
+		log.Printf("Error querying ledger for dividends of %v: %v\n", input.Symbol, ledgerErr)
+		return http.StatusInternalServerError, ledgerErr, nil
+	}
+	for _, entry := range recorded {
+		if entry.ExDate == input.ExDate {
+			log.Printf("Error - dividend of %v on %v already recorded by %v\n", input.Symbol, input.ExDate, entry.SK)
+			return http.StatusConflict, fmt.Sprintf("a dividend of %v with ex-date %v is already recorded", input.Symbol, input.ExDate), nil
+		}
+	}
+
+	// Shares sold since the ex-date still receive the dividend.
+	sells, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: database.EntryTypeTrade, Symbol: input.Symbol, From: input.ExDate})
+	if ledgerErr != nil {
+		log.Printf("Error querying ledger for trades of %v: %v\n", input.Symbol, ledgerErr)
+		return http.StatusInternalServerError, ledgerErr, nil
+	}
+
+	// Fill in the shares held, the exchange & the tax withheld, unless the request already gives them.
+	dividend, resolveErr := utils.ResolveDividend(openPositions, sells, input)
+	if resolveErr != nil {
+		log.Println(resolveErr)
+		return http.StatusBadRequest, resolveErr.Error(), nil
+	}
+	if validateErr := utils.ValidateDividend(dividend); validateErr != nil {
+		log.Println(validateErr)
+		return http.StatusBadRequest, validateErr.Error(), nil
+	}
+
+	// Without a reinvestment price, the shares are bought at the closing price on the day the dividend was paid.
+	if dividend.Reinvest && dividend.ReinvestPrice.IsZero() {
+		priceDate := dividend.PayDate
+		if priceDate == "" {
+			priceDate = dividend.ExDate
+		}
+		price, priceErr := getPrice(dividend.Symbol, priceDate)
+		if priceErr != nil {
+			log.Printf("Error fetching closing price of %v on %v: %v\n", dividend.Symbol, priceDate, priceErr)
+			return lambdaHandler.PriceErrorStatus(priceErr), priceErr.Error(), nil
+		}
+		dividend.ReinvestPrice = price
+	}
+
+	openPositions, ledgerEntries, dividendErr := utils.ApplyDividend(openPositions, dividend, requestID, now())
+	if dividendErr != nil {
+		log.Printf("Error applying dividend: %v\n", dividendErr)
+		return http.StatusInternalServerError, dividendErr.Error(), nil
+	}
+
+	// Update the position ratio's data.
+	updatedRecords := utils.CalculateMarketRatio(openPositions)
+
+	// Write the cash, any reinvested position, the ratio updates & the ledger entries to the DynamoDB table as a single transaction.
+	transaction := database.Transaction{
+		Puts:   updatedRecords,
+		Ledger: ledgerEntries,
+	}
+	if commitErr := store.CommitTransaction(transaction); commitErr != nil {
+		if errors.Is(commitErr, database.ErrVersionConflict) {
+			return http.StatusConflict, nil, commitErr
+		}
+		log.Printf("Error committing dividend to database: %v\n", commitErr)
+		return http.StatusInternalServerError, commitErr, nil
+	}
+
+	log.Println("Successfully recorded dividend!")
+	return http.StatusOK, "Successfully recorded dividend!", nil
+}
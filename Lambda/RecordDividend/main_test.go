@@ -0,0 +1,166 @@
+package main
+
+import (
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess records dividends against an in-memory portfolio and checks the stored positions & ledger afterwards.
+func TestProcess(t *testing.T) {
+	t.Setenv("WITHHOLDING_TAX", `{"NYSE": "0.15", "DEFAULT": "0"}`)
+	now = func() time.Time { return time.Date(2022, 5, 12, 14, 30, 0, 0, time.UTC) }
+	getPrice = func(symbol, date string) (types.Decimal, error) {
+		if symbol == "AAPL" && date == "2022-05-12" {
+			return types.MustParseDecimal("170"), nil
+		}
+		return types.Decimal{}, API.ErrNoDataForDate
+	}
+	const reinvestTime = "2022-05-12T14:30:00.000000001Z"
+	openPositions := []database.OpenStockPosition{
+		{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), PortfolioPercentage: types.MustParseDecimal("0.6"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("150"),
+			Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}}},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("400"), PortfolioPercentage: types.MustParseDecimal("0.4")},
+	}
+
+	tests := map[string]struct {
+		request           events.APIGatewayProxyRequest
+		expectedStatus    int
+		expectedPositions []database.OpenStockPosition
+		expectedEntries   int
+	}{
+		"Withholding Tax": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "PayDate": "2022-05-12", "AmountPerShare": 2.5}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), PortfolioPercentage: types.MustParseDecimal("0.5949"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("150"),
+					Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("408.5"), CurrentValue: types.MustParseDecimal("408.5"), PortfolioPercentage: types.MustParseDecimal("0.4051"), Version: 1},
+			},
+			1,
+		},
+		"Given Withholding Tax": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "AmountPerShare": 2.5, "WithholdingTax": 3}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), PortfolioPercentage: types.MustParseDecimal("0.5958"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("150"),
+					Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("407"), CurrentValue: types.MustParseDecimal("407"), PortfolioPercentage: types.MustParseDecimal("0.4042"), Version: 1},
+			},
+			1,
+		},
+		"Reinvest At Closing Price": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "PayDate": "2022-05-12", "AmountPerShare": 2.5, "Reinvest": true}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("608.5"), PortfolioPercentage: types.MustParseDecimal("0.6034"), AveragePrice: types.MustParseDecimal("150.25"), Shares: types.MustParseDecimal("4.05"), CurrentStockPrice: types.MustParseDecimal("150"),
+					Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}, {Acquired: reinvestTime, Quantity: types.MustParseDecimal("0.05"), Cost: types.MustParseDecimal("8.5")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("400"), PortfolioPercentage: types.MustParseDecimal("0.3966"), Version: 1},
+			},
+			2,
+		},
+		"Reinvest At Given Price": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "AmountPerShare": 2.5, "Reinvest": true, "ReinvestPrice": 200}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("608.4"), PortfolioPercentage: types.MustParseDecimal("0.6033"), AveragePrice: types.MustParseDecimal("150.52"), Shares: types.MustParseDecimal("4.042"), CurrentStockPrice: types.MustParseDecimal("150"),
+					Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}, {Acquired: reinvestTime, Quantity: types.MustParseDecimal("0.042"), Cost: types.MustParseDecimal("8.4")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("400.1"), CurrentValue: types.MustParseDecimal("400.1"), PortfolioPercentage: types.MustParseDecimal("0.3967"), Version: 1},
+			},
+			2,
+		},
+		"No Closing Price": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "AmountPerShare": 2.5, "Reinvest": true}`},
+			http.StatusNotFound,
+			openPositions,
+			0,
+		},
+		"No Shares Held": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "MSFT", "ExDate": "2022-05-18", "AmountPerShare": 0.62}`},
+			http.StatusBadRequest,
+			openPositions,
+			0,
+		},
+		"Negative Amount": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "AmountPerShare": -2.5}`},
+			http.StatusBadRequest,
+			openPositions,
+			0,
+		},
+		"Incorrect Ex-Date": {
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "06/05/2022", "AmountPerShare": 2.5}`},
+			http.StatusBadRequest,
+			openPositions,
+			0,
+		},
+		"Incorrect HTTP Method": {
+			events.APIGatewayProxyRequest{HTTPMethod: "GET"},
+			http.StatusInternalServerError,
+			openPositions,
+			0,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("QUANTITY_PRECISION", "3")
+			store = database.NewMemoryStore(copyPositions(openPositions)...)
+
+			response, err := Process(testCase.request)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, withKeys(testCase.expectedPositions), storedPositions)
+
+			ledger, _ := store.GetLedgerEntries(database.LedgerQuery{})
+			assert.Len(t, ledger.Entries, testCase.expectedEntries)
+		})
+	}
+}
+
+// TestProcessDuplicate checks that a dividend can only be recorded once for each ex-date.
+func TestProcessDuplicate(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 5, 12, 14, 30, 0, 0, time.UTC) }
+	store = database.NewMemoryStore(
+		database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4")},
+		database.OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("400")},
+	)
+	request := events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "ExDate": "2022-05-06", "AmountPerShare": 2.5}`}
+
+	response, err := Process(request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response, err = Process(request)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	cash, _ := store.GetOpenPosition("CASH")
+	assert.Equal(t, types.MustParseDecimal("410"), cash.PurchaseValue)
+}
+
+// copyPositions copies the test portfolio, so one test case's changes to its lots aren't seen by the next.
+func copyPositions(positions []database.OpenStockPosition) []database.OpenStockPosition {
+	copied := make([]database.OpenStockPosition, len(positions))
+	for index, position := range positions {
+		position.Lots = append([]database.Lot{}, position.Lots...)
+		copied[index] = position
+	}
+	return copied
+}
+
+// withKeys fills in the partition key the store adds to an unchanged portfolio.
+func withKeys(positions []database.OpenStockPosition) []database.OpenStockPosition {
+	keyed := copyPositions(positions)
+	for index := range keyed {
+		keyed[index].PK = "OPEN-POSITION"
+	}
+	return keyed
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"Investing-API/common/utils"
+	"log"
+	"sort"
+	"time"
+)
+
+// revaluePositions marks each stock position to market at its closing price on the date. CASH is always worth its own value.
+// A position without a closing price keeps its previous value, and is counted in the returned number of missing prices.
+func revaluePositions(openPositions []database.OpenStockPosition, date string) ([]database.OpenStockPosition, int) {
+	var missingPrices int
+	for index, position := range openPositions {
+		if position.SK == "CASH" {
+			openPositions[index].CurrentValue = position.PurchaseValue
+			continue
+		}
+
+		price, priceErr := getPrice(position.SK, date)
+		if priceErr != nil || price.Sign() <= 0 {
+			log.Printf("Error fetching closing price of %v on %v: %v\n", position.SK, date, priceErr)
+			missingPrices++
+			continue
+		}
+		openPositions[index] = utils.MarkToMarket(position, price)
+	}
+	return openPositions, missingPrices
+}
+
+// applyCorporateActions applies the splits & dividends which went ex since each stock position was bought, up to & including the
+// date, and haven't been applied to it yet, in date order. Splits rescale the position, and dividends credit CASH with their net
+// amount, reinvesting it at the date's closing price when REINVEST_DIVIDENDS is set. It returns the positions along with a ledger
+// entry for each action applied. A position whose corporate actions can't be fetched is left as it is, and checked again on the
+// next revaluation.
+func applyCorporateActions(openPositions []database.OpenStockPosition, date string) ([]database.OpenStockPosition, []database.LedgerEntry) {
+	var entries []database.LedgerEntry
+	appliedAt := now()
+	// Reinvesting a dividend can add a CASH position to the end of the portfolio, which is never checked for corporate actions.
+	for index := range openPositions {
+		symbol := openPositions[index].SK
+		if symbol == "CASH" {
+			continue
+		}
+
+		lastSplit, recordedDividends, ledgerErr := appliedActions(symbol)
+		if ledgerErr != nil {
+			log.Printf("Error querying ledger for corporate actions of %v: %v\n", symbol, ledgerErr)
+			continue
+		}
+		// Any dividend since the position was bought may still be pending, as dividends can be recorded by hand out of order.
+		actions, actionsErr := getCorporateActions(symbol, utils.AcquiredDate(openPositions[index]))
+		if actionsErr != nil {
+			log.Printf("Error fetching corporate actions of %v, not checking for splits or dividends: %v\n", symbol, actionsErr)
+			continue
+		}
+
+		pending := utils.PendingActions(openPositions[index], actions, types.ActionSplit, lastSplit, date)
+		for _, dividend := range utils.PendingActions(openPositions[index], actions, types.ActionDividend, "", date) {
+			if !recordedDividends[dividend.Date] {
+				pending = append(pending, dividend)
+			}
+		}
+		sort.SliceStable(pending, func(i, j int) bool {
+			return pending[i].Date < pending[j].Date
+		})
+		for _, action := range pending {
+			// Every entry of a revaluation gets its own timestamp, as the ledger is keyed by time.
+			at := appliedAt.Add(time.Duration(len(entries)))
+			if action.Type == types.ActionSplit {
+				openPositions[index] = utils.ApplySplit(openPositions[index], action)
+				entries = append(entries, database.NewCorporateActionEntry(action, openPositions[index].Shares, at))
+				log.Printf("Applied %v-for-1 split of %v on %v, now holding %v shares\n", action.Ratio, symbol, action.Date, openPositions[index].Shares)
+				continue
+			}
+
+			var dividendEntries []database.LedgerEntry
+			openPositions, dividendEntries = applyDividend(openPositions, action, date, at)
+			entries = append(entries, dividendEntries...)
+		}
+	}
+	return openPositions, entries
+}
+
+// applyDividend credits CASH with a dividend found in the price data, and reinvests it at the date's closing price when
+// REINVEST_DIVIDENDS is set. A dividend which can't be reinvested is still credited to CASH.
+func applyDividend(openPositions []database.OpenStockPosition, action types.CorporateAction, date string, at time.Time) ([]database.OpenStockPosition, []database.LedgerEntry) {
+	// Shares sold since the ex-date still receive the dividend.
+	sells, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: database.EntryTypeTrade, Symbol: action.Symbol, From: action.Date})
+	if ledgerErr != nil {
+		log.Printf("Error querying ledger for trades of %v, not applying its dividend on %v: %v\n", action.Symbol, action.Date, ledgerErr)
+		return openPositions, nil
+	}
+	dividend, resolveErr := utils.ResolveDividend(openPositions, sells, types.NewDividend{
+		Symbol:         action.Symbol,
+		ExDate:         action.Date,
+		AmountPerShare: action.Amount,
+		Reinvest:       utils.ReinvestDividends(),
+	})
+	if resolveErr != nil {
+		log.Printf("Error resolving dividend of %v on %v, not applying it: %v\n", action.Symbol, action.Date, resolveErr)
+		return openPositions, nil
+	}
+	if dividend.Reinvest {
+		price, priceErr := getPrice(dividend.Symbol, date)
+		if priceErr != nil || price.Sign() <= 0 {
+			log.Printf("Error fetching closing price of %v on %v, not reinvesting its dividend: %v\n", dividend.Symbol, date, priceErr)
+			dividend.Reinvest = false
+		} else {
+			dividend.ReinvestPrice = price
+		}
+	}
+
+	openPositions, entries, dividendErr := utils.ApplyDividend(openPositions, dividend, "", at)
+	if dividendErr != nil {
+		log.Printf("Error reinvesting dividend of %v on %v, crediting it to CASH: %v\n", dividend.Symbol, dividend.ExDate, dividendErr)
+	}
+	log.Printf("Applied %v dividend of %v on %v, %v after withholding tax\n", dividend.Gross(), dividend.Symbol, dividend.ExDate, dividend.Net())
+	return openPositions, entries
+}
+
+// appliedActions returns the ex-date (YYYY-MM-DD) of the latest split applied to a symbol, or an empty date if none have been, along
+// with the ex-date of every dividend recorded for it, whether found in the price data or recorded by the RecordDividend Lambda.
+func appliedActions(symbol string) (string, map[string]bool, error) {
+	splits, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: database.EntryTypeCorporateAction, Symbol: symbol})
+	if ledgerErr != nil {
+		return "", nil, ledgerErr
+	}
+	dividends, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: database.EntryTypeDividend, Symbol: symbol})
+	if ledgerErr != nil {
+		return "", nil, ledgerErr
+	}
+
+	var lastSplit string
+	for _, entry := range splits {
+		if entry.Action == types.ActionSplit && entry.ExDate > lastSplit {
+			lastSplit = entry.ExDate
+		}
+	}
+	recordedDividends := make(map[string]bool, len(dividends))
+	for _, entry := range dividends {
+		recordedDividends[entry.ExDate] = true
+	}
+	return lastSplit, recordedDividends, nil
+}
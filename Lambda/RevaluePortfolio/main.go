@@ -0,0 +1,93 @@
+package main
+
+import (
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"Investing-API/common/utils"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+var store database.PortfolioStore
+
+// now is the clock used to pick the day to revalue. Unit tests replace it with a fixed time.
+var now = time.Now
+
+// getPrice looks up the closing price of a symbol on a date, or on its exchange's previous trading day if it was closed on the
+// date. Unit tests replace it with fixed prices.
+var getPrice = API.GetSymbolPriceOnOrBefore
+
+// getCorporateActions looks up the splits & dividends of a symbol from a date. Unit tests replace it with fixed actions.
+var getCorporateActions = API.GetCorporateActions
+
+// maxRevalueAttempts is how many times the revaluation is attempted when a trade modifies the portfolio at the same time.
+const maxRevalueAttempts = 3
+
+func main() {
+	store = database.NewDynamoStore(database.Login())
+	lambda.Start(Process)
+}
+
+// Process marks every open position to market at the previous day's closing price. It is run each night by an EventBridge
+// schedule, e.g. cron(0 6 * * ? *), and does nothing on days which follow a day every market was closed, such as a weekend or a
+// holiday in both New York & London.
+func Process(event events.CloudWatchEvent) error {
+	today := now()
+	if !utils.CanRun(today) {
+		log.Printf("Market was closed yesterday, skipping revaluation on %v\n", today.Format("2006-01-02"))
+		return nil
+	}
+	date := utils.GetYesterdaysDate(today)
+
+	// Re-read the portfolio and retry if a trade changes it before the revaluation is written.
+	for attempt := 1; ; attempt++ {
+		revalueErr := revaluePortfolio(date)
+		if errors.Is(revalueErr, database.ErrVersionConflict) && attempt < maxRevalueAttempts {
+			log.Printf("Portfolio modified during revaluation, retrying (attempt %v): %v\n", attempt, revalueErr)
+			continue
+		}
+		return revalueErr
+	}
+}
+
+// revaluePortfolio reads the portfolio, applies any splits & dividends since the last revaluation, values each position at its
+// closing price on the date, and writes every position back to the database, along with a snapshot of the portfolio's value and a
+// ledger entry for each split & dividend, as a single transaction.
+func revaluePortfolio(date string) error {
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
+	if dbQueryErr != nil {
+		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
+		return dbQueryErr
+	}
+	if len(openPositions) == 0 {
+		log.Println("No open positions to revalue")
+		return nil
+	}
+
+	// Splits are applied before revaluing, so a closing price after a split is matched with the share count after the split.
+	adjusted, actionEntries := applyCorporateActions(openPositions, date)
+
+	// The day's snapshot is written with the revalued positions, so the portfolio history always matches the stored portfolio.
+	revalued, missingPrices := revaluePositions(adjusted, date)
+	transaction := database.Transaction{
+		Puts:      utils.CalculateMarketRatio(revalued),
+		Ledger:    actionEntries,
+		Snapshots: []database.PortfolioSnapshot{utils.BuildSnapshot(revalued, date)},
+	}
+	if commitErr := store.CommitTransaction(transaction); commitErr != nil {
+		log.Printf("Error committing revaluation to database: %v\n", commitErr)
+		return commitErr
+	}
+
+	// The revaluation is already written, so a missing price is only logged: returning an error would make EventBridge run it again.
+	if missingPrices > 0 {
+		log.Printf("Error - revalued portfolio for %v, but %v positions have no closing price and kept their previous value\n", date, missingPrices)
+		return nil
+	}
+	log.Printf("Successfully revalued portfolio at the closing prices of %v!\n", date)
+	return nil
+}
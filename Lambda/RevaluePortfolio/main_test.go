@@ -0,0 +1,245 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess revalues an in-memory portfolio at fixed closing prices, and checks the stored positions afterwards.
+func TestProcess(t *testing.T) {
+	tuesday := time.Date(2022, 4, 12, 6, 0, 0, 0, time.UTC)
+	monday := time.Date(2022, 4, 11, 6, 0, 0, 0, time.UTC)
+	afterGoodFriday := time.Date(2022, 4, 16, 6, 0, 0, 0, time.UTC)
+
+	openPositions := []database.OpenStockPosition{
+		{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.5")},
+		{SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("280"), PortfolioPercentage: types.MustParseDecimal("0.3"), AveragePrice: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("280")},
+	}
+
+	tests := map[string]struct {
+		today              time.Time
+		closingPrices      map[string]string
+		expectedPositions  []database.OpenStockPosition
+		expectedTotalValue string // Empty when no snapshot should be written.
+	}{
+		"Revalue Positions": {
+			tuesday,
+			map[string]string{"AAPL": "150", "TSLA": "240"},
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("300"), PortfolioPercentage: types.MustParseDecimal("0.2885"), AveragePrice: types.MustParseDecimal("100"), PercentageReturn: types.MustParseDecimal("0.5"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("150"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.4808"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("240"), PortfolioPercentage: types.MustParseDecimal("0.2308"), AveragePrice: types.MustParseDecimal("300"), PercentageReturn: types.MustParseDecimal("-0.2"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("240"), Version: 1},
+			},
+			"1040",
+		},
+		"Missing Price": {
+			tuesday,
+			map[string]string{"AAPL": "150"},
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("300"), PortfolioPercentage: types.MustParseDecimal("0.2778"), AveragePrice: types.MustParseDecimal("100"), PercentageReturn: types.MustParseDecimal("0.5"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("150"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.463"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("280"), PortfolioPercentage: types.MustParseDecimal("0.2593"), AveragePrice: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("280"), Version: 1},
+			},
+			"1080",
+		},
+		"Market Closed Yesterday": {
+			monday,
+			map[string]string{"AAPL": "150", "TSLA": "240"},
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100")},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.5")},
+				{PK: "OPEN-POSITION", SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("280"), PortfolioPercentage: types.MustParseDecimal("0.3"), AveragePrice: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("280")},
+			},
+			"",
+		},
+		"Market Holiday Yesterday": {
+			afterGoodFriday,
+			map[string]string{"AAPL": "150", "TSLA": "240"},
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100")},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.5")},
+				{PK: "OPEN-POSITION", SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("280"), PortfolioPercentage: types.MustParseDecimal("0.3"), AveragePrice: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("280")},
+			},
+			"",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = database.NewMemoryStore(openPositions...)
+			now = func() time.Time { return testCase.today }
+			getPrice = func(symbol, date string) (types.Decimal, error) {
+				price, exists := testCase.closingPrices[symbol]
+				if !exists || date != "2022-04-11" {
+					return types.Decimal{}, fmt.Errorf("no price data for %v on %v", symbol, date)
+				}
+				return types.MustParseDecimal(price), nil
+			}
+			getCorporateActions = func(symbol, from string) ([]types.CorporateAction, error) {
+				return []types.CorporateAction{}, nil
+			}
+
+			assert.NoError(t, Process(events.CloudWatchEvent{}))
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+
+			snapshots, _ := store.GetSnapshots("", "")
+			if testCase.expectedTotalValue == "" {
+				assert.Empty(t, snapshots)
+				return
+			}
+			assert.Len(t, snapshots, 1)
+			assert.Equal(t, "2022-04-11", snapshots[0].Date)
+			assert.Equal(t, types.MustParseDecimal(testCase.expectedTotalValue), snapshots[0].TotalValue)
+		})
+	}
+}
+
+// TestProcessSplit checks that a split since the last revaluation rescales the position before it's revalued, is recorded in the
+// ledger, and isn't applied again on the next revaluation.
+func TestProcessSplit(t *testing.T) {
+	store = database.NewMemoryStore(
+		database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("680"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("170"),
+			Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}}},
+		database.OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("400")},
+	)
+	getPrice = func(symbol, date string) (types.Decimal, error) {
+		return map[string]types.Decimal{"2022-04-11": types.MustParseDecimal("42.5"), "2022-04-12": types.MustParseDecimal("43")}[date], nil
+	}
+	var fetchedFrom []string
+	getCorporateActions = func(symbol, from string) ([]types.CorporateAction, error) {
+		fetchedFrom = append(fetchedFrom, from)
+		return []types.CorporateAction{
+			{Symbol: "AAPL", Date: "2020-08-31", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")},
+			{Symbol: "AAPL", Date: "2022-04-11", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")},
+		}, nil
+	}
+
+	for _, today := range []time.Time{time.Date(2022, 4, 12, 6, 0, 0, 0, time.UTC), time.Date(2022, 4, 13, 6, 0, 0, 0, time.UTC)} {
+		now = func() time.Time { return today }
+		assert.NoError(t, Process(events.CloudWatchEvent{}))
+	}
+	// No dividend has been applied, so the second revaluation still checks for dividends since the position was bought.
+	assert.Equal(t, []string{"2022-03-01", "2022-03-01"}, fetchedFrom)
+
+	aapl, _ := store.GetOpenPosition("AAPL")
+	assert.Equal(t, types.MustParseDecimal("16"), aapl.Shares)
+	assert.Equal(t, types.MustParseDecimal("37.5"), aapl.AveragePrice)
+	assert.Equal(t, types.MustParseDecimal("688"), aapl.CurrentValue)
+	assert.Equal(t, types.MustParseDecimal("0.1467"), aapl.PercentageReturn)
+	assert.Equal(t, types.MustParseDecimal("16"), aapl.Lots[0].Quantity)
+
+	page, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeCorporateAction})
+	assert.Len(t, page.Entries, 1)
+	assert.Equal(t, "2022-04-11", page.Entries[0].ExDate)
+	assert.Equal(t, types.ActionSplit, page.Entries[0].Action)
+	assert.Equal(t, types.MustParseDecimal("4"), page.Entries[0].Ratio)
+	assert.Equal(t, types.MustParseDecimal("16"), page.Entries[0].Quantity)
+}
+
+// TestProcessDividend checks that a dividend since the last revaluation is credited to CASH after withholding tax, reinvested when
+// REINVEST_DIVIDENDS is set, recorded in the ledger, and isn't applied again on the next revaluation.
+func TestProcessDividend(t *testing.T) {
+	tests := map[string]struct {
+		reinvest     string
+		expectedCash types.Decimal
+		expectedAAPL types.Decimal
+		expectedLogs int
+	}{
+		"Credit Cash": {
+			reinvest:     "",
+			expectedCash: types.MustParseDecimal("408.5"),
+			expectedAAPL: types.MustParseDecimal("4"),
+			expectedLogs: 1,
+		},
+		"Reinvest": {
+			reinvest:     "true",
+			expectedCash: types.MustParseDecimal("400"),
+			expectedAAPL: types.MustParseDecimal("4.05"),
+			expectedLogs: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("QUANTITY_PRECISION", "2")
+			t.Setenv("WITHHOLDING_TAX", `{"NYSE": "0.15"}`)
+			t.Setenv("REINVEST_DIVIDENDS", test.reinvest)
+			store = database.NewMemoryStore(
+				database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("680"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("170"),
+					Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}}},
+				database.OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("400")},
+			)
+			getPrice = func(symbol, date string) (types.Decimal, error) {
+				return types.MustParseDecimal("170"), nil
+			}
+			getCorporateActions = func(symbol, from string) ([]types.CorporateAction, error) {
+				return []types.CorporateAction{
+					{Symbol: "AAPL", Date: "2022-02-04", Type: types.ActionDividend, Amount: types.MustParseDecimal("0.22")},
+					{Symbol: "AAPL", Date: "2022-05-06", Type: types.ActionDividend, Amount: types.MustParseDecimal("2.5")},
+				}, nil
+			}
+
+			for _, today := range []time.Time{time.Date(2022, 5, 7, 6, 0, 0, 0, time.UTC), time.Date(2022, 5, 10, 6, 0, 0, 0, time.UTC)} {
+				now = func() time.Time { return today }
+				assert.NoError(t, Process(events.CloudWatchEvent{}))
+			}
+
+			aapl, _ := store.GetOpenPosition("AAPL")
+			cash, _ := store.GetOpenPosition("CASH")
+			assert.Equal(t, test.expectedAAPL, aapl.Shares)
+			assert.Equal(t, test.expectedCash, cash.PurchaseValue)
+
+			dividends, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeDividend})
+			assert.Len(t, dividends.Entries, 1)
+			assert.Equal(t, "2022-05-06", dividends.Entries[0].ExDate)
+			assert.Equal(t, types.MustParseDecimal("8.5"), dividends.Entries[0].Amount)
+			assert.Equal(t, types.MustParseDecimal("1.5"), dividends.Entries[0].WithholdingTax)
+
+			all, _ := store.GetLedgerEntries(database.LedgerQuery{})
+			assert.Len(t, all.Entries, test.expectedLogs)
+		})
+	}
+}
+
+// TestProcessDividendRecordedByHand checks that a dividend recorded by hand with a later ex-date doesn't stop an earlier dividend
+// from being applied, and isn't applied a second time itself.
+func TestProcessDividendRecordedByHand(t *testing.T) {
+	t.Setenv("WITHHOLDING_TAX", `{"NYSE": "0"}`)
+	t.Setenv("REINVEST_DIVIDENDS", "")
+	memoryStore := database.NewMemoryStore(
+		database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("680"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("170"),
+			Lots: []database.Lot{{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("600")}}},
+		database.OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("410"), CurrentValue: types.MustParseDecimal("410")},
+	)
+	recorded := database.NewDividendEntry(types.NewDividend{Symbol: "AAPL", ExDate: "2022-08-05", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("4")}, "request-1", time.Date(2022, 8, 6, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, memoryStore.CommitTransaction(database.Transaction{Ledger: []database.LedgerEntry{recorded}}))
+	store = memoryStore
+	now = func() time.Time { return time.Date(2022, 8, 9, 6, 0, 0, 0, time.UTC) }
+	getPrice = func(symbol, date string) (types.Decimal, error) {
+		return types.MustParseDecimal("170"), nil
+	}
+	getCorporateActions = func(symbol, from string) ([]types.CorporateAction, error) {
+		return []types.CorporateAction{
+			{Symbol: "AAPL", Date: "2022-05-06", Type: types.ActionDividend, Amount: types.MustParseDecimal("0.5")},
+			{Symbol: "AAPL", Date: "2022-08-05", Type: types.ActionDividend, Amount: types.MustParseDecimal("2.5")},
+		}, nil
+	}
+
+	assert.NoError(t, Process(events.CloudWatchEvent{}))
+
+	cash, _ := store.GetOpenPosition("CASH")
+	assert.Equal(t, types.MustParseDecimal("412"), cash.PurchaseValue)
+
+	dividends, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeDividend})
+	assert.Len(t, dividends.Entries, 2)
+	assert.Equal(t, "2022-05-06", dividends.Entries[1].ExDate)
+}
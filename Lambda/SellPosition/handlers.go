@@ -1,6 +1,9 @@
 package main
 
-import "Investing-API/common/database"
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+)
 
 // getPositionOfInterest looks in a slice of portfolio positions for a specific symbol.
 func getPositionOfInterest(openPositions []database.OpenStockPosition, symbol string) (database.OpenStockPosition, uint, bool) {
@@ -11,3 +14,15 @@ func getPositionOfInterest(openPositions []database.OpenStockPosition, symbol st
 	}
 	return database.OpenStockPosition{}, 0, false
 }
+
+// recalculateCashValue adds the proceeds of the trade to the cash held in the portfolio.
+func recalculateCashValue(openPositions []database.OpenStockPosition, tradeValue types.Decimal) []database.OpenStockPosition {
+	for index, position := range openPositions {
+		if position.SK == "CASH" {
+			var newValue = position.PurchaseValue.Add(tradeValue)
+			openPositions[index].PurchaseValue = newValue
+			openPositions[index].CurrentValue = newValue
+		}
+	}
+	return openPositions
+}
@@ -6,15 +6,29 @@ import (
 	"Investing-API/common/types"
 	"Investing-API/common/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+var store database.PortfolioStore
+
+// now is the clock used to timestamp trades. Unit tests replace it with a fixed time.
+var now = time.Now
+
+// saleResponse is returned to the user after a successful sell.
+type saleResponse struct {
+	Message string `json:"Message"`
+	utils.SaleResult
+}
+
 func main() {
+	store = database.NewDynamoStore(database.Login())
 	lambda.Start(Process)
 }
 
@@ -30,11 +44,39 @@ func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResp
 		return lambdaHandler.Response(http.StatusInternalServerError, unmarshallErr)
 	}
 
-	svc := database.Login()
-	openPositions, dbQueryErr := database.GetAllOpenPositions(svc)
+	// Fractional quantities are allowed, up to the configured number of decimal places.
+	if quantityErr := utils.ValidateQuantity(input.Quantity); quantityErr != nil {
+		log.Println(quantityErr)
+		return lambdaHandler.Response(http.StatusBadRequest, quantityErr.Error())
+	}
+	if input.Price.Sign() <= 0 {
+		log.Printf("Error - price must be more than 0, but got: %v\n", input.Price)
+		return lambdaHandler.Response(http.StatusBadRequest, fmt.Sprintf("price must be more than 0, but got: %v", input.Price))
+	}
+	if feeErr := utils.ValidateFees(input); feeErr != nil {
+		log.Println(feeErr)
+		return lambdaHandler.Response(http.StatusBadRequest, feeErr.Error())
+	}
+
+	// Fill in the trade's fees from the fee schedule of its exchange, unless the request already gives them.
+	input, scheduleErr := utils.ApplyFeeSchedule(input, database.SideSell)
+	if scheduleErr != nil {
+		log.Printf("Error applying fee schedule: %v\n", scheduleErr)
+		return lambdaHandler.Response(http.StatusInternalServerError, scheduleErr.Error())
+	}
+
+	return lambdaHandler.RetryOnConflict(lambdaHandler.ConflictAttempts, func() (int, interface{}, error) {
+		return executeTrade(input, request.RequestContext.RequestID)
+	})
+}
+
+// executeTrade reads the portfolio, applies the trade, and writes the result back to the database.
+// It is retried by lambdaHandler.RetryOnConflict when another request modifies the portfolio first.
+func executeTrade(input types.NewStockTrade, requestID string) (int, interface{}, error) {
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
 	if dbQueryErr != nil {
 		log.Printf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
-		return lambdaHandler.Response(http.StatusInternalServerError, dbQueryErr)
+		return http.StatusInternalServerError, dbQueryErr, nil
 	}
 
 	// Look for the specified trade in the portfolio.
@@ -42,45 +84,56 @@ func Process(request events.APIGatewayProxyRequest) (*events.APIGatewayProxyResp
 	if !exists {
 		var errMsg = fmt.Sprintf("Cannot find %v in the portfolio", input.Symbol)
 		log.Println(errMsg)
-		return lambdaHandler.Response(http.StatusInternalServerError, errMsg)
+		return http.StatusInternalServerError, errMsg, nil
 	}
 
 	// Check that the user isn't requesting to sell more shares than they own.
-	if input.Quantity > queryPosition.Shares {
+	if input.Quantity.Cmp(queryPosition.Shares) > 0 {
 		var errMsg = fmt.Sprintf("Cannot sell more shares than you own. You have %v shares in your account", queryPosition.Shares)
 		log.Println(errMsg)
-		return lambdaHandler.Response(http.StatusBadRequest, errMsg)
+		return http.StatusBadRequest, errMsg, nil
+	}
+
+	lotMethod, methodErr := utils.ResolveLotMethod(input.LotMethod)
+	if methodErr != nil {
+		log.Println(methodErr)
+		return http.StatusBadRequest, methodErr.Error(), nil
 	}
 
-	// Calculate the value of the trade
-	sellPrice := utils.RoundToPrecision(input.Price*float64(input.Quantity), 2)
+	// Match the sold shares against the position's lots, to find their cost basis & the realized gain or loss.
+	tradeEntry := database.NewTradeEntry(input, database.SideSell, requestID, now())
+	remainingPosition, sale, sellErr := utils.SellFromLots(queryPosition, input, lotMethod, tradeEntry.Timestamp)
+	if sellErr != nil {
+		log.Println(sellErr)
+		return http.StatusBadRequest, sellErr.Error(), nil
+	}
+	tradeEntry.LotMethod = sale.LotMethod
+	tradeEntry.CostBasis = sale.CostBasis
+	tradeEntry.RealizedPnL = sale.RealizedPnL
+	tradeEntry.LotsSold = sale.LotsSold
 
 	// If the user is selling all their shares, delete the record. Otherwise, update the record.
-	if input.Quantity == queryPosition.Shares {
-		if deleteErr := database.DeleteOpenPosition(svc, queryPosition); deleteErr != nil {
-			log.Printf("Error removing position from portfolio: %v\n", deleteErr)
-			return lambdaHandler.Response(http.StatusInternalServerError, deleteErr)
-		}
+	var transaction database.Transaction
+	if remainingPosition.Shares.IsZero() {
+		transaction.Deletes = append(transaction.Deletes, queryPosition)
 		openPositions = utils.RemovePositionFromPortfolio(openPositions, positionIndex)
 	} else {
-		openPositions[positionIndex].PurchaseValue = queryPosition.PurchaseValue - sellPrice
-		openPositions[positionIndex].Shares = queryPosition.Shares - input.Quantity
+		openPositions[positionIndex] = remainingPosition
 	}
 
-	// Update each position's ratio's data.
-	updatedRecords := utils.CalculatePortfolioRatio(openPositions)
+	// Add the trade proceeds (after fees) to the cash value, and update each position's ratio's data.
+	transaction.Puts = utils.CalculateMarketRatio(recalculateCashValue(openPositions, sale.Proceeds))
 
-	// Update each portfolio position in the database.
-	for index, position := range updatedRecords {
-		if position.SK == "CASH" {
-			openPositions[index].PurchaseValue = position.PurchaseValue + sellPrice
-		}
-		if updateErr := database.UpdateOpenPosition(svc, openPositions[index]); updateErr != nil {
-			log.Printf("Error updating %v record: %v\n", position.SK, updateErr)
-			return lambdaHandler.Response(http.StatusInternalServerError, updateErr)
+	// Write the position, the cash, the ratio updates & the ledger entry to the DynamoDB table as a single transaction.
+	transaction.Ledger = append(transaction.Ledger, tradeEntry)
+	if commitErr := store.CommitTransaction(transaction); commitErr != nil {
+		if errors.Is(commitErr, database.ErrVersionConflict) {
+			return http.StatusConflict, nil, commitErr
 		}
+		log.Printf("Error committing trade to database: %v\n", commitErr)
+		return http.StatusInternalServerError, commitErr, nil
 	}
 
 	log.Println("Successfully sold stock position!")
-	return lambdaHandler.Response(http.StatusOK, "Successfully sold stock position!")
+	return http.StatusOK, saleResponse{Message: "Successfully sold stock position!", SaleResult: sale}, nil
 }
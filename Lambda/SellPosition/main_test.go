@@ -0,0 +1,191 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess runs sell requests against an in-memory portfolio and checks the stored positions afterwards.
+func TestProcess(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 4, 13, 14, 30, 0, 0, time.UTC) }
+
+	var (
+		januaryBuy       = "2022-01-04T15:00:00.000000000Z"
+		marchBuy         = "2022-03-01T15:00:00.000000000Z"
+		startingPosition = database.OpenStockPosition{
+			PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("400"), PortfolioPercentage: types.MustParseDecimal("0.4"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("100"),
+			Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("150")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("250")}},
+		}
+		startingCash = database.OpenStockPosition{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("600"), PortfolioPercentage: types.MustParseDecimal("0.6")}
+	)
+
+	tests := map[string]struct {
+		openPositions       []database.OpenStockPosition
+		request             events.APIGatewayProxyRequest
+		expectedStatus      int
+		expectedRealizedPnL types.Decimal
+		expectedPositions   []database.OpenStockPosition
+	}{
+		"Partial Sell FIFO": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 100}`},
+			http.StatusOK,
+			types.MustParseDecimal("25"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("325"), PortfolioPercentage: types.MustParseDecimal("0.3171"), AveragePrice: types.MustParseDecimal("108.33"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("75")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("250")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("700"), CurrentValue: types.MustParseDecimal("700"), PortfolioPercentage: types.MustParseDecimal("0.6829"), Version: 1},
+			},
+		},
+		"Ratio By Market Value": {
+			[]database.OpenStockPosition{startingCash, {
+				PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("480"), PercentageReturn: types.MustParseDecimal("0.2"), PortfolioPercentage: types.MustParseDecimal("0.4444"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("120"),
+				Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("150")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("250")}},
+			}},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 120}`},
+			http.StatusOK,
+			types.MustParseDecimal("45"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("325"), CurrentValue: types.MustParseDecimal("360"), PercentageReturn: types.MustParseDecimal("0.1077"), PortfolioPercentage: types.MustParseDecimal("0.3333"), AveragePrice: types.MustParseDecimal("108.33"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("120"),
+					Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("75")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("250")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("720"), CurrentValue: types.MustParseDecimal("720"), PortfolioPercentage: types.MustParseDecimal("0.6667"), Version: 1},
+			},
+		},
+		"Partial Sell LIFO": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 100, "LotMethod": "LIFO"}`},
+			http.StatusOK,
+			types.MustParseDecimal("-25"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("275"), PortfolioPercentage: types.MustParseDecimal("0.2821"), AveragePrice: types.MustParseDecimal("91.67"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("150")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("125")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("700"), CurrentValue: types.MustParseDecimal("700"), PortfolioPercentage: types.MustParseDecimal("0.7179"), Version: 1},
+			},
+		},
+		"Partial Sell Average Cost": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 100, "LotMethod": "AVERAGE"}`},
+			http.StatusOK,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("300"), PortfolioPercentage: types.MustParseDecimal("0.3"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("100")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("700"), CurrentValue: types.MustParseDecimal("700"), PortfolioPercentage: types.MustParseDecimal("0.7"), Version: 1},
+			},
+		},
+		"Sell Entire Position": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 4, "Price": 120}`},
+			http.StatusOK,
+			types.MustParseDecimal("80"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1080"), CurrentValue: types.MustParseDecimal("1080"), PortfolioPercentage: types.MustParseDecimal("1"), Version: 1},
+			},
+		},
+		"Sell More Than Owned": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 5, "Price": 100}`},
+			http.StatusBadRequest,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{startingPosition, startingCash},
+		},
+		"Sell Fractional Shares": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 0.5, "Price": 100}`},
+			http.StatusOK,
+			types.MustParseDecimal("12.5"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("362.5"), PortfolioPercentage: types.MustParseDecimal("0.358"), AveragePrice: types.MustParseDecimal("103.57"), Shares: types.MustParseDecimal("3.5"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1.5"), Cost: types.MustParseDecimal("112.5")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("250")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("650"), CurrentValue: types.MustParseDecimal("650"), PortfolioPercentage: types.MustParseDecimal("0.642"), Version: 1},
+			},
+		},
+		"Sell With Fees": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 100, "Commission": 5}`},
+			http.StatusOK,
+			types.MustParseDecimal("20"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("325"), PortfolioPercentage: types.MustParseDecimal("0.3186"), AveragePrice: types.MustParseDecimal("108.33"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("75")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("250")}}, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("695"), CurrentValue: types.MustParseDecimal("695"), PortfolioPercentage: types.MustParseDecimal("0.6814"), Version: 1},
+			},
+		},
+		"Sell A Fraction More Than Owned": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 4.00000001, "Price": 100}`},
+			http.StatusBadRequest,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{startingPosition, startingCash},
+		},
+		"Too Many Decimal Places": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 0.000000001, "Price": 100}`},
+			http.StatusBadRequest,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{startingPosition, startingCash},
+		},
+		"Zero Price": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 0}`},
+			http.StatusBadRequest,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{startingPosition, startingCash},
+		},
+		"Negative Price": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": -100}`},
+			http.StatusBadRequest,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{startingPosition, startingCash},
+		},
+		"Unknown Lot Method": {
+			[]database.OpenStockPosition{startingCash, startingPosition},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "AAPL", "Quantity": 1, "Price": 100, "LotMethod": "HIFO"}`},
+			http.StatusBadRequest,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{startingPosition, startingCash},
+		},
+		"Symbol Not Held": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Symbol": "TSLA", "Quantity": 1, "Price": 100}`},
+			http.StatusInternalServerError,
+			types.MustParseDecimal("0"),
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = database.NewMemoryStore(testCase.openPositions...)
+
+			response, err := Process(testCase.request)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+
+			// Only a successful trade is recorded in the ledger, along with the realized gain or loss returned to the user.
+			ledger, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeTrade})
+			assert.Equal(t, testCase.expectedStatus == http.StatusOK, len(ledger.Entries) == 1)
+			if testCase.expectedStatus == http.StatusOK {
+				var sale saleResponse
+				assert.NoError(t, json.Unmarshal([]byte(response.Body), &sale))
+				assert.Equal(t, testCase.expectedRealizedPnL, sale.RealizedPnL)
+				assert.Equal(t, testCase.expectedRealizedPnL, ledger.Entries[0].RealizedPnL)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"Investing-API/Lambda/lambdaHandler"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestProcess runs withdrawal requests against an in-memory portfolio and checks the stored positions & ledger afterwards.
+func TestProcess(t *testing.T) {
+	now = func() time.Time { return time.Date(2022, 4, 13, 14, 30, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		openPositions     []database.OpenStockPosition
+		request           events.APIGatewayProxyRequest
+		expectedStatus    int
+		expectedPositions []database.OpenStockPosition
+	}{
+		"Partial Withdrawal": {
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100")},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 600}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.5"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.5"), Version: 1},
+			},
+		},
+		"All Cash": {
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100")},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.8")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 800}`},
+			http.StatusOK,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("1"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("0"), CurrentValue: types.MustParseDecimal("0"), PortfolioPercentage: types.MustParseDecimal("0"), Version: 1},
+			},
+		},
+		"Not Enough Cash": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 800.01}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"No Cash": {
+			nil,
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 100}`},
+			http.StatusBadRequest,
+			nil,
+		},
+		"Zero Amount": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 0}`},
+			http.StatusBadRequest,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Incorrect HTTP Method": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+			events.APIGatewayProxyRequest{HTTPMethod: "GET"},
+			http.StatusInternalServerError,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = database.NewMemoryStore(testCase.openPositions...)
+
+			response, err := Process(testCase.request)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+
+			// Only a successful withdrawal is recorded in the ledger, as a negative amount of cash.
+			ledger, _ := store.GetLedgerEntries(database.LedgerQuery{EntryType: database.EntryTypeCashFlow})
+			assert.Equal(t, testCase.expectedStatus == http.StatusOK, len(ledger.Entries) == 1)
+			if len(ledger.Entries) == 1 {
+				assert.Equal(t, database.SideWithdrawal, ledger.Entries[0].Side)
+				assert.Equal(t, -1, ledger.Entries[0].Amount.Sign())
+			}
+		})
+	}
+}
+
+// racingStore simulates another request updating the CASH record between this request reading & writing the portfolio.
+type racingStore struct {
+	*database.MemoryStore
+	races int // The number of upcoming commits which lose the race to another request.
+}
+
+func (s *racingStore) CommitTransaction(tx database.Transaction) error {
+	if s.races > 0 {
+		s.races--
+		cash, _ := s.GetOpenPosition("CASH")
+		_ = s.UpdateOpenPosition(cash)
+	}
+	return s.MemoryStore.CommitTransaction(tx)
+}
+
+// TestProcessConflict checks that a withdrawal is retried when the portfolio changes underneath it, and gives up with a 409.
+func TestProcessConflict(t *testing.T) {
+	tests := map[string]struct {
+		races          int
+		expectedStatus int
+		expectedCash   types.Decimal
+	}{
+		"Retry Succeeds":    {1, http.StatusOK, types.MustParseDecimal("600")},
+		"Retries Exhausted": {lambdaHandler.ConflictAttempts, http.StatusConflict, types.MustParseDecimal("1000")},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store = &racingStore{
+				MemoryStore: database.NewMemoryStore(database.OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")}),
+				races:       testCase.races,
+			}
+
+			response, err := Process(events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"Amount": 400}`})
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedStatus, response.StatusCode)
+
+			cash, _ := store.GetOpenPosition("CASH")
+			assert.Equal(t, testCase.expectedCash, cash.PurchaseValue)
+		})
+	}
+}
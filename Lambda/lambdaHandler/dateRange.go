@@ -0,0 +1,20 @@
+package lambdaHandler
+
+import (
+	"fmt"
+	"time"
+)
+
+// ValidateDateRange checks that the from & to query parameters are both either empty or YYYY-MM-DD, and that the range isn't
+// backwards.
+func ValidateDateRange(from, to string) error {
+	for _, date := range []string{from, to} {
+		if _, dateErr := time.Parse("2006-01-02", date); date != "" && dateErr != nil {
+			return fmt.Errorf("incorrect date format. expecting YYYY-MM-DD, but got: %v", date)
+		}
+	}
+	if from != "" && to != "" && from > to {
+		return fmt.Errorf("from date %v is after to date %v", from, to)
+	}
+	return nil
+}
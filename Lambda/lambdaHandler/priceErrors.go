@@ -0,0 +1,20 @@
+package lambdaHandler
+
+import (
+	"Investing-API/common/API"
+	"errors"
+	"net/http"
+)
+
+// PriceErrorStatus picks the HTTP status code to return when prices can't be fetched, so users can tell a symbol which doesn't
+// exist apart from a price API which is busy or failing.
+func PriceErrorStatus(priceErr error) int {
+	switch {
+	case errors.Is(priceErr, API.ErrUnknownSymbol), errors.Is(priceErr, API.ErrNoDataForDate):
+		return http.StatusNotFound
+	case errors.Is(priceErr, API.ErrRateLimited):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,31 @@
+package lambdaHandler
+
+import (
+	"Investing-API/common/database"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ConflictAttempts is how many times a change to the portfolio is attempted when another request modifies it at the same time.
+const ConflictAttempts = 3
+
+// RetryOnConflict runs attempt, which reads the portfolio, changes it & writes it back, and runs it again if another request changes
+// the portfolio before it's written. The status & body attempt returns make up the response to the user, and a version conflict
+// is returned as its error, so that it can be retried.
+func RetryOnConflict(attempts int, attempt func() (int, interface{}, error)) (*events.APIGatewayProxyResponse, error) {
+	for try := 1; ; try++ {
+		status, responseBody, attemptErr := attempt()
+		if errors.Is(attemptErr, database.ErrVersionConflict) {
+			if try < attempts {
+				log.Printf("Portfolio modified by another request, retrying (attempt %v): %v\n", try, attemptErr)
+				continue
+			}
+			log.Printf("Error - portfolio modified by another request: %v\n", attemptErr)
+			return Response(http.StatusConflict, "portfolio was modified by another request, please try again")
+		}
+		return Response(status, responseBody)
+	}
+}
@@ -0,0 +1,102 @@
+// BackfillPrices fetches the full daily price history of symbols into the price cache, so portfolios older than the recent history
+// the price APIs return by default can be revalued, and reports the gaps in the cache it filled.
+//
+// Usage:
+//
+//	go run ./cmd/BackfillPrices [-symbols AAPL,VUSA.LON] [-from 2020-01-01]
+//
+// Without -symbols, every symbol the portfolio has traded or holds is backfilled, each from its first trade. Alpha Vantage allows
+// few calls a minute, so backfilling many symbols can take several minutes.
+package main
+
+import (
+	"Investing-API/common/API"
+	"Investing-API/common/database"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+func main() {
+	symbolsFlag := flag.String("symbols", "", "comma-separated symbols to backfill, empty for every symbol the portfolio has traded or holds")
+	from := flag.String("from", "", "date to backfill from (YYYY-MM-DD), empty for the date of each symbol's first trade")
+	flag.Parse()
+
+	if _, dateErr := time.Parse("2006-01-02", *from); *from != "" && dateErr != nil {
+		log.Fatalf("Error reading -from: incorrect date format. expecting YYYY-MM-DD, but got: %v\n", *from)
+	}
+	var symbols []string
+	if *symbolsFlag != "" {
+		symbols = strings.Split(*symbolsFlag, ",")
+	}
+
+	symbolsFrom, symbolsErr := backfillDates(database.NewDynamoStore(database.Login()), symbols, *from)
+	if symbolsErr != nil {
+		log.Fatalf("Error querying database for the portfolio's symbols: %v\n", symbolsErr)
+	}
+	if len(symbolsFrom) == 0 {
+		log.Fatalln("No symbols to backfill")
+	}
+
+	symbols = make([]string, 0, len(symbolsFrom))
+	for symbol := range symbolsFrom {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	// Each symbol's prices are cached as soon as they're fetched, so a failed backfill can be run again without losing progress.
+	for _, symbol := range symbols {
+		report, backfillErr := API.BackfillPrices(symbol, symbolsFrom[symbol])
+		if backfillErr != nil {
+			log.Fatalf("Error backfilling prices of %v: %v\n", symbol, backfillErr)
+		}
+		log.Printf("Backfilled %v bars of %v, cached from %v to %v\n", report.Fetched, report.Symbol, report.From, report.To)
+		for _, gap := range report.Filled {
+			fmt.Printf("%-10v filled %v to %v (%v days)\n", report.Symbol, gap.From, gap.To, gap.Days)
+		}
+	}
+	log.Println("Successfully backfilled prices!")
+}
+
+// backfillDates picks the date to backfill each symbol from, as a lookup map of [symbol] => from-date. Without any symbols, every
+// symbol in the trade ledger is backfilled from its first trade, along with every open position.
+func backfillDates(store database.PortfolioStore, symbols []string, from string) (map[string]string, error) {
+	symbolsFrom := make(map[string]string)
+	if len(symbols) > 0 {
+		for _, symbol := range symbols {
+			symbolsFrom[symbol] = from
+		}
+		return symbolsFrom, nil
+	}
+
+	// Ledger entries are in time order, so the first entry of each symbol is its first trade.
+	trades, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{EntryType: database.EntryTypeTrade})
+	if ledgerErr != nil {
+		return nil, ledgerErr
+	}
+	for _, trade := range trades {
+		if _, seen := symbolsFrom[trade.Symbol]; !seen {
+			symbolsFrom[trade.Symbol] = trade.Timestamp[:len("2006-01-02")]
+		}
+	}
+
+	openPositions, dbQueryErr := store.GetAllOpenPositions()
+	if dbQueryErr != nil {
+		return nil, dbQueryErr
+	}
+	for _, position := range openPositions {
+		if _, seen := symbolsFrom[position.SK]; !seen && position.SK != "CASH" {
+			symbolsFrom[position.SK] = ""
+		}
+	}
+
+	if from != "" {
+		for symbol := range symbolsFrom {
+			symbolsFrom[symbol] = from
+		}
+	}
+	return symbolsFrom, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBackfillDates checks which symbols are backfilled, and from which date, with & without symbols.
+func TestBackfillDates(t *testing.T) {
+	trades := []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("150")}, database.SideBuy, "", time.Date(2021, 3, 1, 15, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "TSLA", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("600")}, database.SideBuy, "", time.Date(2021, 6, 1, 15, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "TSLA", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("900")}, database.SideSell, "", time.Date(2021, 11, 1, 15, 0, 0, 0, time.UTC)),
+	}
+	openPositions := []database.OpenStockPosition{
+		{SK: "AAPL", Shares: types.MustParseDecimal("2")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("1200")},
+		{SK: "VUSA.LON", Shares: types.MustParseDecimal("10")},
+	}
+
+	tests := map[string]struct {
+		symbols  []string
+		from     string
+		expected map[string]string
+	}{
+		"Every Symbol":      {nil, "", map[string]string{"AAPL": "2021-03-01", "TSLA": "2021-06-01", "VUSA.LON": ""}},
+		"Every Symbol From": {nil, "2020-01-01", map[string]string{"AAPL": "2020-01-01", "TSLA": "2020-01-01", "VUSA.LON": "2020-01-01"}},
+		"Chosen Symbols":    {[]string{"MSFT"}, "2019-06-03", map[string]string{"MSFT": "2019-06-03"}},
+		"Full History":      {[]string{"MSFT"}, "", map[string]string{"MSFT": ""}},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := database.NewMemoryStore(openPositions...)
+			assert.NoError(t, store.CommitTransaction(database.Transaction{Ledger: trades}))
+
+			symbolsFrom, datesErr := backfillDates(store, testCase.symbols, testCase.from)
+			assert.NoError(t, datesErr)
+			assert.Equal(t, testCase.expected, symbolsFrom)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// ReplayLedger rebuilds the portfolio by replaying the trade ledger from an opening cash balance, and reports any drift between
+// the rebuilt positions and the positions stored in DynamoDB.
+//
+// Usage:
+//
+//	go run ./cmd/ReplayLedger -opening-cash 10000 [-apply]
+//
+// With -apply, the stored positions are replaced with the rebuilt positions in a single transaction. This recovers a portfolio
+// after a partial write. Without it, the command exits with status 1 when any drift is found.
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"Investing-API/common/utils"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	openingCashFlag := flag.String("opening-cash", "0", "cash held in the portfolio before the first ledger entry, 0 if every deposit is in the ledger")
+	apply := flag.Bool("apply", false, "replace the stored positions with the rebuilt positions")
+	flag.Parse()
+
+	openingCash, parseErr := types.ParseDecimal(*openingCashFlag)
+	if parseErr != nil {
+		log.Fatalf("Error reading -opening-cash: %v\n", parseErr)
+	}
+
+	store := database.NewDynamoStore(database.Login())
+
+	entries, ledgerErr := database.GetAllLedgerEntries(store, database.LedgerQuery{})
+	if ledgerErr != nil {
+		log.Fatalf("Error reading the ledger: %v\n", ledgerErr)
+	}
+
+	rebuilt, replayErr := utils.ReplayLedger(entries, openingCash)
+	if replayErr != nil {
+		log.Fatalf("Error replaying the ledger: %v\n", replayErr)
+	}
+
+	stored, dbQueryErr := store.GetAllOpenPositions()
+	if dbQueryErr != nil {
+		log.Fatalf("Error querying database for open portfolio positions: %v\n", dbQueryErr)
+	}
+
+	drift := utils.ComparePositions(stored, rebuilt)
+	log.Printf("Replayed %v ledger entries into %v positions\n", len(entries), len(rebuilt))
+	for _, difference := range drift {
+		fmt.Printf("%-8v %-14v stored: %-12v rebuilt: %v\n", difference.Symbol, difference.Field, difference.Stored, difference.Rebuilt)
+	}
+	if len(drift) == 0 {
+		log.Println("Stored portfolio matches the ledger")
+		return
+	}
+
+	if !*apply {
+		os.Exit(1)
+	}
+	if commitErr := store.CommitTransaction(buildRecoveryTransaction(stored, rebuilt)); commitErr != nil {
+		log.Fatalf("Error writing rebuilt positions to database: %v\n", commitErr)
+	}
+	log.Println("Successfully replaced stored positions with the rebuilt portfolio!")
+}
+
+// buildRecoveryTransaction overwrites the stored positions with the rebuilt positions, and deletes any stored position which no
+// longer exists. The stored share prices are kept, because the ledger only holds the trade prices, so the rebuilt shares are
+// revalued at them.
+func buildRecoveryTransaction(stored, rebuilt []database.OpenStockPosition) database.Transaction {
+	var transaction database.Transaction
+
+	storedLookup := make(map[string]database.OpenStockPosition)
+	for _, position := range stored {
+		storedLookup[position.SK] = position
+	}
+
+	for _, position := range rebuilt {
+		if storedPosition, exists := storedLookup[position.SK]; exists {
+			position.Version = storedPosition.Version
+			if position.SK != "CASH" && !storedPosition.CurrentValue.IsZero() {
+				position = utils.MarkToMarket(position, storedPosition.CurrentStockPrice)
+			}
+			delete(storedLookup, position.SK)
+		}
+		transaction.Puts = append(transaction.Puts, position)
+	}
+	transaction.Puts = utils.CalculateMarketRatio(transaction.Puts)
+	for _, position := range storedLookup {
+		transaction.Deletes = append(transaction.Deletes, position)
+	}
+
+	return transaction
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"Investing-API/common/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildRecoveryTransaction checks that replaying the ledger over drifted positions rewrites them from the ledger at their
+// stored share prices, creates missing positions, deletes stale ones, and moves every version on.
+func TestBuildRecoveryTransaction(t *testing.T) {
+	aaplBuy := time.Date(2022, 3, 1, 15, 0, 0, 0, time.UTC)
+	msftBuy := time.Date(2022, 3, 2, 15, 0, 0, 0, time.UTC)
+	entries := []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("100")}, database.SideBuy, "", aaplBuy),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "MSFT", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("50")}, database.SideBuy, "", msftBuy),
+	}
+	aaplLots := []database.Lot{{Acquired: entries[0].Timestamp, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}
+	msftLots := []database.Lot{{Acquired: entries[1].Timestamp, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("50")}}
+
+	tests := map[string]struct {
+		stored            []database.OpenStockPosition
+		expectedPositions []database.OpenStockPosition
+	}{
+		"Drifted Portfolio": {
+			// The second AAPL buy & the MSFT buy were never written, and TSLA was never deleted.
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("100"), CurrentValue: types.MustParseDecimal("120"), AveragePrice: types.MustParseDecimal("100"), PercentageReturn: types.MustParseDecimal("0.2"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("120"), Version: 3},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("900"), CurrentValue: types.MustParseDecimal("900"), Version: 5},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1"), Version: 2},
+			},
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("240"), PortfolioPercentage: types.MustParseDecimal("0.2308"), AveragePrice: types.MustParseDecimal("100"), PercentageReturn: types.MustParseDecimal("0.2"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("120"), Lots: aaplLots, Version: 4},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("750"), CurrentValue: types.MustParseDecimal("750"), PortfolioPercentage: types.MustParseDecimal("0.7212"), Version: 6},
+				{PK: "OPEN-POSITION", SK: "MSFT", PurchaseValue: types.MustParseDecimal("50"), PortfolioPercentage: types.MustParseDecimal("0.0481"), AveragePrice: types.MustParseDecimal("50"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("50"), Lots: msftLots, Version: 1},
+			},
+		},
+		"Empty Store": {
+			nil,
+			[]database.OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"), Lots: aaplLots, Version: 1},
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("750"), CurrentValue: types.MustParseDecimal("750"), PortfolioPercentage: types.MustParseDecimal("0.75"), Version: 1},
+				{PK: "OPEN-POSITION", SK: "MSFT", PurchaseValue: types.MustParseDecimal("50"), PortfolioPercentage: types.MustParseDecimal("0.05"), AveragePrice: types.MustParseDecimal("50"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("50"), Lots: msftLots, Version: 1},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := database.NewMemoryStore(testCase.stored...)
+			stored, _ := store.GetAllOpenPositions()
+			rebuilt, replayErr := utils.ReplayLedger(entries, types.MustParseDecimal("1000"))
+			assert.NoError(t, replayErr)
+
+			assert.NoError(t, store.CommitTransaction(buildRecoveryTransaction(stored, rebuilt)))
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+			assert.Empty(t, utils.ComparePositions(storedPositions, rebuilt))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package API
+
+import (
+	"Investing-API/common/types"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	// alphaVantageName is the name of the Alpha Vantage provider in PRICE_PROVIDERS.
+	alphaVantageName = "alphavantage"
+
+	// alphaVantageURL is the query endpoint of the Alpha Vantage API.
+	alphaVantageURL = "https://www.alphavantage.co/query"
+
+	// compactDays is how many calendar days the compact time series of 100 trading days is sure to cover.
+	compactDays = 130
+)
+
+// AlphaVantage is the PriceProvider of the Alpha Vantage API (https://www.alphavantage.co). Symbols of non-US exchanges have an
+// exchange suffix, e.g. VUSA.LON.
+type AlphaVantage struct {
+	BaseURL string
+	APIKey  string
+	Client  *Client
+}
+
+// NewAlphaVantage creates the Alpha Vantage provider, using the API_KEY environment variable. Every Alpha Vantage provider shares
+// the same rate limit.
+func NewAlphaVantage() *AlphaVantage {
+	return &AlphaVantage{BaseURL: alphaVantageURL, APIKey: os.Getenv("API_KEY"), Client: NewClient(sharedAlphaVantageLimiter())}
+}
+
+// Name returns "alphavantage".
+func (a *AlphaVantage) Name() string {
+	return alphaVantageName
+}
+
+// DailyBars fetches the daily bars of a symbol from the TIME_SERIES_DAILY endpoint. The compact time series of the last 100
+// trading days is fetched, unless bars from before then are needed. The closes aren't adjusted for splits or dividends.
+func (a *AlphaVantage) DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error) {
+	// Errors & rate limits are returned as a message, in place of the time series.
+	query := url.Values{"symbol": {symbol}, "outputsize": {outputSize(from)}}
+	responseData, requestErr := a.Client.Get(ctx, a.buildURL("TIME_SERIES_DAILY", query), alphaVantageError)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	bars, parseErr := parseBars(responseData)
+	if parseErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, parseErr)
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("%w: alphavantage returned no daily prices for %v", ErrUpstream, symbol)
+	}
+	return types.BarsBetween(bars, from, ""), nil
+}
+
+// AdjustedDailyBars fetches the daily bars of a symbol from the TIME_SERIES_DAILY_ADJUSTED endpoint, which adds each day's
+// dividend & split coefficient, and its close adjusted for every later split & dividend.
+func (a *AlphaVantage) AdjustedDailyBars(ctx context.Context, symbol, from string) ([]types.AdjustedBar, error) {
+	query := url.Values{"symbol": {symbol}, "outputsize": {outputSize(from)}}
+	responseData, requestErr := a.Client.Get(ctx, a.buildURL("TIME_SERIES_DAILY_ADJUSTED", query), alphaVantageError)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	bars, parseErr := parseAdjustedBars(responseData)
+	if parseErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, parseErr)
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("%w: alphavantage returned no adjusted daily prices for %v", ErrUpstream, symbol)
+	}
+	start := sort.Search(len(bars), func(i int) bool {
+		return bars[i].Date >= from
+	})
+	return bars[start:], nil
+}
+
+// LatestQuote fetches the latest price of a symbol from the GLOBAL_QUOTE endpoint.
+func (a *AlphaVantage) LatestQuote(ctx context.Context, symbol string) (Quote, error) {
+	var quote Quote
+	responseData, requestErr := a.Client.Get(ctx, a.buildURL("GLOBAL_QUOTE", url.Values{"symbol": {symbol}}), alphaVantageError)
+	if requestErr != nil {
+		return quote, requestErr
+	}
+
+	var apiResponse GlobalQuoteResponse
+	if unmarshallErr := json.Unmarshal(responseData, &apiResponse); unmarshallErr != nil {
+		return quote, fmt.Errorf("%w: %v", ErrUpstream, unmarshallErr)
+	}
+	// Unknown symbols are returned as an empty quote.
+	if apiResponse.Quote.Symbol == "" {
+		return quote, fmt.Errorf("%w: alphavantage returned no quote for %v", ErrUnknownSymbol, symbol)
+	}
+	price, parseErr := types.ParseDecimal(apiResponse.Quote.Price)
+	if parseErr != nil {
+		return quote, fmt.Errorf("%w: invalid price in quote for %v: %v", ErrUpstream, symbol, parseErr)
+	}
+
+	return Quote{Symbol: apiResponse.Quote.Symbol, Price: price, Date: apiResponse.Quote.LatestTradingDay}, nil
+}
+
+// SearchSymbols finds symbols from the SYMBOL_SEARCH endpoint.
+func (a *AlphaVantage) SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error) {
+	responseData, requestErr := a.Client.Get(ctx, a.buildURL("SYMBOL_SEARCH", url.Values{"keywords": {keywords}}), alphaVantageError)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	var apiResponse SymbolSearchResponse
+	if unmarshallErr := json.Unmarshal(responseData, &apiResponse); unmarshallErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, unmarshallErr)
+	}
+	// A search without any matches still has an empty list of matches.
+	if apiResponse.BestMatches == nil {
+		return nil, fmt.Errorf("%w: alphavantage returned no search results for %q", ErrUpstream, keywords)
+	}
+
+	matches := []SymbolMatch{}
+	for _, match := range apiResponse.BestMatches {
+		matches = append(matches, SymbolMatch{Symbol: match.Symbol, Name: match.Name, Region: match.Region, Currency: match.Currency})
+	}
+	return matches, nil
+}
+
+// outputSize picks the time series to fetch from a date: the compact time series of the last 100 trading days, unless bars from
+// before then are needed.
+func outputSize(from string) string {
+	if from != "" && from < time.Now().AddDate(0, 0, -compactDays).Format("2006-01-02") {
+		return "full"
+	}
+	return "compact"
+}
+
+// buildURL constructs the API query URL of an Alpha Vantage function.
+func (a *AlphaVantage) buildURL(function string, params url.Values) string {
+	params.Set("function", function)
+	params.Set("apikey", a.APIKey)
+	return a.BaseURL + "?" + params.Encode()
+}
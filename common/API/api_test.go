@@ -1,6 +1,10 @@
 package API
 
 import (
+	"Investing-API/common/types"
+	"context"
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
@@ -28,3 +32,72 @@ func TestDateFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestParseBar checks that each price & the volume of a bar are read, and that invalid prices are rejected.
+func TestParseBar(t *testing.T) {
+	tests := map[string]struct {
+		open, high, low, close, volume string
+		expectedVolume                 int64
+		expectErr                      bool
+	}{
+		"Whole Volume":     {"168.71", "169.03", "165.5", "165.75", "89770555", 89770555, false},
+		"Exponent Volume":  {"168.71", "169.03", "165.5", "165.75", "8.977e+07", 89770000, false},
+		"Missing Volume":   {"168.71", "169.03", "165.5", "165.75", "", 0, false},
+		"Invalid Volume":   {"168.71", "169.03", "165.5", "165.75", "many", 0, true},
+		"Missing Close":    {"168.71", "169.03", "165.5", "", "89770555", 0, true},
+		"No Data For Open": {"N/D", "169.03", "165.5", "165.75", "89770555", 0, true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			bar, barErr := parseBar("2022-04-11", testCase.open, testCase.high, testCase.low, testCase.close, testCase.volume)
+			if testCase.expectErr {
+				assert.Error(t, barErr)
+				return
+			}
+			assert.NoError(t, barErr)
+			assert.Equal(t, types.Bar{
+				Date:   "2022-04-11",
+				Open:   types.MustParseDecimal(testCase.open),
+				High:   types.MustParseDecimal(testCase.high),
+				Low:    types.MustParseDecimal(testCase.low),
+				Close:  types.MustParseDecimal(testCase.close),
+				Volume: testCase.expectedVolume,
+			}, bar)
+		})
+	}
+}
+
+// TestAdjustedDailyBars checks that the adjusted time series is read with each day's dividend & split, and that the corporate
+// actions are found from them.
+func TestAdjustedDailyBars(t *testing.T) {
+	server := fixtureServer(t, "alphavantage/daily_adjusted.json", http.StatusOK)
+	defer server.Close()
+	provider := &AlphaVantage{BaseURL: server.URL, APIKey: "test-key", Client: &Client{HTTP: server.Client()}}
+
+	bars, barsErr := provider.AdjustedDailyBars(context.Background(), "AAPL", "2020-08-10")
+	assert.NoError(t, barsErr)
+	assert.Len(t, bars, 3)
+	assert.Equal(t, types.AdjustedBar{
+		Bar: types.Bar{
+			Date:   "2020-08-31",
+			Open:   types.MustParseDecimal("127.58"),
+			High:   types.MustParseDecimal("131"),
+			Low:    types.MustParseDecimal("126"),
+			Close:  types.MustParseDecimal("129.04"),
+			Volume: 225702688,
+		},
+		AdjustedClose:    types.MustParseDecimal("126.9832"),
+		Dividend:         types.MustParseDecimal("0"),
+		SplitCoefficient: types.MustParseDecimal("4"),
+	}, bars[1])
+
+	bars, _ = provider.AdjustedDailyBars(context.Background(), "AAPL", "")
+	assert.Equal(t, []types.CorporateAction{
+		{Symbol: "AAPL", Date: "2020-08-07", Type: types.ActionDividend, Amount: types.MustParseDecimal("0.82")},
+		{Symbol: "AAPL", Date: "2020-08-31", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")},
+	}, types.CorporateActions("AAPL", bars))
+
+	_, stooqErr := (&Stooq{}).AdjustedDailyBars(context.Background(), "AAPL", "")
+	assert.True(t, errors.Is(stooqErr, ErrNotSupported))
+}
@@ -0,0 +1,89 @@
+package API
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"context"
+	"fmt"
+	"log"
+)
+
+// fullHistoryFrom is the from date of a backfill without one, which is before the start of every provider's full history.
+const fullHistoryFrom = "1900-01-01"
+
+// BackfillReport is the result of backfilling the cached bars of a symbol.
+type BackfillReport struct {
+	Symbol  string    `json:"Symbol"`
+	From    string    `json:"From"`    // The earliest cached bar once backfilled, YYYY-MM-DD.
+	To      string    `json:"To"`      // The latest cached bar once backfilled, YYYY-MM-DD.
+	Fetched int       `json:"Fetched"` // The number of bars fetched from the provider.
+	Filled  []DateGap `json:"Filled"`  // The runs of consecutive bars which weren't cached before, oldest first.
+}
+
+// DateGap is a run of consecutive trading days, between two dates (YYYY-MM-DD, inclusive).
+type DateGap struct {
+	From string `json:"From"`
+	To   string `json:"To"`
+	Days int    `json:"Days"` // The number of trading days in the run.
+}
+
+// Backfill fetches the full history of a symbol's daily bars from a date (YYYY-MM-DD), or from the start of the provider's history
+// when the date is empty, and caches every bar which was missing. Unlike DailyBars, the provider is always called, so gaps left
+// in the middle of the cache are filled too.
+func (c *CachedProvider) Backfill(ctx context.Context, symbol, from string) (BackfillReport, error) {
+	report := BackfillReport{Symbol: symbol, Filled: []DateGap{}}
+	history, cacheErr := c.Cache.GetPriceHistory(symbol)
+	if cacheErr != nil {
+		return report, fmt.Errorf("reading cached prices of %v: %w", symbol, cacheErr)
+	}
+
+	if from == "" {
+		from = fullHistoryFrom
+	}
+	fetched, fetchErr := c.PriceProvider.DailyBars(ctx, symbol, from)
+	if fetchErr != nil {
+		return report, fetchErr
+	}
+	report.Fetched = len(fetched)
+	report.Filled = missingRuns(history.Bars, fetched)
+
+	// The fetched bars run through the latest trading day, so the cache is as up to date as a DailyBars call would leave it.
+	update := database.PriceHistory{Symbol: symbol, Bars: fetched, FetchedOn: c.Now().UTC().Format("2006-01-02")}
+	if len(fetched) > 0 && (history.FetchedFrom == "" || fetched[0].Date < history.FetchedFrom) {
+		update.FetchedFrom = fetched[0].Date
+	}
+	if putErr := c.Cache.PutPriceHistory(update); putErr != nil {
+		return report, fmt.Errorf("caching prices of %v: %w", symbol, putErr)
+	}
+
+	if merged := types.MergeBars(history.Bars, fetched); len(merged) > 0 {
+		report.From, report.To = merged[0].Date, merged[len(merged)-1].Date
+	}
+	log.Printf("Backfilled %v bars of %v, filling %v gaps\n", report.Fetched, symbol, len(report.Filled))
+	return report, nil
+}
+
+// missingRuns finds the bars of fetched which aren't in cached, and groups them into runs of bars which are next to each other in
+// fetched. Both series are sorted by date.
+func missingRuns(cached, fetched []types.Bar) []DateGap {
+	cachedDates := make(map[string]bool, len(cached))
+	for _, bar := range cached {
+		cachedDates[bar.Date] = true
+	}
+
+	gaps := []DateGap{}
+	inGap := false
+	for _, bar := range fetched {
+		switch {
+		case cachedDates[bar.Date]:
+			inGap = false
+		case inGap:
+			gaps[len(gaps)-1].To = bar.Date
+			gaps[len(gaps)-1].Days++
+		default:
+			gaps = append(gaps, DateGap{From: bar.Date, To: bar.Date, Days: 1})
+			inGap = true
+		}
+	}
+	return gaps
+}
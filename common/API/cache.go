@@ -0,0 +1,109 @@
+package API
+
+import (
+	"Investing-API/common/calendar"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// maxFallbackDays is how many trading days before a date are tried for a price, when the exchange turns out to have been
+// closed on days the calendar doesn't know about.
+const maxFallbackDays = 5
+
+// CachedProvider looks up daily bars in a price cache before fetching them from its provider, and caches every bar it fetches.
+// A symbol is fetched at most once a day, and only for the dates which aren't cached yet. Quotes & searches aren't cached.
+type CachedProvider struct {
+	PriceProvider
+	Cache database.PriceStore
+	Now   func() time.Time // The clock which decides when cached bars are out of date.
+}
+
+// NewCachedProvider wraps a provider in a price cache.
+func NewCachedProvider(provider PriceProvider, cache database.PriceStore) *CachedProvider {
+	return &CachedProvider{PriceProvider: provider, Cache: cache, Now: time.Now}
+}
+
+// DailyBars returns the cached bars of a symbol from a date, once the cache has been brought up to date. Cached bars are
+// returned without a network call when they were fetched today, and already go back to the date.
+func (c *CachedProvider) DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error) {
+	history, cacheErr := c.Cache.GetPriceHistory(symbol)
+	if cacheErr != nil {
+		log.Printf("Error reading cached prices of %v, fetching them instead: %v\n", symbol, cacheErr)
+		return c.PriceProvider.DailyBars(ctx, symbol, from)
+	}
+
+	today := c.Now().UTC().Format("2006-01-02")
+	coversFrom := history.FetchedOn != "" && (from == "" || history.FetchedFrom <= from)
+	if coversFrom && history.FetchedOn >= today {
+		return types.BarsBetween(history.Bars, from, ""), nil
+	}
+
+	// Only the bars after the latest cached one are missing, unless the cache doesn't go back far enough. The latest cached bar
+	// is fetched again, in case it was cached before the day's close.
+	fetchFrom := from
+	if coversFrom && len(history.Bars) > 0 {
+		fetchFrom = history.Bars[len(history.Bars)-1].Date
+	}
+	fetched, fetchErr := c.PriceProvider.DailyBars(ctx, symbol, fetchFrom)
+	if fetchErr != nil {
+		if coversFrom {
+			log.Printf("Error fetching prices of %v, using cached prices from %v: %v\n", symbol, history.FetchedOn, fetchErr)
+			return types.BarsBetween(history.Bars, from, ""), nil
+		}
+		return nil, fetchErr
+	}
+
+	update := database.PriceHistory{Symbol: symbol, Bars: fetched, FetchedOn: today}
+	fetchedFrom := fetchFrom
+	if fetchedFrom == "" && len(fetched) > 0 {
+		fetchedFrom = fetched[0].Date
+	}
+	if history.FetchedFrom == "" || fetchedFrom < history.FetchedFrom {
+		update.FetchedFrom = fetchedFrom
+	}
+	if putErr := c.Cache.PutPriceHistory(update); putErr != nil {
+		log.Printf("Error caching prices of %v: %v\n", symbol, putErr)
+	}
+
+	return types.BarsBetween(types.MergeBars(history.Bars, fetched), from, ""), nil
+}
+
+// ClosingPrice returns the closing price of a symbol on a date (YYYY-MM-DD). A price cached after the date is returned without a
+// network call, but a price cached on the date itself may be from before the close, so it is fetched again.
+func (c *CachedProvider) ClosingPrice(ctx context.Context, symbol, date string) (types.Decimal, error) {
+	if history, cacheErr := c.Cache.GetPriceHistory(symbol); cacheErr == nil && history.FetchedOn > date {
+		if bars := types.BarsBetween(history.Bars, date, date); len(bars) > 0 {
+			return bars[0].Close, nil
+		}
+	}
+
+	bars, barsErr := c.DailyBars(ctx, symbol, date)
+	if barsErr != nil {
+		return types.Decimal{}, barsErr
+	}
+	return datePrice(types.ClosingPrices(bars), symbol, date)
+}
+
+// ClosingPriceOnOrBefore returns the closing price of a symbol on the latest trading day on or before a date (YYYY-MM-DD), along
+// with the day the price is from. Days the symbol's exchange is closed, such as weekends & holidays, are skipped.
+func (c *CachedProvider) ClosingPriceOnOrBefore(ctx context.Context, symbol, date string) (types.Decimal, string, error) {
+	requested, parseErr := calendar.ParseDate(date)
+	if parseErr != nil {
+		return types.Decimal{}, "", parseErr
+	}
+
+	exchange := calendar.ForSymbol(symbol)
+	day := exchange.TradingDayOnOrBefore(requested)
+	for attempt := 0; ; attempt++ {
+		priceDate := calendar.FormatDate(day)
+		price, priceErr := c.ClosingPrice(ctx, symbol, priceDate)
+		if !errors.Is(priceErr, ErrNoDataForDate) || attempt == maxFallbackDays {
+			return price, priceDate, priceErr
+		}
+		day = exchange.PreviousTradingDay(day)
+	}
+}
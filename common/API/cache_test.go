@@ -0,0 +1,241 @@
+package API
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCachedProvider checks that cached bars are used without a network call, and that only the missing dates are fetched, at
+// most once a day.
+func TestCachedProvider(t *testing.T) {
+	provider := &historyProvider{bars: []types.Bar{
+		bar("2022-04-04", "178.44"), bar("2022-04-05", "175.06"), bar("2022-04-06", "171.83"),
+		bar("2022-04-07", "172.14"), bar("2022-04-08", "170.09"), bar("2022-04-11", "165.75"),
+	}}
+	cache := database.NewMemoryStore()
+	cached := NewCachedProvider(provider, cache)
+
+	// The steps run in order, each one starting from the cache the previous step left behind.
+	steps := []struct {
+		name          string
+		today         string
+		newBars       []types.Bar
+		failing       bool
+		from          string
+		expectedFetch []string // The from date of each call made to the provider.
+		expectedDates []string
+	}{
+		{"Empty Cache", "2022-04-11", nil, false, "2022-04-06", []string{"2022-04-06"},
+			[]string{"2022-04-06", "2022-04-07", "2022-04-08", "2022-04-11"}},
+		{"Cached Today", "2022-04-11", nil, false, "2022-04-07", nil,
+			[]string{"2022-04-07", "2022-04-08", "2022-04-11"}},
+		{"Earlier Dates Missing", "2022-04-11", nil, false, "2022-04-05", []string{"2022-04-05"},
+			[]string{"2022-04-05", "2022-04-06", "2022-04-07", "2022-04-08", "2022-04-11"}},
+		{"Next Day", "2022-04-12", []types.Bar{bar("2022-04-12", "167.66")}, false, "2022-04-08",
+			[]string{"2022-04-11"}, []string{"2022-04-08", "2022-04-11", "2022-04-12"}},
+		{"Provider Failing", "2022-04-13", nil, true, "2022-04-11", []string{"2022-04-12"},
+			[]string{"2022-04-11", "2022-04-12"}},
+	}
+
+	for _, step := range steps {
+		provider.fetches, provider.failing = nil, step.failing
+		provider.bars = append(provider.bars, step.newBars...)
+		today, _ := time.Parse("2006-01-02", step.today)
+		cached.Now = func() time.Time { return today.Add(20 * time.Hour) }
+
+		bars, barsErr := cached.DailyBars(context.Background(), "AAPL", step.from)
+		assert.NoError(t, barsErr, step.name)
+		assert.Equal(t, step.expectedFetch, provider.fetches, step.name)
+		assert.Equal(t, step.expectedDates, dates(bars), step.name)
+	}
+
+	history, _ := cache.GetPriceHistory("AAPL")
+	assert.Equal(t, "2022-04-12", history.FetchedOn)
+	assert.Equal(t, "2022-04-05", history.FetchedFrom)
+	assert.Len(t, history.Bars, 6)
+}
+
+// TestClosingPrice checks that a cached closing price is returned without a network call, and that a date without a price is
+// an ErrNoDataForDate error.
+func TestClosingPrice(t *testing.T) {
+	provider := &historyProvider{bars: []types.Bar{bar("2022-04-08", "170.09"), bar("2022-04-11", "165.75")}}
+	cache := database.NewMemoryStore()
+	cache.PutPriceHistory(database.PriceHistory{
+		Symbol:      "AAPL",
+		Bars:        []types.Bar{bar("2022-04-08", "170")},
+		FetchedOn:   "2022-04-09",
+		FetchedFrom: "2022-04-08",
+	})
+	cached := NewCachedProvider(provider, cache)
+	cached.Now = func() time.Time { return time.Date(2022, 4, 12, 12, 0, 0, 0, time.UTC) }
+
+	price, priceErr := cached.ClosingPrice(context.Background(), "AAPL", "2022-04-08")
+	assert.NoError(t, priceErr)
+	assert.Equal(t, types.MustParseDecimal("170"), price)
+	assert.Empty(t, provider.fetches)
+
+	price, priceErr = cached.ClosingPrice(context.Background(), "AAPL", "2022-04-11")
+	assert.NoError(t, priceErr)
+	assert.Equal(t, types.MustParseDecimal("165.75"), price)
+	assert.Equal(t, []string{"2022-04-08"}, provider.fetches)
+
+	// The weekend has no price, and the cache is already up to date.
+	_, priceErr = cached.ClosingPrice(context.Background(), "AAPL", "2022-04-09")
+	assert.True(t, errors.Is(priceErr, ErrNoDataForDate))
+	assert.Equal(t, []string{"2022-04-08"}, provider.fetches)
+}
+
+// TestClosingPriceCachedIntraday checks that a price cached on its own date is fetched again, as it may be from before the close.
+func TestClosingPriceCachedIntraday(t *testing.T) {
+	provider := &historyProvider{bars: []types.Bar{bar("2022-04-08", "170.09"), bar("2022-04-11", "165.75")}}
+	cache := database.NewMemoryStore()
+	cache.PutPriceHistory(database.PriceHistory{
+		Symbol:      "AAPL",
+		Bars:        []types.Bar{bar("2022-04-08", "170.09"), bar("2022-04-11", "163.20")},
+		FetchedOn:   "2022-04-11",
+		FetchedFrom: "2022-04-08",
+	})
+	cached := NewCachedProvider(provider, cache)
+	cached.Now = func() time.Time { return time.Date(2022, 4, 12, 6, 0, 0, 0, time.UTC) }
+
+	price, priceErr := cached.ClosingPrice(context.Background(), "AAPL", "2022-04-11")
+	assert.NoError(t, priceErr)
+	assert.Equal(t, types.MustParseDecimal("165.75"), price)
+	assert.Equal(t, []string{"2022-04-11"}, provider.fetches)
+
+	// The refetched close is cached, so it isn't fetched again.
+	price, priceErr = cached.ClosingPrice(context.Background(), "AAPL", "2022-04-11")
+	assert.NoError(t, priceErr)
+	assert.Equal(t, types.MustParseDecimal("165.75"), price)
+	assert.Equal(t, []string{"2022-04-11"}, provider.fetches)
+}
+
+// TestClosingPriceOnOrBefore checks that a date the exchange was closed falls back to the closing price of the trading day before.
+func TestClosingPriceOnOrBefore(t *testing.T) {
+	provider := &historyProvider{bars: []types.Bar{
+		bar("2022-04-13", "170.40"), bar("2022-04-14", "165.29"), bar("2022-04-18", "165.07"), bar("2022-04-19", "167.40"),
+	}}
+	cached := NewCachedProvider(provider, database.NewMemoryStore())
+	cached.Now = func() time.Time { return time.Date(2022, 4, 20, 12, 0, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		symbol            string
+		date              string
+		expectedPrice     string
+		expectedPriceDate string
+	}{
+		"Trading Day":   {"AAPL", "2022-04-18", "165.07", "2022-04-18"},
+		"Good Friday":   {"AAPL", "2022-04-15", "165.29", "2022-04-14"},
+		"Weekend":       {"AAPL", "2022-04-17", "165.29", "2022-04-14"},
+		"London Easter": {"VUSA.LON", "2022-04-18", "165.29", "2022-04-14"},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			price, priceDate, priceErr := cached.ClosingPriceOnOrBefore(context.Background(), testCase.symbol, testCase.date)
+			assert.NoError(t, priceErr)
+			assert.Equal(t, types.MustParseDecimal(testCase.expectedPrice), price)
+			assert.Equal(t, testCase.expectedPriceDate, priceDate)
+		})
+	}
+
+	// A day missing from the prices, although the calendar expects trading, falls back to the day before too.
+	provider.bars = append(provider.bars[:2], provider.bars[3:]...)
+	cached = NewCachedProvider(provider, database.NewMemoryStore())
+	cached.Now = func() time.Time { return time.Date(2022, 4, 20, 12, 0, 0, 0, time.UTC) }
+	price, priceDate, priceErr := cached.ClosingPriceOnOrBefore(context.Background(), "AAPL", "2022-04-18")
+	assert.NoError(t, priceErr)
+	assert.Equal(t, types.MustParseDecimal("165.29"), price)
+	assert.Equal(t, "2022-04-14", priceDate)
+}
+
+// TestBackfill checks that a backfill fetches the full history, even when the cache is up to date, and reports the gaps it filled.
+func TestBackfill(t *testing.T) {
+	provider := &historyProvider{bars: []types.Bar{
+		bar("2022-04-04", "178.44"), bar("2022-04-05", "175.06"), bar("2022-04-06", "171.83"),
+		bar("2022-04-07", "172.14"), bar("2022-04-08", "170.09"), bar("2022-04-11", "165.75"),
+	}}
+	cache := database.NewMemoryStore()
+	cache.PutPriceHistory(database.PriceHistory{
+		Symbol:      "AAPL",
+		Bars:        []types.Bar{bar("2022-04-06", "171.83"), bar("2022-04-11", "165.75")},
+		FetchedOn:   "2022-04-11",
+		FetchedFrom: "2022-04-06",
+	})
+	cached := NewCachedProvider(provider, cache)
+	cached.Now = func() time.Time { return time.Date(2022, 4, 11, 22, 0, 0, 0, time.UTC) }
+
+	report, backfillErr := cached.Backfill(context.Background(), "AAPL", "")
+	assert.NoError(t, backfillErr)
+	assert.Equal(t, []string{fullHistoryFrom}, provider.fetches)
+	assert.Equal(t, BackfillReport{
+		Symbol:  "AAPL",
+		From:    "2022-04-04",
+		To:      "2022-04-11",
+		Fetched: 6,
+		Filled:  []DateGap{{From: "2022-04-04", To: "2022-04-05", Days: 2}, {From: "2022-04-07", To: "2022-04-08", Days: 2}},
+	}, report)
+
+	history, _ := cache.GetPriceHistory("AAPL")
+	assert.Equal(t, "2022-04-04", history.FetchedFrom)
+	assert.Len(t, history.Bars, 6)
+
+	// Once backfilled, a second backfill has nothing left to fill.
+	report, backfillErr = cached.Backfill(context.Background(), "AAPL", "2022-04-05")
+	assert.NoError(t, backfillErr)
+	assert.Equal(t, []DateGap{}, report.Filled)
+	assert.Equal(t, 5, report.Fetched)
+}
+
+// TestFilePriceStoreCache checks that bars cached in files are used by a new provider, as they are by a new Lambda.
+func TestFilePriceStoreCache(t *testing.T) {
+	provider := &historyProvider{bars: []types.Bar{bar("2022-04-08", "170.09"), bar("2022-04-11", "165.75")}}
+	directory := t.TempDir()
+	now := func() time.Time { return time.Date(2022, 4, 11, 22, 0, 0, 0, time.UTC) }
+
+	first := &CachedProvider{PriceProvider: provider, Cache: database.NewFilePriceStore(directory), Now: now}
+	_, barsErr := first.DailyBars(context.Background(), "VUSA.LON", "2022-04-08")
+	assert.NoError(t, barsErr)
+
+	second := &CachedProvider{PriceProvider: provider, Cache: database.NewFilePriceStore(directory), Now: now}
+	bars, barsErr := second.DailyBars(context.Background(), "VUSA.LON", "2022-04-08")
+	assert.NoError(t, barsErr)
+	assert.Equal(t, provider.bars, bars)
+	assert.Len(t, provider.fetches, 1)
+}
+
+// historyProvider returns its bars from the requested date, and records the date of every call.
+type historyProvider struct {
+	stubProvider
+	bars    []types.Bar
+	failing bool
+	fetches []string
+}
+
+func (h *historyProvider) DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error) {
+	h.fetches = append(h.fetches, from)
+	if h.failing {
+		return nil, ErrRateLimited
+	}
+	return types.BarsBetween(h.bars, from, ""), nil
+}
+
+// dates lists the dates of bars, in order.
+func dates(bars []types.Bar) []string {
+	var barDates []string
+	for _, bar := range bars {
+		barDates = append(barDates, bar.Date)
+	}
+	return barDates
+}
+
+// bar builds the bar of a day, with the same open, high, low & close, to keep the test cases short.
+func bar(date, price string) types.Bar {
+	return types.Bar{Date: date, Open: types.MustParseDecimal(price), High: types.MustParseDecimal(price), Low: types.MustParseDecimal(price), Close: types.MustParseDecimal(price), Volume: 1000}
+}
@@ -0,0 +1,157 @@
+package API
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// requestTimeout limits how long a single request to a provider can take, including reading its body.
+	requestTimeout = 15 * time.Second
+
+	// The retries of rate limited & failed requests. The delay doubles after each attempt, up to the maximum.
+	defaultMaxRetries = 3
+	defaultBaseDelay  = time.Second
+	defaultMaxDelay   = 20 * time.Second
+
+	// defaultAlphaVantageCallsPerMinute is the call frequency of Alpha Vantage's free tier.
+	defaultAlphaVantageCallsPerMinute = 5
+)
+
+var (
+	// alphaVantageLimiter is shared by every Alpha Vantage client, so calls made by different providers count towards the same limit.
+	alphaVantageLimiter     *RateLimiter
+	alphaVantageLimiterOnce sync.Once
+)
+
+// Client makes the HTTP requests of a price provider. Requests wait for the rate limiter (when there is one), and are retried
+// with exponential backoff when the provider is rate limiting or has a server error.
+type Client struct {
+	HTTP       *http.Client
+	Limiter    *RateLimiter // nil for no rate limit.
+	MaxRetries int          // How many times a request is retried after its first attempt.
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// NewClient creates a client with a request timeout and the default retries, which shares the given rate limiter.
+func NewClient(limiter *RateLimiter) *Client {
+	return &Client{
+		HTTP:       &http.Client{Timeout: requestTimeout},
+		Limiter:    limiter,
+		MaxRetries: defaultMaxRetries,
+		BaseDelay:  defaultBaseDelay,
+		MaxDelay:   defaultMaxDelay,
+	}
+}
+
+// Get fetches a URL, and returns the body of a successful response. check reads the body for an error the provider returned in
+// place of data (e.g. a rate limit message sent with a 200 status code), so those are retried as well. It can be nil.
+func (c *Client) Get(ctx context.Context, queryURL string, check func(responseData []byte) error) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		if c.Limiter != nil {
+			if waitErr := c.Limiter.Wait(ctx); waitErr != nil {
+				return nil, waitErr
+			}
+		}
+
+		responseData, requestErr := c.get(ctx, queryURL)
+		if requestErr == nil && check != nil {
+			requestErr = check(responseData)
+		}
+		if requestErr == nil {
+			return responseData, nil
+		}
+		if attempt >= c.MaxRetries || !retryable(requestErr) {
+			return nil, requestErr
+		}
+
+		delay := c.backoff(attempt)
+		log.Printf("Retrying request in %v (attempt %v): %v\n", delay, attempt+1, requestErr)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, requestErr
+		}
+	}
+}
+
+// get makes a single request for a URL, and returns the body of a successful response.
+func (c *Client) get(ctx context.Context, queryURL string) ([]byte, error) {
+	request, requestErr := http.NewRequestWithContext(ctx, http.MethodGet, queryURL, nil)
+	if requestErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, requestErr)
+	}
+
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, responseErr := client.Do(request)
+	if responseErr != nil {
+		log.Printf("Error while quierying %v: %v\n", request.URL.Host, responseErr)
+		// A cancelled request is returned as the context's own error, so callers can tell it apart from a failing provider.
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: request to %v failed", ErrUpstream, request.URL.Host)
+	}
+	defer response.Body.Close()
+
+	responseData, readErr := ioutil.ReadAll(response.Body)
+	if readErr != nil {
+		log.Printf("Error while reading API response body: %v\n", readErr)
+		return nil, fmt.Errorf("%w: %v", ErrUpstream, readErr)
+	}
+
+	// Check for non-successful response codes from the API
+	if response.StatusCode != http.StatusOK {
+		log.Printf("Unexpected StatusCode returned from query: %v\n", response.StatusCode)
+		return nil, statusError(response.StatusCode, request.URL.Host)
+	}
+
+	return responseData, nil
+}
+
+// backoff returns how long to wait before retrying a request: a random delay ("full jitter") of up to BaseDelay × 2^attempt,
+// capped at MaxDelay, so that clients which were limited at the same time don't all retry at the same time.
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.MaxDelay
+	if attempt < 32 {
+		if exponential := c.BaseDelay << uint(attempt); exponential > 0 && exponential < ceiling {
+			ceiling = exponential
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// sharedAlphaVantageLimiter returns the rate limiter of every Alpha Vantage client. The ALPHA_VANTAGE_CALLS_PER_MINUTE
+// environment variable sets its rate, for API keys with a higher limit than the free tier.
+func sharedAlphaVantageLimiter() *RateLimiter {
+	alphaVantageLimiterOnce.Do(func() {
+		callsPerMinute := defaultAlphaVantageCallsPerMinute
+		if setting := os.Getenv("ALPHA_VANTAGE_CALLS_PER_MINUTE"); setting != "" {
+			parsed, parseErr := strconv.Atoi(setting)
+			if parseErr != nil || parsed < 1 {
+				log.Printf("Invalid ALPHA_VANTAGE_CALLS_PER_MINUTE %q, using %v\n", setting, defaultAlphaVantageCallsPerMinute)
+			} else {
+				callsPerMinute = parsed
+			}
+		}
+		alphaVantageLimiter = NewRateLimiter(callsPerMinute, callsPerMinute)
+	})
+	return alphaVantageLimiter
+}
@@ -0,0 +1,164 @@
+package API
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRateLimiter checks that a burst of calls is allowed straight away, and that later calls wait for the bucket to refill.
+func TestRateLimiter(t *testing.T) {
+	// A token every 20ms, with a burst of 2.
+	limiter := NewRateLimiter(3000, 2)
+
+	start := time.Now()
+	for call := 0; call < 2; call++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.Less(t, int64(time.Since(start)), int64(15*time.Millisecond))
+
+	for call := 0; call < 2; call++ {
+		assert.NoError(t, limiter.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(35*time.Millisecond))
+}
+
+// TestRateLimiterCancel checks that a call stops waiting when its context is done, and hands its token back.
+func TestRateLimiterCancel(t *testing.T) {
+	// A token every 100ms, with a burst of 1.
+	limiter := NewRateLimiter(600, 1)
+	assert.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(limiter.Wait(ctx), context.DeadlineExceeded))
+
+	// Only the first call's token is still owed, so the next call waits less than two intervals.
+	start := time.Now()
+	assert.NoError(t, limiter.Wait(context.Background()))
+	assert.Less(t, int64(time.Since(start)), int64(150*time.Millisecond))
+}
+
+// TestClientRetries checks that rate limits & server errors are retried with backoff, and that other errors aren't.
+func TestClientRetries(t *testing.T) {
+	note := `{"Note": "Thank you for using Alpha Vantage! Our standard API call frequency is 5 calls per minute."}`
+	tests := map[string]struct {
+		statusCodes   []int
+		bodies        []string
+		expectedErr   error
+		expectedCalls int32
+	}{
+		"First Attempt Works": {[]int{200}, []string{`{}`}, nil, 1},
+		"Too Many Requests":   {[]int{429, 200}, []string{``, `{}`}, nil, 2},
+		"Server Errors":       {[]int{503, 502, 200}, []string{``, ``, `{}`}, nil, 3},
+		"Rate Limit Message":  {[]int{200, 200}, []string{note, `{}`}, nil, 2},
+		"Gives Up":            {[]int{500, 500, 500, 500}, []string{``, ``, ``, ``}, ErrUpstream, 3},
+		"Still Rate Limited":  {[]int{200, 200, 200}, []string{note, note, note}, ErrRateLimited, 3},
+		"Client Error":        {[]int{404}, []string{``}, ErrUpstream, 1},
+		"Unknown Symbol":      {[]int{200}, []string{`{"Error Message": "Invalid API call."}`}, ErrUnknownSymbol, 1},
+		"Unreadable Response": {[]int{200}, []string{`<html></html>`}, ErrUpstream, 1},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := atomic.AddInt32(&calls, 1) - 1
+				w.WriteHeader(testCase.statusCodes[call])
+				w.Write([]byte(testCase.bodies[call]))
+			}))
+			defer server.Close()
+
+			client := &Client{HTTP: server.Client(), MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+			responseData, getErr := client.Get(context.Background(), server.URL, alphaVantageError)
+			assertErrorIs(t, testCase.expectedErr, getErr)
+			assert.Equal(t, testCase.expectedCalls, atomic.LoadInt32(&calls))
+			if testCase.expectedErr == nil {
+				assert.Equal(t, `{}`, string(responseData))
+			}
+		})
+	}
+}
+
+// TestClientContext checks that a request, or a retry, stops when its context is done, and that slow responses time out.
+func TestClientContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &Client{HTTP: server.Client(), MaxRetries: 5, BaseDelay: time.Second, MaxDelay: time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, slowErr := client.Get(ctx, server.URL+"/slow", nil)
+	assert.True(t, errors.Is(slowErr, context.DeadlineExceeded))
+
+	// The retry's backoff is cut short, and the last error from the provider is returned.
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, retryErr := client.Get(ctx, server.URL, nil)
+	assert.True(t, errors.Is(retryErr, ErrUpstream))
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+
+	timeoutClient := &Client{HTTP: &http.Client{Timeout: 20 * time.Millisecond}}
+	_, timeoutErr := timeoutClient.Get(context.Background(), server.URL+"/slow", nil)
+	assert.True(t, errors.Is(timeoutErr, ErrUpstream))
+}
+
+// TestClientSharesLimiter checks that providers with the same limiter count their calls towards the same limit.
+func TestClientSharesLimiter(t *testing.T) {
+	server := fixtureServer(t, "alphavantage/daily.json", http.StatusOK)
+	defer server.Close()
+
+	// A token every 50ms, with a burst of 1.
+	limiter := NewRateLimiter(1200, 1)
+	first := &AlphaVantage{BaseURL: server.URL, Client: &Client{HTTP: server.Client(), Limiter: limiter}}
+	second := &AlphaVantage{BaseURL: server.URL, Client: &Client{HTTP: server.Client(), Limiter: limiter}}
+
+	start := time.Now()
+	for _, provider := range []*AlphaVantage{first, second, first} {
+		_, barsErr := provider.DailyBars(context.Background(), "AAPL", "")
+		assert.NoError(t, barsErr)
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(90*time.Millisecond))
+}
+
+// TestBackoff checks that the delay between retries grows exponentially, up to the maximum, with jitter.
+func TestBackoff(t *testing.T) {
+	client := &Client{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := map[string]struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		"First Retry":  {0, 100 * time.Millisecond},
+		"Third Retry":  {2, 400 * time.Millisecond},
+		"Capped":       {10, time.Second},
+		"Huge Attempt": {100, time.Second},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			for sample := 0; sample < 50; sample++ {
+				delay := client.backoff(testCase.attempt)
+				assert.Greater(t, int64(delay), int64(0))
+				assert.LessOrEqual(t, int64(delay), int64(testCase.ceiling))
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package API
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// The kinds of error returned by the price providers. Every error returned by this package which has one of these causes
+// wraps it, so callers can check the cause with errors.Is, e.g. errors.Is(err, ErrRateLimited).
+var (
+	// ErrRateLimited is returned when a provider refuses a request because too many have been made.
+	ErrRateLimited = errors.New("price API rate limit reached")
+
+	// ErrUnknownSymbol is returned when a provider doesn't recognise the requested symbol.
+	ErrUnknownSymbol = errors.New("unknown symbol")
+
+	// ErrNoDataForDate is returned when a symbol has prices, but not on the requested date.
+	ErrNoDataForDate = errors.New("no price data for date")
+
+	// ErrUpstream is returned when a provider fails, or returns a response which can't be read.
+	ErrUpstream = errors.New("price API error")
+)
+
+// alphaVantageMessage is the body Alpha Vantage returns, with a 200 status code, in place of the requested data.
+type alphaVantageMessage struct {
+	Note         string `json:"Note"`
+	Information  string `json:"Information"`
+	ErrorMessage string `json:"Error Message"`
+}
+
+// StatusError is an unsuccessful HTTP status code returned by a provider. It wraps ErrRateLimited for 429 Too Many Requests, and
+// ErrUpstream for every other status code.
+type StatusError struct {
+	StatusCode int
+	Host       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: unexpected status code %v from %v", e.Unwrap(), e.StatusCode, e.Host)
+}
+
+// Unwrap returns the kind of error the status code is, for errors.Is.
+func (e *StatusError) Unwrap() error {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return ErrRateLimited
+	}
+	return ErrUpstream
+}
+
+// statusError converts an unsuccessful HTTP status code into an error.
+func statusError(statusCode int, host string) error {
+	return &StatusError{StatusCode: statusCode, Host: host}
+}
+
+// retryable reports whether a failed request might succeed if it's made again: when the provider is rate limiting, or has a
+// server error.
+func retryable(requestErr error) bool {
+	var statusErr *StatusError
+	if errors.As(requestErr, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	return errors.Is(requestErr, ErrRateLimited)
+}
+
+// alphaVantageError reads the message Alpha Vantage returned in place of data. It returns nil if the body isn't a message.
+func alphaVantageError(responseData []byte) error {
+	var message alphaVantageMessage
+	if unmarshallErr := json.Unmarshal(responseData, &message); unmarshallErr != nil {
+		return fmt.Errorf("%w: alphavantage returned invalid JSON: %v", ErrUpstream, unmarshallErr)
+	}
+
+	switch {
+	case message.Note != "":
+		// Notes are only sent when the per-minute call frequency has been exceeded.
+		return fmt.Errorf("%w: %v", ErrRateLimited, message.Note)
+	case isRateLimitMessage(message.Information):
+		return fmt.Errorf("%w: %v", ErrRateLimited, message.Information)
+	case message.Information != "":
+		return fmt.Errorf("%w: %v", ErrUpstream, message.Information)
+	case strings.Contains(strings.ToLower(message.ErrorMessage), "apikey"):
+		return fmt.Errorf("%w: %v", ErrUpstream, message.ErrorMessage)
+	case message.ErrorMessage != "":
+		// Every other invalid call is a symbol the API doesn't know.
+		return fmt.Errorf("%w: %v", ErrUnknownSymbol, message.ErrorMessage)
+	}
+	return nil
+}
+
+// stooqError reads the plain text message Stooq returned in place of a CSV file. It returns nil if the body isn't a message.
+func stooqError(responseData []byte) error {
+	message := strings.TrimSpace(string(responseData))
+	switch {
+	case strings.Contains(strings.ToLower(message), "limit"):
+		return fmt.Errorf("%w: %v", ErrRateLimited, message)
+	case message == "No data":
+		return fmt.Errorf("%w: %v", ErrUnknownSymbol, message)
+	}
+	return nil
+}
+
+// isRateLimitMessage checks for the wording of Alpha Vantage's daily & per-minute rate limit messages.
+func isRateLimitMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "rate limit") || strings.Contains(message, "call frequency")
+}
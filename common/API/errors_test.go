@@ -0,0 +1,134 @@
+package API
+
+import (
+	"Investing-API/common/types"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAlphaVantageErrors checks that the messages Alpha Vantage returns in place of data are converted to the matching error.
+// The fixtures in testdata are responses recorded from the API.
+func TestAlphaVantageErrors(t *testing.T) {
+	tests := map[string]struct {
+		fixture     string
+		statusCode  int
+		call        func(provider PriceProvider) error
+		expectedErr error
+	}{
+		"Daily Bars":            {"daily.json", http.StatusOK, dailyBars, nil},
+		"Call Frequency Note":   {"note.json", http.StatusOK, dailyBars, ErrRateLimited},
+		"Daily Rate Limit":      {"rate_limit.json", http.StatusOK, dailyBars, ErrRateLimited},
+		"Premium Endpoint":      {"premium.json", http.StatusOK, dailyBars, ErrUpstream},
+		"Invalid Symbol":        {"invalid_symbol.json", http.StatusOK, dailyBars, ErrUnknownSymbol},
+		"Invalid API Key":       {"invalid_apikey.json", http.StatusOK, dailyBars, ErrUpstream},
+		"Too Many Requests":     {"note.json", http.StatusTooManyRequests, dailyBars, ErrRateLimited},
+		"Server Error":          {"daily.json", http.StatusBadGateway, dailyBars, ErrUpstream},
+		"Quote Rate Limit":      {"note.json", http.StatusOK, latestQuote, ErrRateLimited},
+		"Empty Quote":           {"empty_quote.json", http.StatusOK, latestQuote, ErrUnknownSymbol},
+		"Search Rate Limit":     {"rate_limit.json", http.StatusOK, searchSymbols, ErrRateLimited},
+		"Search Without Result": {"empty_search.json", http.StatusOK, searchSymbols, nil},
+		"Search Invalid JSON":   {"bad_gateway.html", http.StatusOK, searchSymbols, ErrUpstream},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := fixtureServer(t, filepath.Join("alphavantage", testCase.fixture), testCase.statusCode)
+			defer server.Close()
+
+			callErr := testCase.call(&AlphaVantage{BaseURL: server.URL, APIKey: "test-key", Client: &Client{HTTP: server.Client()}})
+			assertErrorIs(t, testCase.expectedErr, callErr)
+		})
+	}
+}
+
+// TestStooqErrors checks that the plain text messages Stooq returns in place of a CSV file are converted to the matching error.
+func TestStooqErrors(t *testing.T) {
+	tests := map[string]struct {
+		fixture     string
+		statusCode  int
+		call        func(provider PriceProvider) error
+		expectedErr error
+	}{
+		"No Data":           {"no_data.csv", http.StatusOK, dailyBars, ErrUnknownSymbol},
+		"Daily Hits Limit":  {"limit.csv", http.StatusOK, dailyBars, ErrRateLimited},
+		"Quote Limit":       {"limit.csv", http.StatusOK, latestQuote, ErrRateLimited},
+		"Unknown Quote":     {"unknown_quote.csv", http.StatusOK, latestQuote, ErrUnknownSymbol},
+		"Too Many Requests": {"no_data.csv", http.StatusTooManyRequests, latestQuote, ErrRateLimited},
+		"Server Error":      {"no_data.csv", http.StatusInternalServerError, dailyBars, ErrUpstream},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := fixtureServer(t, filepath.Join("stooq", testCase.fixture), testCase.statusCode)
+			defer server.Close()
+
+			callErr := testCase.call(&Stooq{BaseURL: server.URL, Client: &Client{HTTP: server.Client()}})
+			assertErrorIs(t, testCase.expectedErr, callErr)
+		})
+	}
+}
+
+// TestFallbackProviderErrors checks that the cause of the last provider's failure can still be checked after falling back.
+func TestFallbackProviderErrors(t *testing.T) {
+	rateLimited := &stubProvider{name: "rate-limited", err: statusError(http.StatusTooManyRequests, "example.com")}
+	unknown := &stubProvider{name: "unknown", err: alphaVantageError([]byte(`{"Error Message": "Invalid API call."}`))}
+
+	_, quoteErr := FallbackProvider{rateLimited, unknown}.LatestQuote(context.Background(), "AAPL")
+	assert.True(t, errors.Is(quoteErr, ErrUnknownSymbol))
+	assert.False(t, errors.Is(quoteErr, ErrRateLimited))
+}
+
+// TestDatePriceErrors checks that a date without a price is told apart from a symbol without prices.
+func TestDatePriceErrors(t *testing.T) {
+	prices := map[string]types.Decimal{"2022-04-08": types.MustParseDecimal("170.09")}
+	price, priceErr := datePrice(prices, "AAPL", "2022-04-09")
+	assert.True(t, errors.Is(priceErr, ErrNoDataForDate))
+	assert.Equal(t, types.Decimal{}, price)
+
+	price, priceErr = datePrice(prices, "AAPL", "2022-04-08")
+	assert.NoError(t, priceErr)
+	assert.Equal(t, types.MustParseDecimal("170.09"), price)
+}
+
+// fixtureServer serves the same recorded response from testdata to every request.
+func fixtureServer(t *testing.T, fixture string, statusCode int) *httptest.Server {
+	body, readErr := ioutil.ReadFile(filepath.Join("testdata", fixture))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+		w.Write(body)
+	}))
+}
+
+// assertErrorIs checks that err wraps expected, or that there is no error when nothing is expected.
+func assertErrorIs(t *testing.T, expected, err error) {
+	if expected == nil {
+		assert.NoError(t, err)
+		return
+	}
+	assert.True(t, errors.Is(err, expected), "expected %v, but got: %v", expected, err)
+}
+
+func dailyBars(provider PriceProvider) error {
+	_, barsErr := provider.DailyBars(context.Background(), "AAPL", "")
+	return barsErr
+}
+
+func latestQuote(provider PriceProvider) error {
+	_, quoteErr := provider.LatestQuote(context.Background(), "AAPL")
+	return quoteErr
+}
+
+func searchSymbols(provider PriceProvider) error {
+	_, searchErr := provider.SearchSymbols(context.Background(), "Apple")
+	return searchErr
+}
@@ -1,16 +1,24 @@
 package API
 
 import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
+	"sync"
+)
+
+var (
+	// priceStore is the price cache shared by every call, which is created on first use.
+	priceStore     database.PriceStore
+	priceStoreOnce sync.Once
 )
 
 // GetSymbolDatePrice looks up the price of a symbol on a specific date. The date should be in the format YYYY-MM-DD
-func GetSymbolDatePrice(symbol, date string) (float64, error) {
-	var price float64
+func GetSymbolDatePrice(symbol, date string) (types.Decimal, error) {
+	var price types.Decimal
 
 	// Check that the date matches the expected format of YYYY-MM-DD
 	if !checkDateFormat(date) {
@@ -18,35 +26,123 @@ func GetSymbolDatePrice(symbol, date string) (float64, error) {
 		return price, errors.New(dateErr)
 	}
 
-	queryURL := buildURL(symbol)
-	response, requestErr := http.Get(queryURL)
-	if requestErr != nil {
-		log.Printf("Error while quierying URL: %v\n", requestErr)
-		return price, requestErr
+	provider, configErr := cachedProvider()
+	if configErr != nil {
+		log.Printf("Error configuring price providers: %v\n", configErr)
+		return price, configErr
 	}
 
-	// Check for non-successful response codes from the API
-	if response.StatusCode != 200 {
-		log.Printf("Unexpected StatusCode returned from query: %v\n", response.StatusCode)
+	return provider.ClosingPrice(context.Background(), symbol, date)
+}
+
+// GetSymbolPriceOnOrBefore looks up the price of a symbol on a date (YYYY-MM-DD), or on the latest trading day before it when
+// the symbol's exchange was closed on the date, e.g. for a market holiday.
+func GetSymbolPriceOnOrBefore(symbol, date string) (types.Decimal, error) {
+	if !checkDateFormat(date) {
+		return types.Decimal{}, fmt.Errorf("incorrect date format, expecting YYYY-MM-DD, but got: %v", date)
 	}
 
-	responseData, responseErr := ioutil.ReadAll(response.Body)
-	if responseErr != nil {
-		log.Printf("Error while reading API response body: %v\n", responseErr)
-		return price, responseErr
+	provider, configErr := cachedProvider()
+	if configErr != nil {
+		log.Printf("Error configuring price providers: %v\n", configErr)
+		return types.Decimal{}, configErr
 	}
 
-	priceMap, parseErr := parseData(responseData)
-	if parseErr != nil {
-		log.Printf("Error while structuring price data: %v\n", responseErr)
-		return price, responseErr
+	price, priceDate, priceErr := provider.ClosingPriceOnOrBefore(context.Background(), symbol, date)
+	if priceErr == nil && priceDate != date {
+		log.Printf("No trading in %v on %v, using its closing price on %v\n", symbol, date, priceDate)
 	}
+	return price, priceErr
+}
 
+// datePrice looks up the price of a symbol on a date in its daily prices. A missing date is an ErrNoDataForDate error, so it can be
+// told apart from a symbol without any prices.
+func datePrice(priceMap map[string]types.Decimal, symbol, date string) (types.Decimal, error) {
 	data, exists := priceMap[date]
 	if !exists {
 		log.Printf("Data for the follwoing data does not exist: %v\n", date)
-		return price, responseErr
+		return types.Decimal{}, fmt.Errorf("%w: %v on %v", ErrNoDataForDate, symbol, date)
 	}
 
 	return data, nil
 }
+
+// GetSymbolBars fetches the daily bars of a symbol between two dates (YYYY-MM-DD, inclusive), sorted by date. An empty from date
+// returns the recent history, and an empty to date runs to the latest trading day. The bars come from the price cache, or from
+// the first provider in PRICE_PROVIDERS which has them.
+func GetSymbolBars(symbol, from, to string) ([]types.Bar, error) {
+	provider, configErr := cachedProvider()
+	if configErr != nil {
+		log.Printf("Error configuring price providers: %v\n", configErr)
+		return nil, configErr
+	}
+
+	bars, barsErr := provider.DailyBars(context.Background(), symbol, from)
+	if barsErr != nil {
+		log.Printf("Error while fetching prices of %v: %v\n", symbol, barsErr)
+		return nil, barsErr
+	}
+
+	return types.BarsBetween(bars, from, to), nil
+}
+
+// GetSymbolAdjustedBars fetches the daily bars of a symbol from a date (YYYY-MM-DD), along with each day's splits & dividends and
+// its close adjusted for them. An empty date returns the recent history. Adjusted bars aren't cached, as every split or dividend
+// changes the adjusted closes before it.
+func GetSymbolAdjustedBars(symbol, from string) ([]types.AdjustedBar, error) {
+	provider, configErr := ConfiguredProvider()
+	if configErr != nil {
+		log.Printf("Error configuring price providers: %v\n", configErr)
+		return nil, configErr
+	}
+
+	bars, barsErr := provider.AdjustedDailyBars(context.Background(), symbol, from)
+	if barsErr != nil {
+		log.Printf("Error while fetching adjusted prices of %v: %v\n", symbol, barsErr)
+		return nil, barsErr
+	}
+	return bars, nil
+}
+
+// GetCorporateActions fetches the splits & dividends of a symbol which went ex on or after a date (YYYY-MM-DD), in date order. An
+// empty date returns the actions of the recent history.
+func GetCorporateActions(symbol, from string) ([]types.CorporateAction, error) {
+	bars, barsErr := GetSymbolAdjustedBars(symbol, from)
+	if barsErr != nil {
+		return nil, barsErr
+	}
+	return types.CorporateActions(symbol, bars), nil
+}
+
+// BackfillPrices fetches the full history of a symbol's daily bars from a date (YYYY-MM-DD), or its whole history when the date
+// is empty, into the price cache, and reports the gaps in the cache which were filled.
+func BackfillPrices(symbol, from string) (BackfillReport, error) {
+	if from != "" && !checkDateFormat(from) {
+		return BackfillReport{}, fmt.Errorf("incorrect date format, expecting YYYY-MM-DD, but got: %v", from)
+	}
+
+	provider, configErr := cachedProvider()
+	if configErr != nil {
+		log.Printf("Error configuring price providers: %v\n", configErr)
+		return BackfillReport{}, configErr
+	}
+
+	report, backfillErr := provider.Backfill(context.Background(), symbol, from)
+	if backfillErr != nil {
+		log.Printf("Error while backfilling prices of %v: %v\n", symbol, backfillErr)
+	}
+	return report, backfillErr
+}
+
+// cachedProvider returns the providers in PRICE_PROVIDERS, behind the configured price cache.
+func cachedProvider() (*CachedProvider, error) {
+	provider, configErr := ConfiguredProvider()
+	if configErr != nil {
+		return nil, configErr
+	}
+
+	priceStoreOnce.Do(func() {
+		priceStore = database.ConfiguredPriceStore()
+	})
+	return NewCachedProvider(provider, priceStore), nil
+}
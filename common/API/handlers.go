@@ -1,23 +1,16 @@
 package API
 
 import (
+	"Investing-API/common/types"
 	"encoding/json"
 	"fmt"
-	"os"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// buildURL constructs the API query URL for fetching the given symbol's price data
-func buildURL(symbol string) string {
-	return fmt.Sprintf(
-		"https://www.alphavantage.co/query?function=TIME_SERIES_DAILY&symbol=%v&outputsize=compact&apikey=%v",
-		symbol, os.Getenv("API_KEY"),
-	)
-}
-
 // checkDateFormat ensures that the date is in the format YYYY-MM-DD, and is less than the current date.
 func checkDateFormat(date string) bool {
 	if !regexp.MustCompile(`^\d{4}\-(0[1-9]|1[012])\-(0[1-9]|[12][0-9]|3[01])$`).MatchString(date) {
@@ -31,28 +24,92 @@ func checkDateFormat(date string) bool {
 	return true
 }
 
-// parseData reads the API response body into a date: price lookup map : [date] => closing-price
-func parseData(data []byte) (map[string]float64, error) {
-	var stockData = make(map[string]float64)
+// parseBars reads the API response body into the daily bars of the time series, sorted by date. Days with an invalid price are
+// left out.
+func parseBars(data []byte) ([]types.Bar, error) {
 	var apiResponse QueryResponse
 	if err := json.Unmarshal(data, &apiResponse); err != nil {
-		return stockData, err
+		return nil, err
 	}
 
-	// For each closing price record returned from search, format to float, and add into a lookup-map
-	for key, value := range apiResponse.TimeSeries {
-		var priceData TimeSeries
-		if byteData, err := json.Marshal(value); err != nil {
+	bars := make([]types.Bar, 0, len(apiResponse.TimeSeries))
+	for date, series := range apiResponse.TimeSeries {
+		bar, barErr := parseBar(date, series.Open, series.High, series.Low, series.Close, series.Volume)
+		if barErr != nil {
 			continue
-		} else if err = json.Unmarshal(byteData, &priceData); err != nil {
+		}
+		bars = append(bars, bar)
+	}
+	types.SortBars(bars)
+
+	return bars, nil
+}
+
+// parseAdjustedBars reads the adjusted API response body into the daily bars of the time series, sorted by date. Days with an
+// invalid price are left out.
+func parseAdjustedBars(data []byte) ([]types.AdjustedBar, error) {
+	var apiResponse AdjustedQueryResponse
+	if err := json.Unmarshal(data, &apiResponse); err != nil {
+		return nil, err
+	}
+
+	bars := make([]types.AdjustedBar, 0, len(apiResponse.TimeSeries))
+	for date, series := range apiResponse.TimeSeries {
+		bar, barErr := parseBar(date, series.Open, series.High, series.Low, series.Close, series.Volume)
+		if barErr != nil {
 			continue
 		}
-		formattedPrice, formattingErr := strconv.ParseFloat(priceData.Close, 64)
-		if formattingErr != nil {
+		adjusted := types.AdjustedBar{Bar: bar}
+		adjustedErr := parseOptionalDecimals(
+			[]string{series.AdjustedClose, series.DividendAmount, series.SplitCoefficient},
+			[]*types.Decimal{&adjusted.AdjustedClose, &adjusted.Dividend, &adjusted.SplitCoefficient},
+		)
+		if adjustedErr != nil {
 			continue
 		}
-		stockData[key] = formattedPrice
+		bars = append(bars, adjusted)
 	}
+	types.SortAdjustedBars(bars)
+
+	return bars, nil
+}
 
-	return stockData, nil
+// parseOptionalDecimals reads each text into its value. A missing text leaves its value at 0.
+func parseOptionalDecimals(texts []string, values []*types.Decimal) error {
+	for index, text := range texts {
+		if text == "" {
+			continue
+		}
+		value, parseErr := types.ParseDecimal(text)
+		if parseErr != nil {
+			return parseErr
+		}
+		*values[index] = value
+	}
+	return nil
+}
+
+// parseBar builds a bar from the text of its prices & volume. A missing volume is read as 0, as some indices & funds have none.
+func parseBar(date, open, high, low, close, volume string) (types.Bar, error) {
+	bar := types.Bar{Date: date}
+	for _, field := range []struct {
+		text  string
+		value *types.Decimal
+	}{{open, &bar.Open}, {high, &bar.High}, {low, &bar.Low}, {close, &bar.Close}} {
+		price, parseErr := types.ParseDecimal(field.text)
+		if parseErr != nil {
+			return bar, fmt.Errorf("invalid price on %v: %v", date, parseErr)
+		}
+		*field.value = price
+	}
+
+	if volume != "" {
+		// Volumes are sometimes given with a decimal point, e.g. 1.2345e+06.
+		shares, parseErr := strconv.ParseFloat(volume, 64)
+		if parseErr != nil {
+			return bar, fmt.Errorf("invalid volume on %v: %v", date, parseErr)
+		}
+		bar.Volume = int64(math.Round(shares))
+	}
+	return bar, nil
 }
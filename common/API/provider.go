@@ -0,0 +1,142 @@
+package API
+
+import (
+	"Investing-API/common/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// defaultProviders is the order providers are tried in when PRICE_PROVIDERS isn't set.
+const defaultProviders = alphaVantageName + "," + stooqName
+
+// ErrNotSupported is returned by a provider which doesn't offer the requested data, so the next provider is tried instead.
+var ErrNotSupported = errors.New("not supported by this price provider")
+
+// PriceProvider is a source of market data. AlphaVantage & Stooq are the implementations, and FallbackProvider combines them.
+type PriceProvider interface {
+	// Name identifies the provider in PRICE_PROVIDERS & in logs.
+	Name() string
+
+	// DailyBars returns the daily bars of a symbol from a date (YYYY-MM-DD, inclusive) to the latest trading day, sorted by
+	// date. An empty date returns the provider's recent history, of at least 100 days.
+	DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error)
+
+	// AdjustedDailyBars returns the daily bars of a symbol like DailyBars, along with the splits & dividends of each day and the
+	// closes adjusted for them. A provider without corporate actions returns ErrNotSupported.
+	AdjustedDailyBars(ctx context.Context, symbol, from string) ([]types.AdjustedBar, error)
+
+	// LatestQuote returns the most recent price of a symbol.
+	LatestQuote(ctx context.Context, symbol string) (Quote, error)
+
+	// SearchSymbols returns the symbols which best match the keywords, e.g. part of a company name.
+	SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error)
+}
+
+// Quote is the most recent price of a symbol.
+type Quote struct {
+	Symbol string        `json:"Symbol"`
+	Price  types.Decimal `json:"Price"`
+	Date   string        `json:"Date"` // The trading day of the price, YYYY-MM-DD.
+}
+
+// SymbolMatch is a symbol found by a search.
+type SymbolMatch struct {
+	Symbol   string `json:"Symbol"`
+	Name     string `json:"Name"`
+	Region   string `json:"Region,omitempty"`
+	Currency string `json:"Currency,omitempty"`
+}
+
+// FallbackProvider tries each of its providers in order, until one of them succeeds.
+type FallbackProvider []PriceProvider
+
+// ConfiguredProvider returns the providers named in the PRICE_PROVIDERS environment variable, a comma separated list tried in
+// order (e.g. "stooq,alphavantage"). Alpha Vantage, then Stooq, are tried when it isn't set.
+func ConfiguredProvider() (FallbackProvider, error) {
+	names := os.Getenv("PRICE_PROVIDERS")
+	if names == "" {
+		names = defaultProviders
+	}
+
+	var providers FallbackProvider
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case alphaVantageName:
+			providers = append(providers, NewAlphaVantage())
+		case stooqName:
+			providers = append(providers, NewStooq())
+		default:
+			return nil, fmt.Errorf("unknown price provider %q in PRICE_PROVIDERS", name)
+		}
+	}
+	return providers, nil
+}
+
+// Name lists the providers, in the order they're tried.
+func (p FallbackProvider) Name() string {
+	var names []string
+	for _, provider := range p {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// DailyBars returns the daily bars from the first provider which has them.
+func (p FallbackProvider) DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error) {
+	var bars []types.Bar
+	providerErr := p.try(func(provider PriceProvider) (err error) {
+		bars, err = provider.DailyBars(ctx, symbol, from)
+		return err
+	})
+	return bars, providerErr
+}
+
+// AdjustedDailyBars returns the adjusted daily bars from the first provider which has them.
+func (p FallbackProvider) AdjustedDailyBars(ctx context.Context, symbol, from string) ([]types.AdjustedBar, error) {
+	var bars []types.AdjustedBar
+	providerErr := p.try(func(provider PriceProvider) (err error) {
+		bars, err = provider.AdjustedDailyBars(ctx, symbol, from)
+		return err
+	})
+	return bars, providerErr
+}
+
+// LatestQuote returns the latest quote from the first provider which has one.
+func (p FallbackProvider) LatestQuote(ctx context.Context, symbol string) (Quote, error) {
+	var quote Quote
+	providerErr := p.try(func(provider PriceProvider) (err error) {
+		quote, err = provider.LatestQuote(ctx, symbol)
+		return err
+	})
+	return quote, providerErr
+}
+
+// SearchSymbols returns the matches from the first provider which can search.
+func (p FallbackProvider) SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error) {
+	var matches []SymbolMatch
+	providerErr := p.try(func(provider PriceProvider) (err error) {
+		matches, err = provider.SearchSymbols(ctx, keywords)
+		return err
+	})
+	return matches, providerErr
+}
+
+// try calls each provider in turn, until a call succeeds. If every provider fails, the last provider's error is returned.
+func (p FallbackProvider) try(call func(provider PriceProvider) error) error {
+	if len(p) == 0 {
+		return errors.New("no price providers configured")
+	}
+
+	var callErr error
+	for _, provider := range p {
+		if callErr = call(provider); callErr == nil {
+			return nil
+		}
+		log.Printf("Error from %v price provider: %v\n", provider.Name(), callErr)
+	}
+	return fmt.Errorf("every price provider failed (%v), the last with: %w", p.Name(), callErr)
+}
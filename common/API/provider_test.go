@@ -0,0 +1,172 @@
+package API
+
+import (
+	"Investing-API/common/types"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAlphaVantage checks that each Alpha Vantage query is built & read correctly, and that messages without data are errors.
+func TestAlphaVantage(t *testing.T) {
+	responses := map[string]string{
+		"TIME_SERIES_DAILY": `{"Time Series (Daily)": {
+			"2022-04-11": {"1. open": "168.71", "2. high": "169.03", "3. low": "165.5", "4. close": "165.75", "5. volume": "89770555"},
+			"2022-04-08": {"1. open": "171.78", "2. high": "171.78", "3. low": "169.2", "4. close": "170.09", "5. volume": "76575508"},
+			"2022-04-07": {"1. open": "171.16", "2. high": "173.36", "3. low": "169.85", "4. close": "invalid", "5. volume": "77594650"}
+		}}`,
+		"GLOBAL_QUOTE":  `{"Global Quote": {"01. symbol": "AAPL", "05. price": "165.7500", "07. latest trading day": "2022-04-11"}}`,
+		"SYMBOL_SEARCH": `{"bestMatches": [{"1. symbol": "AAPL", "2. name": "Apple Inc", "4. region": "United States", "8. currency": "USD"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.URL.Query().Get("apikey"))
+		if r.URL.Query().Get("symbol") == "UNKNOWN" || r.URL.Query().Get("keywords") == "UNKNOWN" {
+			w.Write([]byte(`{"Error Message": "Invalid API call."}`))
+			return
+		}
+		w.Write([]byte(responses[r.URL.Query().Get("function")]))
+	}))
+	defer server.Close()
+	provider := &AlphaVantage{BaseURL: server.URL, APIKey: "test-key", Client: &Client{HTTP: server.Client()}}
+
+	bars, barsErr := provider.DailyBars(context.Background(), "AAPL", "")
+	assert.NoError(t, barsErr)
+	assert.Equal(t, []types.Bar{
+		{Date: "2022-04-08", Open: types.MustParseDecimal("171.78"), High: types.MustParseDecimal("171.78"), Low: types.MustParseDecimal("169.2"), Close: types.MustParseDecimal("170.09"), Volume: 76575508},
+		{Date: "2022-04-11", Open: types.MustParseDecimal("168.71"), High: types.MustParseDecimal("169.03"), Low: types.MustParseDecimal("165.5"), Close: types.MustParseDecimal("165.75"), Volume: 89770555},
+	}, bars)
+
+	quote, quoteErr := provider.LatestQuote(context.Background(), "AAPL")
+	assert.NoError(t, quoteErr)
+	assert.Equal(t, Quote{Symbol: "AAPL", Price: types.MustParseDecimal("165.75"), Date: "2022-04-11"}, quote)
+
+	matches, searchErr := provider.SearchSymbols(context.Background(), "Apple")
+	assert.NoError(t, searchErr)
+	assert.Equal(t, []SymbolMatch{{Symbol: "AAPL", Name: "Apple Inc", Region: "United States", Currency: "USD"}}, matches)
+
+	_, barsErr = provider.DailyBars(context.Background(), "UNKNOWN", "")
+	assert.Error(t, barsErr)
+	_, quoteErr = provider.LatestQuote(context.Background(), "UNKNOWN")
+	assert.Error(t, quoteErr)
+	_, searchErr = provider.SearchSymbols(context.Background(), "UNKNOWN")
+	assert.Error(t, searchErr)
+}
+
+// TestStooq checks that Stooq's CSV files are read, and that symbols are converted to Stooq's format.
+func TestStooq(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/q/d/l/" && r.URL.Query().Get("s") == "vusa.uk":
+			w.Write([]byte("Date,Open,High,Low,Close,Volume\n2022-04-08,66.2,66.6,66.1,66.52,1000\n2022-04-11,66.4,66.5,65.8,65.91,1.2e3\n"))
+		case r.URL.Path == "/q/l/" && r.URL.Query().Get("s") == "aapl.us":
+			w.Write([]byte("Symbol,Date,Time,Open,High,Low,Close,Volume\nAAPL.US,2022-04-11,22:00:09,168.71,169.03,165.5,165.75,89770555\n"))
+		case r.URL.Path == "/q/l/":
+			w.Write([]byte("Symbol,Date,Time,Open,High,Low,Close,Volume\nUNKNOWN.US,N/D,N/D,N/D,N/D,N/D,N/D,N/D\n"))
+		default:
+			w.Write([]byte("No data"))
+		}
+	}))
+	defer server.Close()
+	provider := &Stooq{BaseURL: server.URL, Client: &Client{HTTP: server.Client()}}
+
+	bars, barsErr := provider.DailyBars(context.Background(), "VUSA.LON", "")
+	assert.NoError(t, barsErr)
+	assert.Equal(t, []types.Bar{
+		{Date: "2022-04-08", Open: types.MustParseDecimal("66.2"), High: types.MustParseDecimal("66.6"), Low: types.MustParseDecimal("66.1"), Close: types.MustParseDecimal("66.52"), Volume: 1000},
+		{Date: "2022-04-11", Open: types.MustParseDecimal("66.4"), High: types.MustParseDecimal("66.5"), Low: types.MustParseDecimal("65.8"), Close: types.MustParseDecimal("65.91"), Volume: 1200},
+	}, bars)
+
+	quote, quoteErr := provider.LatestQuote(context.Background(), "AAPL")
+	assert.NoError(t, quoteErr)
+	assert.Equal(t, Quote{Symbol: "AAPL", Price: types.MustParseDecimal("165.75"), Date: "2022-04-11"}, quote)
+
+	_, barsErr = provider.DailyBars(context.Background(), "UNKNOWN", "")
+	assert.Error(t, barsErr)
+	_, quoteErr = provider.LatestQuote(context.Background(), "UNKNOWN")
+	assert.Error(t, quoteErr)
+	_, searchErr := provider.SearchSymbols(context.Background(), "Apple")
+	assert.True(t, errors.Is(searchErr, ErrNotSupported))
+}
+
+// TestFallbackProvider checks that providers are tried in order, and that the last error is returned when they all fail.
+func TestFallbackProvider(t *testing.T) {
+	failing := &stubProvider{name: "failing", err: errors.New("rate limited")}
+	working := &stubProvider{name: "working", price: types.MustParseDecimal("100")}
+
+	tests := map[string]struct {
+		providers     FallbackProvider
+		expectedPrice types.Decimal
+		expectErr     bool
+	}{
+		"First Provider Works": {FallbackProvider{working, failing}, types.MustParseDecimal("100"), false},
+		"Falls Back":           {FallbackProvider{failing, working}, types.MustParseDecimal("100"), false},
+		"Every Provider Fails": {FallbackProvider{failing, failing}, types.Decimal{}, true},
+		"No Providers":         {FallbackProvider{}, types.Decimal{}, true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			quote, quoteErr := testCase.providers.LatestQuote(context.Background(), "AAPL")
+			assert.Equal(t, testCase.expectErr, quoteErr != nil)
+			assert.Equal(t, testCase.expectedPrice, quote.Price)
+		})
+	}
+
+	_, barsErr := FallbackProvider{failing}.DailyBars(context.Background(), "AAPL", "")
+	assert.True(t, errors.Is(barsErr, failing.err))
+}
+
+// TestConfiguredProvider checks that PRICE_PROVIDERS sets which providers are tried, and in which order.
+func TestConfiguredProvider(t *testing.T) {
+	tests := map[string]struct {
+		setting      string
+		expectedName string
+		expectErr    bool
+	}{
+		"Default Order":    {"", "alphavantage,stooq", false},
+		"Chosen Order":     {"Stooq, alphavantage", "stooq,alphavantage", false},
+		"Single Provider":  {"stooq", "stooq", false},
+		"Unknown Provider": {"stooq,yahoo", "", true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("PRICE_PROVIDERS", testCase.setting)
+			provider, configErr := ConfiguredProvider()
+			assert.Equal(t, testCase.expectErr, configErr != nil)
+			assert.Equal(t, testCase.expectedName, provider.Name())
+		})
+	}
+}
+
+// stubProvider returns the same price for every symbol, or fails with the same error.
+type stubProvider struct {
+	name  string
+	price types.Decimal
+	err   error
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error) {
+	return []types.Bar{{Date: "2022-04-11", Open: s.price, High: s.price, Low: s.price, Close: s.price}}, s.err
+}
+
+func (s *stubProvider) AdjustedDailyBars(ctx context.Context, symbol, from string) ([]types.AdjustedBar, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *stubProvider) LatestQuote(ctx context.Context, symbol string) (Quote, error) {
+	if s.err != nil {
+		return Quote{}, s.err
+	}
+	return Quote{Symbol: symbol, Price: s.price, Date: "2022-04-11"}, nil
+}
+
+func (s *stubProvider) SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error) {
+	return nil, s.err
+}
@@ -0,0 +1,65 @@
+package API
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket, which allows a burst of calls, then one call each interval. It's safe to share between
+// goroutines, and every provider client created with the same limiter shares its calls.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // How long the bucket takes to refill a single token.
+	burst    float64       // The most tokens the bucket holds.
+	tokens   float64       // Negative when calls are waiting for tokens which haven't been refilled yet.
+	last     time.Time     // When the tokens were last refilled.
+}
+
+// NewRateLimiter creates a limiter which allows callsPerMinute calls a minute, with up to burst of them made at once. The bucket
+// starts full.
+func NewRateLimiter(callsPerMinute, burst int) *RateLimiter {
+	if callsPerMinute < 1 {
+		callsPerMinute = 1
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		interval: time.Minute / time.Duration(callsPerMinute),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a call is allowed, or the context is done. A call which gives up waiting hands its token back.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// Take a token straight away, and wait for the bucket to refill it if there wasn't one spare.
+	l.tokens--
+	delay := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
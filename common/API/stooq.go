@@ -0,0 +1,131 @@
+package API
+
+import (
+	"Investing-API/common/types"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// stooqName is the name of the Stooq provider in PRICE_PROVIDERS.
+	stooqName = "stooq"
+
+	// stooqURL is the site the Stooq CSV downloads are served from.
+	stooqURL = "https://stooq.com"
+)
+
+// Stooq is the PriceProvider of the free CSV downloads from https://stooq.com. It takes the same symbols as Alpha Vantage, and
+// converts them to Stooq's own, e.g. AAPL to aapl.us, and VUSA.LON to vusa.uk. Stooq has no symbol search.
+type Stooq struct {
+	BaseURL string
+	Client  *Client
+}
+
+// NewStooq creates the Stooq provider. Stooq has a daily download limit, but no limit on how often calls are made.
+func NewStooq() *Stooq {
+	return &Stooq{BaseURL: stooqURL, Client: NewClient(nil)}
+}
+
+// Name returns "stooq".
+func (s *Stooq) Name() string {
+	return stooqName
+}
+
+// DailyBars downloads the daily bars of a symbol. Stooq would return the whole history of a symbol without a start date, so the
+// recent history is limited to the same days as Alpha Vantage's compact time series.
+func (s *Stooq) DailyBars(ctx context.Context, symbol, from string) ([]types.Bar, error) {
+	if from == "" {
+		from = time.Now().AddDate(0, 0, -compactDays).Format("2006-01-02")
+	}
+	query := url.Values{"s": {stooqSymbol(symbol)}, "i": {"d"}, "d1": {strings.ReplaceAll(from, "-", "")}}
+	rows, downloadErr := s.download(ctx, "/q/d/l/", query)
+	if downloadErr != nil {
+		return nil, downloadErr
+	}
+
+	var bars []types.Bar
+	for _, row := range rows {
+		bar, barErr := parseBar(row["Date"], row["Open"], row["High"], row["Low"], row["Close"], row["Volume"])
+		if barErr != nil {
+			continue
+		}
+		bars = append(bars, bar)
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("%w: stooq returned no daily prices for %v", ErrUpstream, symbol)
+	}
+	types.SortBars(bars)
+	return bars, nil
+}
+
+// AdjustedDailyBars isn't supported, as Stooq's daily bars don't include splits or dividends.
+func (s *Stooq) AdjustedDailyBars(ctx context.Context, symbol, from string) ([]types.AdjustedBar, error) {
+	return nil, ErrNotSupported
+}
+
+// LatestQuote downloads the latest price of a symbol.
+func (s *Stooq) LatestQuote(ctx context.Context, symbol string) (Quote, error) {
+	query := url.Values{"s": {stooqSymbol(symbol)}, "f": {"sd2t2ohlcv"}, "h": {""}, "e": {"csv"}}
+	rows, downloadErr := s.download(ctx, "/q/l/", query)
+	if downloadErr != nil {
+		return Quote{}, downloadErr
+	}
+
+	if len(rows) == 0 {
+		return Quote{}, fmt.Errorf("%w: stooq returned no quote for %v", ErrUpstream, symbol)
+	}
+	// Unknown symbols are returned with N/D in place of every value.
+	price, parseErr := types.ParseDecimal(rows[0]["Close"])
+	if parseErr != nil {
+		return Quote{}, fmt.Errorf("%w: stooq returned no quote for %v", ErrUnknownSymbol, symbol)
+	}
+	return Quote{Symbol: symbol, Price: price, Date: rows[0]["Date"]}, nil
+}
+
+// SearchSymbols isn't supported by Stooq.
+func (s *Stooq) SearchSymbols(ctx context.Context, keywords string) ([]SymbolMatch, error) {
+	return nil, ErrNotSupported
+}
+
+// download fetches a CSV file, and reads each of its rows into a lookup map of [column] => value.
+func (s *Stooq) download(ctx context.Context, path string, query url.Values) ([]map[string]string, error) {
+	// Errors & rate limits are returned as a plain text message, in place of the CSV file.
+	responseData, requestErr := s.Client.Get(ctx, s.BaseURL+path+"?"+query.Encode(), stooqError)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	records, csvErr := csv.NewReader(bytes.NewReader(responseData)).ReadAll()
+	if csvErr != nil || len(records) == 0 {
+		return nil, fmt.Errorf("%w: stooq returned an invalid CSV file: %v", ErrUpstream, strings.TrimSpace(string(responseData)))
+	}
+
+	var rows []map[string]string
+	for _, record := range records[1:] {
+		row := make(map[string]string)
+		for column, name := range records[0] {
+			if column < len(record) {
+				row[name] = record[column]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// stooqSymbol converts an Alpha Vantage symbol to Stooq's. Symbols without an exchange suffix are US symbols.
+func stooqSymbol(symbol string) string {
+	symbol = strings.ToLower(symbol)
+	switch {
+	case strings.HasSuffix(symbol, ".lon"):
+		return strings.TrimSuffix(symbol, ".lon") + ".uk"
+	case !strings.Contains(symbol, "."):
+		return symbol + ".us"
+	}
+	return symbol
+}
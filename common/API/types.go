@@ -2,8 +2,8 @@ package API
 
 // QueryResponse is the container response that is returned from the Stock-Price query.
 type QueryResponse struct {
-	MetaData   MetaData               `json:"Meta Data"`
-	TimeSeries map[string]interface{} `json:"Time Series (Daily)"`
+	MetaData   MetaData              `json:"Meta Data"`
+	TimeSeries map[string]TimeSeries `json:"Time Series (Daily)"`
 }
 
 // MetaData contains the top-level info of the stock symbol being queried.
@@ -23,3 +23,47 @@ type TimeSeries struct {
 	Close  string `json:"4. close"`
 	Volume string `json:"5. volume"`
 }
+
+// AdjustedQueryResponse is the response of the adjusted Stock-Price query.
+type AdjustedQueryResponse struct {
+	MetaData   MetaData                      `json:"Meta Data"`
+	TimeSeries map[string]AdjustedTimeSeries `json:"Time Series (Daily)"`
+}
+
+// AdjustedTimeSeries is the stock-price structure of each day returned from the adjusted API query, along with the day's
+// corporate actions.
+type AdjustedTimeSeries struct {
+	Open             string `json:"1. open"`
+	High             string `json:"2. high"`
+	Low              string `json:"3. low"`
+	Close            string `json:"4. close"`
+	AdjustedClose    string `json:"5. adjusted close"`
+	Volume           string `json:"6. volume"`
+	DividendAmount   string `json:"7. dividend amount"`
+	SplitCoefficient string `json:"8. split coefficient"`
+}
+
+// GlobalQuoteResponse is the response of the latest price query.
+type GlobalQuoteResponse struct {
+	Quote GlobalQuote `json:"Global Quote"`
+}
+
+// GlobalQuote is the latest price of the symbol being queried.
+type GlobalQuote struct {
+	Symbol           string `json:"01. symbol"`
+	Price            string `json:"05. price"`
+	LatestTradingDay string `json:"07. latest trading day"`
+}
+
+// SymbolSearchResponse is the response of the symbol search query.
+type SymbolSearchResponse struct {
+	BestMatches []SymbolSearchMatch `json:"bestMatches"`
+}
+
+// SymbolSearchMatch is a single symbol found by the search query.
+type SymbolSearchMatch struct {
+	Symbol   string `json:"1. symbol"`
+	Name     string `json:"2. name"`
+	Region   string `json:"4. region"`
+	Currency string `json:"8. currency"`
+}
@@ -0,0 +1,139 @@
+// Package calendar decides which days an exchange is open for trading, from the rules of its holidays & early closes.
+//
+// Holidays are generated for any year from each exchange's rules (e.g. Good Friday from the date of Easter, and the weekday a
+// holiday is observed on when it falls at a weekend), with the one-off closures each exchange has announced added on top.
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Closing times are in each exchange's own time zone, which the Lambda runtime has no zoneinfo for.
+)
+
+// The exchanges with a calendar.
+const (
+	NYSE = "NYSE" // The New York Stock Exchange, whose holidays NASDAQ shares.
+	LSE  = "LSE"  // The London Stock Exchange.
+)
+
+// dateFormat is the format of every date used by the calendar.
+const dateFormat = "2006-01-02"
+
+// Calendar is the trading calendar of a single exchange.
+type Calendar struct {
+	Exchange   string
+	Location   *time.Location // The exchange's time zone, which its closing times are in.
+	Close      time.Duration  // The time after midnight the exchange closes on a normal trading day.
+	EarlyClose time.Duration  // The time after midnight the exchange closes on an early close day.
+
+	holidays    func(year int) map[string]string // The holidays of a year, as a lookup map of [date] => name.
+	earlyCloses func(year int) []time.Time       // The days of a year the exchange could close early, if it's open.
+}
+
+var (
+	newYork, _ = time.LoadLocation("America/New_York")
+	london, _  = time.LoadLocation("Europe/London")
+
+	calendars = map[string]*Calendar{
+		NYSE: {Exchange: NYSE, Location: newYork, Close: 16 * time.Hour, EarlyClose: 13 * time.Hour, holidays: nyseHolidays, earlyCloses: nyseEarlyCloses},
+		LSE:  {Exchange: LSE, Location: london, Close: 16*time.Hour + 30*time.Minute, EarlyClose: 12*time.Hour + 30*time.Minute, holidays: lseHolidays, earlyCloses: lseEarlyCloses},
+	}
+)
+
+// For returns the calendar of an exchange, e.g. LSE.
+func For(exchange string) (*Calendar, error) {
+	calendar, exists := calendars[strings.ToUpper(exchange)]
+	if !exists {
+		return nil, fmt.Errorf("no trading calendar for exchange %q", exchange)
+	}
+	return calendar, nil
+}
+
+// ForSymbol returns the calendar of the exchange a symbol is listed on. Symbols with a .LON suffix are listed in London, and
+// symbols without an exchange suffix in New York.
+func ForSymbol(symbol string) *Calendar {
+	if strings.HasSuffix(strings.ToUpper(symbol), ".LON") {
+		return calendars[LSE]
+	}
+	return calendars[NYSE]
+}
+
+// Holiday returns the name of the holiday on a date, if the exchange is closed for one. Weekends aren't holidays.
+func (c *Calendar) Holiday(date time.Time) (string, bool) {
+	name, isHoliday := c.holidays(date.Year())[date.Format(dateFormat)]
+	return name, isHoliday
+}
+
+// IsTradingDay reports whether the exchange is open on a date: a weekday which isn't a holiday.
+func (c *Calendar) IsTradingDay(date time.Time) bool {
+	if weekday := date.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	_, isHoliday := c.Holiday(date)
+	return !isHoliday
+}
+
+// PreviousTradingDay returns the last trading day before a date.
+func (c *Calendar) PreviousTradingDay(date time.Time) time.Time {
+	day := dateOnly(date).AddDate(0, 0, -1)
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// NextTradingDay returns the first trading day after a date.
+func (c *Calendar) NextTradingDay(date time.Time) time.Time {
+	day := dateOnly(date).AddDate(0, 0, 1)
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// TradingDayOnOrBefore returns the date itself if it's a trading day, or else the last trading day before it.
+func (c *Calendar) TradingDayOnOrBefore(date time.Time) time.Time {
+	if c.IsTradingDay(date) {
+		return dateOnly(date)
+	}
+	return c.PreviousTradingDay(date)
+}
+
+// IsEarlyClose reports whether the exchange closes early on a date.
+func (c *Calendar) IsEarlyClose(date time.Time) bool {
+	if !c.IsTradingDay(date) {
+		return false
+	}
+	for _, day := range c.earlyCloses(date.Year()) {
+		if day.Format(dateFormat) == date.Format(dateFormat) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClosingTime returns when the exchange closes on a trading day, in the exchange's time zone.
+func (c *Calendar) ClosingTime(date time.Time) time.Time {
+	closeAfter := c.Close
+	if c.IsEarlyClose(date) {
+		closeAfter = c.EarlyClose
+	}
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, c.Location)
+	return midnight.Add(closeAfter)
+}
+
+// ParseDate reads a date (YYYY-MM-DD) to use with a calendar.
+func ParseDate(date string) (time.Time, error) {
+	return time.Parse(dateFormat, date)
+}
+
+// FormatDate writes a date from a calendar as YYYY-MM-DD.
+func FormatDate(date time.Time) string {
+	return date.Format(dateFormat)
+}
+
+// dateOnly drops the time of day from a date, keeping the day it falls on in its own time zone.
+func dateOnly(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
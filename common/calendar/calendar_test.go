@@ -0,0 +1,123 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestIsTradingDay checks generated & one-off holidays of both exchanges, including holidays observed on another day.
+func TestIsTradingDay(t *testing.T) {
+	tests := map[string]struct {
+		exchange     string
+		date         string
+		isTradingDay bool
+	}{
+		"NYSE Weekday":                  {NYSE, "2022-04-14", true},
+		"NYSE Weekend":                  {NYSE, "2022-04-16", false},
+		"NYSE Good Friday":              {NYSE, "2022-04-15", false},
+		"NYSE Easter Monday":            {NYSE, "2022-04-18", true},
+		"NYSE Independence Day Monday":  {NYSE, "2021-07-05", false},
+		"NYSE Juneteenth Monday":        {NYSE, "2022-06-20", false},
+		"NYSE Juneteenth Before 2022":   {NYSE, "2021-06-18", true},
+		"NYSE Thanksgiving":             {NYSE, "2022-11-24", false},
+		"NYSE Christmas Friday":         {NYSE, "2021-12-24", false},
+		"NYSE New Year On Saturday":     {NYSE, "2021-12-31", true},
+		"NYSE Hurricane Sandy":          {NYSE, "2012-10-30", false},
+		"LSE Good Friday":               {LSE, "2022-04-15", false},
+		"LSE Easter Monday":             {LSE, "2022-04-18", false},
+		"LSE Thanksgiving":              {LSE, "2022-11-24", true},
+		"LSE Christmas Tuesday":         {LSE, "2022-12-27", false},
+		"LSE Boxing Day Tuesday":        {LSE, "2021-12-28", false},
+		"LSE New Year Monday":           {LSE, "2022-01-03", false},
+		"LSE Platinum Jubilee":          {LSE, "2022-06-03", false},
+		"LSE Moved Spring Bank Holiday": {LSE, "2022-05-30", true},
+		"LSE State Funeral":             {LSE, "2022-09-19", false},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			exchange, _ := For(testCase.exchange)
+			assert.Equal(t, testCase.isTradingDay, exchange.IsTradingDay(day(testCase.date)))
+		})
+	}
+}
+
+// TestEaster checks the generated date of Easter Sunday against known years.
+func TestEaster(t *testing.T) {
+	for year, expected := range map[int]string{2000: "2000-04-23", 2019: "2019-04-21", 2022: "2022-04-17", 2024: "2024-03-31", 2038: "2038-04-25"} {
+		assert.Equal(t, expected, FormatDate(easter(year)))
+	}
+}
+
+// TestNearestTradingDay checks that the previous & next trading days skip weekends and holidays.
+func TestNearestTradingDay(t *testing.T) {
+	tests := map[string]struct {
+		exchange         string
+		date             string
+		expectedPrevious string
+		expectedNext     string
+		expectedOnBefore string
+	}{
+		"NYSE Midweek":     {NYSE, "2022-04-13", "2022-04-12", "2022-04-14", "2022-04-13"},
+		"NYSE Easter":      {NYSE, "2022-04-17", "2022-04-14", "2022-04-18", "2022-04-14"},
+		"LSE Easter":       {LSE, "2022-04-17", "2022-04-14", "2022-04-19", "2022-04-14"},
+		"LSE Christmas":    {LSE, "2022-12-26", "2022-12-23", "2022-12-28", "2022-12-23"},
+		"NYSE Time of Day": {NYSE, "2022-04-15T22:30:00Z", "2022-04-14", "2022-04-18", "2022-04-14"},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			exchange, _ := For(testCase.exchange)
+			date := day(testCase.date)
+			assert.Equal(t, testCase.expectedPrevious, FormatDate(exchange.PreviousTradingDay(date)))
+			assert.Equal(t, testCase.expectedNext, FormatDate(exchange.NextTradingDay(date)))
+			assert.Equal(t, testCase.expectedOnBefore, FormatDate(exchange.TradingDayOnOrBefore(date)))
+		})
+	}
+}
+
+// TestClosingTime checks the normal & early closing times of both exchanges, in their own time zones.
+func TestClosingTime(t *testing.T) {
+	tests := map[string]struct {
+		exchange        string
+		date            string
+		expectedClosing string
+		isEarlyClose    bool
+	}{
+		"NYSE Normal":             {NYSE, "2022-04-14", "2022-04-14T20:00:00Z", false},
+		"NYSE After Thanksgiving": {NYSE, "2022-11-25", "2022-11-25T18:00:00Z", true},
+		"NYSE Before July 4th":    {NYSE, "2023-07-03", "2023-07-03T17:00:00Z", true},
+		"NYSE Christmas Eve":      {NYSE, "2024-12-24", "2024-12-24T18:00:00Z", true},
+		"LSE Normal Summer":       {LSE, "2022-06-15", "2022-06-15T15:30:00Z", false},
+		"LSE New Year's Eve":      {LSE, "2021-12-31", "2021-12-31T12:30:00Z", true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			exchange, _ := For(testCase.exchange)
+			date := day(testCase.date)
+			assert.Equal(t, testCase.isEarlyClose, exchange.IsEarlyClose(date))
+			assert.Equal(t, testCase.expectedClosing, exchange.ClosingTime(date).UTC().Format(time.RFC3339))
+		})
+	}
+}
+
+// TestForSymbol checks that symbols are matched to the exchange they're listed on.
+func TestForSymbol(t *testing.T) {
+	assert.Equal(t, NYSE, ForSymbol("AAPL").Exchange)
+	assert.Equal(t, LSE, ForSymbol("VUSA.LON").Exchange)
+
+	_, calendarErr := For("TSE")
+	assert.Error(t, calendarErr)
+}
+
+// day reads a date, or a time, from a constant, to keep the test cases short.
+func day(value string) time.Time {
+	if date, parseErr := ParseDate(value); parseErr == nil {
+		return date
+	}
+	date, _ := time.Parse(time.RFC3339, value)
+	return date
+}
@@ -0,0 +1,168 @@
+package calendar
+
+import (
+	"strconv"
+	"time"
+)
+
+// nyseHolidays returns the days of a year the New York Stock Exchange is closed for, as a lookup map of [date] => name.
+// A holiday on a Sunday is observed on the Monday, and on a Saturday on the Friday, except New Year's Day, which isn't
+// observed in the year before.
+func nyseHolidays(year int) map[string]string {
+	holidays := holidayMap{}
+	if newYear := date(year, time.January, 1); newYear.Weekday() != time.Saturday {
+		holidays.add(observed(newYear), "New Year's Day")
+	}
+	holidays.add(nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King Jr. Day")
+	holidays.add(nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday")
+	holidays.add(easter(year).AddDate(0, 0, -2), "Good Friday")
+	holidays.add(lastWeekday(year, time.May, time.Monday), "Memorial Day")
+	if year >= 2022 {
+		holidays.add(observed(date(year, time.June, 19)), "Juneteenth")
+	}
+	holidays.add(observed(date(year, time.July, 4)), "Independence Day")
+	holidays.add(nthWeekday(year, time.September, time.Monday, 1), "Labor Day")
+	holidays.add(nthWeekday(year, time.November, time.Thursday, 4), "Thanksgiving Day")
+	holidays.add(observed(date(year, time.December, 25)), "Christmas Day")
+
+	for day, name := range nyseClosures {
+		if day[:4] == strconv.Itoa(year) {
+			holidays[day] = name
+		}
+	}
+	return holidays
+}
+
+// nyseClosures are the days the New York Stock Exchange has closed outside of its holidays, as a lookup map of [date] => name.
+var nyseClosures = map[string]string{
+	"2012-10-29": "Hurricane Sandy",
+	"2012-10-30": "Hurricane Sandy",
+	"2018-12-05": "National Day of Mourning for President George H.W. Bush",
+	"2025-01-09": "National Day of Mourning for President Jimmy Carter",
+}
+
+// nyseEarlyCloses returns the days of a year the New York Stock Exchange closes at 13:00, when it's open: the day before
+// Independence Day, the day after Thanksgiving, and Christmas Eve.
+func nyseEarlyCloses(year int) []time.Time {
+	return []time.Time{
+		date(year, time.July, 3),
+		nthWeekday(year, time.November, time.Thursday, 4).AddDate(0, 0, 1),
+		date(year, time.December, 24),
+	}
+}
+
+// lseHolidays returns the days of a year the London Stock Exchange is closed for, as a lookup map of [date] => name: the
+// bank holidays of England & Wales. A bank holiday on a weekend is substituted by the next weekday which isn't a holiday.
+func lseHolidays(year int) map[string]string {
+	holidays := holidayMap{}
+	holidays.add(substitute(date(year, time.January, 1), holidays), "New Year's Day")
+	holidays.add(easter(year).AddDate(0, 0, -2), "Good Friday")
+	holidays.add(easter(year).AddDate(0, 0, 1), "Easter Monday")
+
+	earlyMay := nthWeekday(year, time.May, time.Monday, 1)
+	if year == 2020 {
+		earlyMay = date(2020, time.May, 8) // Moved for the 75th anniversary of VE Day.
+	}
+	holidays.add(earlyMay, "Early May Bank Holiday")
+
+	switch year {
+	case 2002, 2012:
+		holidays.add(date(year, time.June, 4), "Spring Bank Holiday") // Moved for the Golden & Diamond Jubilees.
+	case 2022:
+		holidays.add(date(year, time.June, 2), "Spring Bank Holiday") // Moved for the Platinum Jubilee.
+	default:
+		holidays.add(lastWeekday(year, time.May, time.Monday), "Spring Bank Holiday")
+	}
+	holidays.add(lastWeekday(year, time.August, time.Monday), "Summer Bank Holiday")
+
+	holidays.add(substitute(date(year, time.December, 25), holidays), "Christmas Day")
+	holidays.add(substitute(date(year, time.December, 26), holidays), "Boxing Day")
+
+	for day, name := range lseClosures {
+		if day[:4] == strconv.Itoa(year) {
+			holidays[day] = name
+		}
+	}
+	return holidays
+}
+
+// lseClosures are the one-off bank holidays the London Stock Exchange has closed for, as a lookup map of [date] => name.
+var lseClosures = map[string]string{
+	"2002-06-03": "Golden Jubilee",
+	"2011-04-29": "Wedding of Prince William and Catherine Middleton",
+	"2012-06-05": "Diamond Jubilee",
+	"2022-06-03": "Platinum Jubilee",
+	"2022-09-19": "State Funeral of Queen Elizabeth II",
+	"2023-05-08": "Coronation of King Charles III",
+}
+
+// lseEarlyCloses returns the days of a year the London Stock Exchange closes at 12:30, when it's open: Christmas Eve and New
+// Year's Eve.
+func lseEarlyCloses(year int) []time.Time {
+	return []time.Time{
+		date(year, time.December, 24),
+		date(year, time.December, 31),
+	}
+}
+
+// holidayMap is a lookup map of [date] => name of holidays.
+type holidayMap map[string]string
+
+func (h holidayMap) add(day time.Time, name string) {
+	h[day.Format(dateFormat)] = name
+}
+
+// date returns midnight (UTC) on a day.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekday returns the nth (from 1) weekday of a month, e.g. the 4th Thursday of November.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := date(year, month, 1)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last weekday of a month, e.g. the last Monday of May.
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := date(year, month+1, 0)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// observed returns the day a US holiday is observed on: the Friday before a Saturday, or the Monday after a Sunday.
+func observed(day time.Time) time.Time {
+	switch day.Weekday() {
+	case time.Saturday:
+		return day.AddDate(0, 0, -1)
+	case time.Sunday:
+		return day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// substitute returns the day a UK bank holiday is taken on: the day itself, or if it's on a weekend the next weekday which
+// isn't already a holiday.
+func substitute(day time.Time, holidays holidayMap) time.Time {
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday || holidays[day.Format(dateFormat)] != "" {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// easter returns Easter Sunday of a year, by the anonymous Gregorian algorithm.
+func easter(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
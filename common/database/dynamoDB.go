@@ -1,9 +1,12 @@
 package database
 
 import (
+	"Investing-API/common/types"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -12,6 +15,14 @@ import (
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 )
 
+const (
+	// tableName is the DynamoDB table which holds all portfolio records.
+	tableName = "PORTFOLIO"
+
+	// openPositionKey is the partition key shared by every open portfolio position.
+	openPositionKey = "OPEN-POSITION"
+)
+
 // Login creates a new DynamoDB client we can use to interact with the database.
 func Login() *dynamodb.DynamoDB {
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
@@ -28,25 +39,35 @@ func Login() *dynamodb.DynamoDB {
 	return dynamodb.New(sess)
 }
 
+// DynamoStore is the PortfolioStore implementation backed by the DynamoDB PORTFOLIO table.
+type DynamoStore struct {
+	svc *dynamodb.DynamoDB
+}
+
+// NewDynamoStore wraps a DynamoDB client (see Login) in a PortfolioStore.
+func NewDynamoStore(svc *dynamodb.DynamoDB) *DynamoStore {
+	return &DynamoStore{svc: svc}
+}
+
 // GetAllOpenPositions queries the database for all active portfolio positions.
-func GetAllOpenPositions(svc *dynamodb.DynamoDB) ([]OpenStockPosition, error) {
+func (s *DynamoStore) GetAllOpenPositions() ([]OpenStockPosition, error) {
 	var openPositions []OpenStockPosition
 
 	queryInput := &dynamodb.QueryInput{
-		TableName: aws.String("PORTFOLIO"),
+		TableName: aws.String(tableName),
 		KeyConditions: map[string]*dynamodb.Condition{
 			"PK": {
 				ComparisonOperator: aws.String("EQ"),
 				AttributeValueList: []*dynamodb.AttributeValue{
 					{
-						S: aws.String("OPEN-POSITION"),
+						S: aws.String(openPositionKey),
 					},
 				},
 			},
 		},
 	}
 
-	result, queryErr := svc.Query(queryInput)
+	result, queryErr := s.svc.Query(queryInput)
 	if queryErr != nil {
 		log.Printf("Error querying DynamoDB: %v\n", queryErr)
 		return openPositions, queryErr
@@ -60,49 +81,40 @@ func GetAllOpenPositions(svc *dynamodb.DynamoDB) ([]OpenStockPosition, error) {
 	return openPositions, nil
 }
 
-// GetOpenPosition ...
-func GetOpenPosition(svc *dynamodb.DynamoDB, symbol string) (OpenStockPosition, error) {
-	var openPositions OpenStockPosition
+// GetOpenPosition fetches the portfolio position of a single symbol.
+func (s *DynamoStore) GetOpenPosition(symbol string) (OpenStockPosition, error) {
+	var openPosition OpenStockPosition
 
-	queryInput := &dynamodb.QueryInput{
-		TableName: aws.String("PORTFOLIO"),
-		KeyConditions: map[string]*dynamodb.Condition{
+	queryInput := &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]*dynamodb.AttributeValue{
 			"PK": {
-				ComparisonOperator: aws.String("EQ"),
-				AttributeValueList: []*dynamodb.AttributeValue{
-					{
-						S: aws.String("OPEN-POSITION"),
-					},
-				},
+				S: aws.String(openPositionKey),
 			},
 			"SK": {
-				ComparisonOperator: aws.String("EQ"),
-				AttributeValueList: []*dynamodb.AttributeValue{
-					{
-						S: aws.String(symbol),
-					},
-				},
+				S: aws.String(symbol),
 			},
 		},
 	}
 
-	result, queryErr := svc.Query(queryInput)
+	result, queryErr := s.svc.GetItem(queryInput)
 	if queryErr != nil {
 		log.Printf("Error querying DynamoDB: %v\n", queryErr)
-		return openPositions, queryErr
+		return openPosition, queryErr
 	}
 
-	if unmarshallErr := dynamodbattribute.UnmarshalListOfMaps(result.Items, &openPositions); unmarshallErr != nil {
+	if unmarshallErr := dynamodbattribute.UnmarshalMap(result.Item, &openPosition); unmarshallErr != nil {
 		log.Printf("Error unmarshalling DynamoDB response: %v\n", unmarshallErr)
-		return openPositions, unmarshallErr
+		return openPosition, unmarshallErr
 	}
 
-	return openPositions, nil
+	return openPosition, nil
 }
 
 // AddNewPosition creates a new open portfolio position in the DynamoDB table.
-func AddNewPosition(svc *dynamodb.DynamoDB, record OpenStockPosition) error {
-	record.PK = "OPEN-POSITION"
+func (s *DynamoStore) AddNewPosition(record OpenStockPosition) error {
+	record.PK = openPositionKey
+	record.Version = 1
 
 	dbRecord, marshallErr := dynamodbattribute.MarshalMap(record)
 	if marshallErr != nil {
@@ -111,25 +123,38 @@ func AddNewPosition(svc *dynamodb.DynamoDB, record OpenStockPosition) error {
 	}
 
 	input := &dynamodb.PutItemInput{
-		Item:      dbRecord,
-		TableName: aws.String("PORTFOLIO"),
+		Item:                dbRecord,
+		TableName:           aws.String(tableName),
+		ConditionExpression: aws.String("attribute_not_exists(SK)"),
 	}
 
-	if _, putItemErr := svc.PutItem(input); putItemErr != nil {
+	if _, putItemErr := s.svc.PutItem(input); putItemErr != nil {
 		log.Printf("Error inserting record: %v\n", putItemErr)
-		return putItemErr
+		return conflictError(putItemErr, []string{record.SK})
 	}
 
 	return nil
 }
 
 // UpdateOpenPosition updates a portfolio record in the DynamoDB table.
-func UpdateOpenPosition(svc *dynamodb.DynamoDB, record OpenStockPosition) error {
+// The write only succeeds if the record is stored, and still has the version it was read with.
+func (s *DynamoStore) UpdateOpenPosition(record OpenStockPosition) error {
+	condition, conditionValues := versionCondition(record.Version, true)
+
+	lots, marshallErr := dynamodbattribute.Marshal(record.Lots)
+	if marshallErr != nil {
+		log.Printf("Error marshalling record lots: %v\n", marshallErr)
+		return marshallErr
+	}
+
 	queryInput := &dynamodb.UpdateItemInput{
 		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
 			":purchaseValue": {
 				N: aws.String(fmt.Sprintf("%v", record.PurchaseValue)),
 			},
+			":currentValue": {
+				N: aws.String(fmt.Sprintf("%v", record.CurrentValue)),
+			},
 			":portfolioPercentage": {
 				N: aws.String(fmt.Sprintf("%v", record.PortfolioPercentage)),
 			},
@@ -145,11 +170,15 @@ func UpdateOpenPosition(svc *dynamodb.DynamoDB, record OpenStockPosition) error
 			":currentStockPrice": {
 				N: aws.String(fmt.Sprintf("%v", record.CurrentStockPrice)),
 			},
+			":lots": lots,
+			":newVersion": {
+				N: aws.String(fmt.Sprintf("%v", record.Version+1)),
+			},
 		},
-		TableName: aws.String("PORTFOLIO"),
+		TableName: aws.String(tableName),
 		Key: map[string]*dynamodb.AttributeValue{
 			"PK": {
-				S: aws.String(record.PK),
+				S: aws.String(openPositionKey),
 			},
 			"SK": {
 				S: aws.String(record.SK),
@@ -158,37 +187,454 @@ func UpdateOpenPosition(svc *dynamodb.DynamoDB, record OpenStockPosition) error
 		ReturnValues: aws.String("UPDATED_NEW"),
 		UpdateExpression: aws.String("set " +
 			"PurchaseValue = :purchaseValue, " +
+			"CurrentValue = :currentValue, " +
 			"PortfolioPercentage = :portfolioPercentage, " +
 			"AveragePrice = :averagePrice, " +
 			"PercentageReturn = :percentageReturn, " +
 			"Shares = :shares, " +
-			"CurrentStockPrice = :currentStockPrice",
+			"CurrentStockPrice = :currentStockPrice, " +
+			"Lots = :lots, " +
+			"Version = :newVersion",
 		),
+		ConditionExpression: condition,
+	}
+	for key, value := range conditionValues {
+		queryInput.ExpressionAttributeValues[key] = value
 	}
 
-	if _, err := svc.UpdateItem(queryInput); err != nil {
+	if _, err := s.svc.UpdateItem(queryInput); err != nil {
 		log.Printf("Got error calling UpdateItem: %s", err)
-		return err
+		return conflictError(err, []string{record.SK})
 	}
 
 	return nil
 }
 
-func DeleteOpenPosition(svc *dynamodb.DynamoDB, record OpenStockPosition) error {
+// DeleteOpenPosition removes a portfolio record from the DynamoDB table.
+// The delete only succeeds if the record is stored, and still has the version it was read with.
+func (s *DynamoStore) DeleteOpenPosition(record OpenStockPosition) error {
+	condition, conditionValues := versionCondition(record.Version, true)
+
 	queryInput := &dynamodb.DeleteItemInput{
 		Key: map[string]*dynamodb.AttributeValue{
 			"PK": {
-				N: aws.String(record.PK),
+				S: aws.String(openPositionKey),
 			},
 			"SK": {
 				S: aws.String(record.SK),
 			},
 		},
-		TableName: aws.String("PORTFOLIO"),
+		TableName:                 aws.String(tableName),
+		ConditionExpression:       condition,
+		ExpressionAttributeValues: conditionValues,
 	}
-	if _, err := svc.DeleteItem(queryInput); err != nil {
+	if _, err := s.svc.DeleteItem(queryInput); err != nil {
 		log.Printf("Got error calling DeleteItem: %s", err)
-		return err
+		return conflictError(err, []string{record.SK})
+	}
+	return nil
+}
+
+// CommitTransaction writes every put, delete, ledger entry & snapshot in the transaction with a single TransactWriteItems call.
+// Each write is conditional on the stored record still having the version it was read with, and a put of version 0 creates the
+// record when it isn't stored.
+func (s *DynamoStore) CommitTransaction(tx Transaction) error {
+	if validationErr := tx.validate(); validationErr != nil {
+		log.Printf("Invalid transaction: %v\n", validationErr)
+		return validationErr
+	}
+
+	var transactItems []*dynamodb.TransactWriteItem
+	for _, record := range tx.Puts {
+		condition, conditionValues := versionCondition(record.Version, false)
+		record.PK = openPositionKey
+		record.Version++
+
+		dbRecord, marshallErr := dynamodbattribute.MarshalMap(record)
+		if marshallErr != nil {
+			log.Printf("Error marshalling record: %v\n", marshallErr)
+			return marshallErr
+		}
+
+		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				Item:                      dbRecord,
+				TableName:                 aws.String(tableName),
+				ConditionExpression:       condition,
+				ExpressionAttributeValues: conditionValues,
+			},
+		})
+	}
+	for _, record := range tx.Deletes {
+		condition, conditionValues := versionCondition(record.Version, true)
+		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+			Delete: &dynamodb.Delete{
+				Key: map[string]*dynamodb.AttributeValue{
+					"PK": {
+						S: aws.String(openPositionKey),
+					},
+					"SK": {
+						S: aws.String(record.SK),
+					},
+				},
+				TableName:                 aws.String(tableName),
+				ConditionExpression:       condition,
+				ExpressionAttributeValues: conditionValues,
+			},
+		})
+	}
+	for _, entry := range tx.Ledger {
+		entry.PK = ledgerKey
+
+		dbRecord, marshallErr := dynamodbattribute.MarshalMap(entry)
+		if marshallErr != nil {
+			log.Printf("Error marshalling ledger entry: %v\n", marshallErr)
+			return marshallErr
+		}
+
+		// The ledger is append-only, so an entry can never replace an existing one.
+		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				Item:                dbRecord,
+				TableName:           aws.String(tableName),
+				ConditionExpression: aws.String("attribute_not_exists(SK)"),
+			},
+		})
+	}
+
+	for _, snapshot := range tx.Snapshots {
+		snapshot.PK = snapshotKey
+
+		dbRecord, marshallErr := dynamodbattribute.MarshalMap(snapshot)
+		if marshallErr != nil {
+			log.Printf("Error marshalling snapshot: %v\n", marshallErr)
+			return marshallErr
+		}
+
+		// A day can be snapshotted again (e.g. when its revaluation is re-run), so snapshots are written unconditionally.
+		transactItems = append(transactItems, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				Item:      dbRecord,
+				TableName: aws.String(tableName),
+			},
+		})
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	}
+	if _, err := s.svc.TransactWriteItems(input); err != nil {
+		log.Printf("Got error calling TransactWriteItems: %s", err)
+		return conflictError(err, tx.sortKeys())
+	}
+
+	return nil
+}
+
+// versionCondition builds the condition expression which only allows a write if the stored record has the given version.
+// Records written before versioning was introduced have no Version attribute, so they are treated as version 0, the same as a
+// record which isn't stored. Only puts may create a record, so updates & deletes must also find the record stored.
+func versionCondition(version uint, mustExist bool) (*string, map[string]*dynamodb.AttributeValue) {
+	condition, conditionValues := "Version = :expectedVersion", map[string]*dynamodb.AttributeValue{
+		":expectedVersion": {
+			N: aws.String(fmt.Sprintf("%v", version)),
+		},
+	}
+	if version == 0 {
+		condition, conditionValues = "attribute_not_exists(Version)", nil
+	}
+	if mustExist {
+		condition = "attribute_exists(PK) AND " + condition
+	}
+	return aws.String(condition), conditionValues
+}
+
+// conflictError converts a failed DynamoDB condition check into a ConflictError. Any other error is returned unchanged.
+// The sort keys must be in the same order as the items of the request, so a cancelled transaction can name the conflicting record.
+func conflictError(err error, sortKeys []string) error {
+	var conditionErr *dynamodb.ConditionalCheckFailedException
+	var transactionConflictErr *dynamodb.TransactionConflictException
+	if errors.As(err, &conditionErr) || errors.As(err, &transactionConflictErr) {
+		if len(sortKeys) == 1 {
+			return &ConflictError{SK: sortKeys[0]}
+		}
+		return &ConflictError{}
+	}
+
+	var cancelledErr *dynamodb.TransactionCanceledException
+	if errors.As(err, &cancelledErr) {
+		for index, reason := range cancelledErr.CancellationReasons {
+			code := aws.StringValue(reason.Code)
+			if code != "ConditionalCheckFailed" && code != "TransactionConflict" {
+				continue
+			}
+			if index < len(sortKeys) {
+				return &ConflictError{SK: sortKeys[index]}
+			}
+			return &ConflictError{}
+		}
+	}
+
+	return err
+}
+
+// GetLedgerEntries queries the ledger partition for a page of entries matching the query.
+func (s *DynamoStore) GetLedgerEntries(query LedgerQuery) (LedgerPage, error) {
+	var page LedgerPage
+
+	start, end := query.sortKeyRange()
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :start AND :end"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {
+				S: aws.String(ledgerKey),
+			},
+			":start": {
+				S: aws.String(start),
+			},
+			":end": {
+				S: aws.String(end),
+			},
+		},
+	}
+
+	// The sort key range can't filter by date when entries of every type are requested, so filter on the timestamp instead.
+	var filters []string
+	var filterNames = make(map[string]*string)
+	if query.Symbol != "" {
+		filters = append(filters, "#symbol = :symbol")
+		filterNames["#symbol"] = aws.String("Symbol")
+		queryInput.ExpressionAttributeValues[":symbol"] = &dynamodb.AttributeValue{S: aws.String(query.Symbol)}
+	}
+	if query.EntryType == "" && query.From != "" {
+		filters = append(filters, "#timestamp >= :from")
+		filterNames["#timestamp"] = aws.String("Timestamp")
+		queryInput.ExpressionAttributeValues[":from"] = &dynamodb.AttributeValue{S: aws.String(query.From)}
+	}
+	if query.EntryType == "" && query.To != "" {
+		filters = append(filters, "#timestamp <= :to")
+		filterNames["#timestamp"] = aws.String("Timestamp")
+		queryInput.ExpressionAttributeValues[":to"] = &dynamodb.AttributeValue{S: aws.String(query.To + "~")}
+	}
+	if len(filters) > 0 {
+		queryInput.FilterExpression = aws.String(strings.Join(filters, " AND "))
+		queryInput.ExpressionAttributeNames = filterNames
+	}
+
+	if query.Limit > 0 {
+		queryInput.Limit = aws.Int64(query.Limit)
+	}
+	if query.Cursor != "" {
+		sortKey, cursorErr := decodeCursor(query.Cursor)
+		if cursorErr != nil {
+			return page, cursorErr
+		}
+		queryInput.ExclusiveStartKey = map[string]*dynamodb.AttributeValue{
+			"PK": {
+				S: aws.String(ledgerKey),
+			},
+			"SK": {
+				S: aws.String(sortKey),
+			},
+		}
+	}
+
+	// DynamoDB applies the limit before the filter, so a page filtered by symbol or date can come back short. Keep querying until
+	// the page holds the limit of matching entries, or the partition runs out.
+	for {
+		result, queryErr := s.svc.Query(queryInput)
+		if queryErr != nil {
+			log.Printf("Error querying DynamoDB: %v\n", queryErr)
+			return page, queryErr
+		}
+
+		var entries []LedgerEntry
+		if unmarshallErr := dynamodbattribute.UnmarshalListOfMaps(result.Items, &entries); unmarshallErr != nil {
+			log.Printf("Error unmarshalling DynamoDB response: %v\n", unmarshallErr)
+			return page, unmarshallErr
+		}
+		page.Entries = append(page.Entries, entries...)
+
+		lastKey, exists := result.LastEvaluatedKey["SK"]
+		if !exists {
+			page.NextCursor = ""
+			return page, nil
+		}
+		page.NextCursor = encodeCursor(aws.StringValue(lastKey.S))
+
+		remaining := query.Limit - int64(len(page.Entries))
+		if query.Limit <= 0 || remaining <= 0 {
+			return page, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+		queryInput.Limit = aws.Int64(remaining)
+	}
+}
+
+// GetSnapshots queries the snapshot partition for the snapshots between two dates, following every page of results.
+func (s *DynamoStore) GetSnapshots(from, to string) ([]PortfolioSnapshot, error) {
+	var snapshots []PortfolioSnapshot
+
+	start, end := snapshotKeyRange(from, to)
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :start AND :end"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {
+				S: aws.String(snapshotKey),
+			},
+			":start": {
+				S: aws.String(start),
+			},
+			":end": {
+				S: aws.String(end),
+			},
+		},
+	}
+
+	for {
+		result, queryErr := s.svc.Query(queryInput)
+		if queryErr != nil {
+			log.Printf("Error querying DynamoDB: %v\n", queryErr)
+			return snapshots, queryErr
+		}
+
+		var page []PortfolioSnapshot
+		if unmarshallErr := dynamodbattribute.UnmarshalListOfMaps(result.Items, &page); unmarshallErr != nil {
+			log.Printf("Error unmarshalling DynamoDB response: %v\n", unmarshallErr)
+			return snapshots, unmarshallErr
+		}
+		snapshots = append(snapshots, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return snapshots, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// GetPriceHistory queries the price partition of a symbol for every cached bar, following every page of results. Bars are
+// sorted by date, the same as their sort keys.
+func (s *DynamoStore) GetPriceHistory(symbol string) (PriceHistory, error) {
+	history := PriceHistory{Symbol: symbol, Bars: []types.Bar{}}
+
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":pk": {
+				S: aws.String(pricePrefix + symbol),
+			},
+		},
+	}
+
+	for {
+		result, queryErr := s.svc.Query(queryInput)
+		if queryErr != nil {
+			log.Printf("Error querying DynamoDB: %v\n", queryErr)
+			return history, queryErr
+		}
+
+		for _, item := range result.Items {
+			if aws.StringValue(item["SK"].S) == priceFetchKey {
+				var fetch PriceFetchRecord
+				if unmarshallErr := dynamodbattribute.UnmarshalMap(item, &fetch); unmarshallErr != nil {
+					log.Printf("Error unmarshalling DynamoDB response: %v\n", unmarshallErr)
+					return history, unmarshallErr
+				}
+				history.FetchedOn, history.FetchedFrom = fetch.FetchedOn, fetch.FetchedFrom
+				continue
+			}
+
+			var price PriceRecord
+			if unmarshallErr := dynamodbattribute.UnmarshalMap(item, &price); unmarshallErr != nil {
+				log.Printf("Error unmarshalling DynamoDB response: %v\n", unmarshallErr)
+				return history, unmarshallErr
+			}
+			history.Bars = append(history.Bars, price.Bar)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return history, nil
+		}
+		queryInput.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// PutPriceHistory writes each bar, and the record of when they were fetched, with as few BatchWriteItem calls as possible.
+// The fetch record is written last, so bars which failed to be written are fetched again.
+func (s *DynamoStore) PutPriceHistory(history PriceHistory) error {
+	var items []map[string]*dynamodb.AttributeValue
+	for _, bar := range history.Bars {
+		record := PriceRecord{PK: pricePrefix + history.Symbol, SK: dayPrefix + bar.Date, Bar: bar}
+		dbRecord, marshallErr := dynamodbattribute.MarshalMap(record)
+		if marshallErr != nil {
+			log.Printf("Error marshalling bar: %v\n", marshallErr)
+			return marshallErr
+		}
+		items = append(items, dbRecord)
+	}
+	if writeErr := s.batchPut(items); writeErr != nil {
+		return writeErr
+	}
+
+	// An empty FetchedFrom keeps the one already stored.
+	update := "SET FetchedOn = :fetchedOn"
+	values := map[string]*dynamodb.AttributeValue{
+		":fetchedOn": {
+			S: aws.String(history.FetchedOn),
+		},
+	}
+	if history.FetchedFrom != "" {
+		update += ", FetchedFrom = :fetchedFrom"
+		values[":fetchedFrom"] = &dynamodb.AttributeValue{S: aws.String(history.FetchedFrom)}
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"PK": {
+				S: aws.String(pricePrefix + history.Symbol),
+			},
+			"SK": {
+				S: aws.String(priceFetchKey),
+			},
+		},
+		UpdateExpression:          aws.String(update),
+		ExpressionAttributeValues: values,
+	}
+	if _, updateErr := s.svc.UpdateItem(input); updateErr != nil {
+		log.Printf("Got error calling UpdateItem: %s", updateErr)
+		return updateErr
+	}
+
+	return nil
+}
+
+// batchPut writes items in batches of the most BatchWriteItem allows, retrying any items DynamoDB leaves unprocessed.
+func (s *DynamoStore) batchPut(items []map[string]*dynamodb.AttributeValue) error {
+	for start := 0; start < len(items); start += maxBatchWriteItems {
+		end := start + maxBatchWriteItems
+		if end > len(items) {
+			end = len(items)
+		}
+
+		var requests []*dynamodb.WriteRequest
+		for _, item := range items[start:end] {
+			requests = append(requests, &dynamodb.WriteRequest{PutRequest: &dynamodb.PutRequest{Item: item}})
+		}
+		pending := map[string][]*dynamodb.WriteRequest{tableName: requests}
+		for attempt := 1; len(pending) > 0; attempt++ {
+			if attempt > maxBatchWriteAttempts {
+				return fmt.Errorf("%v items were still unprocessed after %v attempts", len(pending[tableName]), maxBatchWriteAttempts)
+			}
+			result, writeErr := s.svc.BatchWriteItem(&dynamodb.BatchWriteItemInput{RequestItems: pending})
+			if writeErr != nil {
+				log.Printf("Got error calling BatchWriteItem: %s", writeErr)
+				return writeErr
+			}
+			pending = result.UnprocessedItems
+		}
 	}
 	return nil
 }
@@ -0,0 +1,27 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVersionConflict is matched (using errors.Is) by every ConflictError.
+var ErrVersionConflict = errors.New("portfolio record was modified by another request")
+
+// ConflictError is returned when a conditional write fails because the stored record no longer has the version it was read
+// with. The caller should re-read the portfolio and retry, or report the conflict to the user (HTTP 409).
+type ConflictError struct {
+	SK string // Sort key of the conflicting record, when it is known.
+}
+
+func (e *ConflictError) Error() string {
+	if e.SK == "" {
+		return ErrVersionConflict.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.SK, ErrVersionConflict)
+}
+
+// Is allows callers to check for any version conflict with errors.Is(err, ErrVersionConflict).
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrVersionConflict
+}
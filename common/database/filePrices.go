@@ -0,0 +1,88 @@
+package database
+
+import (
+	"Investing-API/common/types"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FilePriceStore is a PriceStore which caches each symbol's bars in a JSON file of its own, for local development.
+type FilePriceStore struct {
+	mu        sync.Mutex
+	directory string
+}
+
+// NewFilePriceStore creates a price cache in a directory, which is created when the first prices are cached.
+func NewFilePriceStore(directory string) *FilePriceStore {
+	return &FilePriceStore{directory: directory}
+}
+
+// GetPriceHistory reads the cached bars of a symbol from its file.
+func (s *FilePriceStore) GetPriceHistory(symbol string) (PriceHistory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(symbol)
+}
+
+// PutPriceHistory adds bars to the file of a symbol.
+func (s *FilePriceStore) PutPriceHistory(history PriceHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cached, readErr := s.read(history.Symbol)
+	if readErr != nil {
+		return readErr
+	}
+	contents, marshallErr := json.MarshalIndent(mergePrices(cached, history), "", "  ")
+	if marshallErr != nil {
+		log.Printf("Error marshalling cached prices: %v\n", marshallErr)
+		return marshallErr
+	}
+
+	if mkdirErr := os.MkdirAll(s.directory, 0755); mkdirErr != nil {
+		log.Printf("Error creating price cache directory: %v\n", mkdirErr)
+		return mkdirErr
+	}
+	// Write to a temporary file first, so a failed write can't leave a half written cache behind.
+	temporaryPath := s.path(history.Symbol) + ".tmp"
+	if writeErr := ioutil.WriteFile(temporaryPath, contents, 0644); writeErr != nil {
+		log.Printf("Error writing cached prices: %v\n", writeErr)
+		return writeErr
+	}
+	return os.Rename(temporaryPath, s.path(history.Symbol))
+}
+
+// read loads the cached prices of a symbol. The caller must hold the lock.
+func (s *FilePriceStore) read(symbol string) (PriceHistory, error) {
+	history := PriceHistory{Symbol: symbol, Bars: []types.Bar{}}
+	contents, readErr := ioutil.ReadFile(s.path(symbol))
+	if errors.Is(readErr, os.ErrNotExist) {
+		return history, nil
+	}
+	if readErr != nil {
+		log.Printf("Error reading cached prices: %v\n", readErr)
+		return history, readErr
+	}
+
+	if unmarshallErr := json.Unmarshal(contents, &history); unmarshallErr != nil {
+		log.Printf("Error unmarshalling cached prices of %v: %v\n", symbol, unmarshallErr)
+		return history, unmarshallErr
+	}
+	return history, nil
+}
+
+// path returns the file of a symbol. Path separators are replaced, so a symbol can't name a file outside the directory.
+func (s *FilePriceStore) path(symbol string) string {
+	fileName := strings.NewReplacer("/", "_", `\`, "_").Replace(symbol)
+	if fileName == "." || fileName == ".." {
+		fileName = "_"
+	}
+	return filepath.Join(s.directory, fileName+".json")
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"Investing-API/common/types"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+const (
+	// ledgerKey is the partition key shared by every ledger entry.
+	ledgerKey = "LEDGER"
+
+	// ledgerTimeFormat is a fixed-width timestamp, so that ledger sort keys order by time.
+	ledgerTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+	// EntryTypeTrade is the ledger entry type of a buy or sell.
+	EntryTypeTrade = "TRADE"
+
+	// EntryTypeCorporateAction is the ledger entry type of a split or dividend applied to a position.
+	EntryTypeCorporateAction = "CORPORATE_ACTION"
+
+	// EntryTypeDividend is the ledger entry type of a dividend credited to CASH.
+	EntryTypeDividend = "DIVIDEND"
+
+	// EntryTypeCashFlow is the ledger entry type of money deposited into, or withdrawn from, CASH from outside the portfolio.
+	EntryTypeCashFlow = "CASH_FLOW"
+
+	// SideBuy & SideSell are the sides of a trade ledger entry.
+	SideBuy  = "BUY"
+	SideSell = "SELL"
+
+	// SideDeposit & SideWithdrawal are the sides of a cash flow ledger entry.
+	SideDeposit    = "DEPOSIT"
+	SideWithdrawal = "WITHDRAWAL"
+)
+
+// NewTradeEntry builds the ledger entry of a trade made at the given time.
+func NewTradeEntry(trade types.NewStockTrade, side, requestID string, at time.Time) LedgerEntry {
+	timestamp := at.UTC().Format(ledgerTimeFormat)
+	return LedgerEntry{
+		PK:        ledgerKey,
+		SK:        EntryTypeTrade + "#" + timestamp,
+		EntryType: EntryTypeTrade,
+		Timestamp: timestamp,
+		RequestID: requestID,
+		Symbol:    trade.Symbol,
+		Side:      side,
+		Quantity:  trade.Quantity,
+		Price:     trade.Price,
+		Fees:      trade.TotalFees(),
+	}
+}
+
+// NewCorporateActionEntry builds the ledger entry of a corporate action applied to a position at the given time. The quantity is
+// the shares held once the action was applied.
+func NewCorporateActionEntry(action types.CorporateAction, quantity types.Decimal, at time.Time) LedgerEntry {
+	timestamp := at.UTC().Format(ledgerTimeFormat)
+	return LedgerEntry{
+		PK:        ledgerKey,
+		SK:        EntryTypeCorporateAction + "#" + timestamp,
+		EntryType: EntryTypeCorporateAction,
+		Timestamp: timestamp,
+		Symbol:    action.Symbol,
+		Quantity:  quantity,
+		Price:     action.Amount,
+		Action:    action.Type,
+		ExDate:    action.Date,
+		Ratio:     action.Ratio,
+	}
+}
+
+// NewDividendEntry builds the ledger entry of a dividend credited at the given time.
+func NewDividendEntry(dividend types.NewDividend, requestID string, at time.Time) LedgerEntry {
+	timestamp := at.UTC().Format(ledgerTimeFormat)
+	return LedgerEntry{
+		PK:             ledgerKey,
+		SK:             EntryTypeDividend + "#" + timestamp,
+		EntryType:      EntryTypeDividend,
+		Timestamp:      timestamp,
+		RequestID:      requestID,
+		Symbol:         dividend.Symbol,
+		Quantity:       dividend.Quantity,
+		Price:          dividend.AmountPerShare,
+		ExDate:         dividend.ExDate,
+		Amount:         dividend.Net(),
+		WithholdingTax: dividend.WithholdingTax,
+	}
+}
+
+// NewCashFlowEntry builds the ledger entry of a deposit or withdrawal made at the given time. The entry's Amount is the change to
+// CASH, so it is negative for a withdrawal.
+func NewCashFlowEntry(cashFlow types.NewCashFlow, side, requestID string, at time.Time) LedgerEntry {
+	timestamp := at.UTC().Format(ledgerTimeFormat)
+	amount := cashFlow.Amount
+	if side == SideWithdrawal {
+		amount = amount.Neg()
+	}
+	return LedgerEntry{
+		PK:        ledgerKey,
+		SK:        EntryTypeCashFlow + "#" + timestamp,
+		EntryType: EntryTypeCashFlow,
+		Timestamp: timestamp,
+		RequestID: requestID,
+		Symbol:    "CASH",
+		Side:      side,
+		Amount:    amount,
+	}
+}
+
+// sortKeyRange returns the inclusive range of sort keys which can match the query.
+// Entries of every type share the ledger partition, so without an entry type the whole partition is in range.
+func (q LedgerQuery) sortKeyRange() (string, string) {
+	if q.EntryType == "" {
+		return "", "~"
+	}
+	start, end := q.EntryType+"#", q.EntryType+"#~"
+	if q.From != "" {
+		start += q.From
+	}
+	if q.To != "" {
+		// Every timestamp on the To date sorts after the bare date, and before the date followed by '~'.
+		end = q.EntryType + "#" + q.To + "~"
+	}
+	return start, end
+}
+
+// matches checks an entry against the query's filters.
+func (q LedgerQuery) matches(entry LedgerEntry) bool {
+	if q.EntryType != "" && entry.EntryType != q.EntryType {
+		return false
+	}
+	if q.Symbol != "" && entry.Symbol != q.Symbol {
+		return false
+	}
+	if q.From != "" && entry.Timestamp < q.From {
+		return false
+	}
+	if q.To != "" && entry.Timestamp > q.To+"~" {
+		return false
+	}
+	return true
+}
+
+// encodeCursor turns the sort key of the last entry on a page into an opaque pagination cursor.
+func encodeCursor(sortKey string) string {
+	if sortKey == "" {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(sortKey))
+}
+
+// decodeCursor returns the sort key a pagination cursor was created from.
+func decodeCursor(cursor string) (string, error) {
+	sortKey, decodeErr := base64.RawURLEncoding.DecodeString(cursor)
+	if decodeErr != nil {
+		return "", fmt.Errorf("invalid cursor: %v", decodeErr)
+	}
+	return string(sortKey), nil
+}
+
+// GetAllLedgerEntries follows the pages of a ledger query until every matching entry has been read.
+func GetAllLedgerEntries(store PortfolioStore, query LedgerQuery) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+	for {
+		page, queryErr := store.GetLedgerEntries(query)
+		if queryErr != nil {
+			return entries, queryErr
+		}
+		entries = append(entries, page.Entries...)
+		if page.NextCursor == "" {
+			return entries, nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a thread-safe, in-memory PortfolioStore. It is used for local development & unit tests.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	positions map[string]OpenStockPosition
+	ledger    map[string]LedgerEntry
+	snapshots map[string]PortfolioSnapshot
+	prices    map[string]PriceHistory
+}
+
+// NewMemoryStore creates an in-memory store, seeded with the given portfolio positions.
+func NewMemoryStore(records ...OpenStockPosition) *MemoryStore {
+	store := &MemoryStore{
+		positions: make(map[string]OpenStockPosition),
+		ledger:    make(map[string]LedgerEntry),
+		snapshots: make(map[string]PortfolioSnapshot),
+		prices:    make(map[string]PriceHistory),
+	}
+	for _, record := range records {
+		record.PK = openPositionKey
+		store.positions[record.SK] = record
+	}
+	return store
+}
+
+// GetAllOpenPositions returns a copy of every stored portfolio position, ordered by symbol.
+func (s *MemoryStore) GetAllOpenPositions() ([]OpenStockPosition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var openPositions []OpenStockPosition
+	for _, record := range s.positions {
+		openPositions = append(openPositions, record)
+	}
+	sort.Slice(openPositions, func(i, j int) bool {
+		return openPositions[i].SK < openPositions[j].SK
+	})
+
+	return openPositions, nil
+}
+
+// GetOpenPosition returns the stored position of a single symbol.
+func (s *MemoryStore) GetOpenPosition(symbol string) (OpenStockPosition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.positions[symbol], nil
+}
+
+// AddNewPosition stores a new portfolio position.
+func (s *MemoryStore) AddNewPosition(record OpenStockPosition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.positions[record.SK]; exists {
+		return &ConflictError{SK: record.SK}
+	}
+	s.put(record)
+	return nil
+}
+
+// UpdateOpenPosition overwrites a stored portfolio position.
+func (s *MemoryStore) UpdateOpenPosition(record OpenStockPosition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if versionErr := s.checkVersion(record, true); versionErr != nil {
+		return versionErr
+	}
+	s.put(record)
+	return nil
+}
+
+// DeleteOpenPosition removes a stored portfolio position.
+func (s *MemoryStore) DeleteOpenPosition(record OpenStockPosition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if versionErr := s.checkVersion(record, true); versionErr != nil {
+		return versionErr
+	}
+	delete(s.positions, record.SK)
+	return nil
+}
+
+// CommitTransaction validates the whole transaction, including every record's version, before applying any of its writes.
+func (s *MemoryStore) CommitTransaction(tx Transaction) error {
+	if validationErr := tx.validate(); validationErr != nil {
+		return validationErr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range tx.Puts {
+		if versionErr := s.checkVersion(record, false); versionErr != nil {
+			return versionErr
+		}
+	}
+	for _, record := range tx.Deletes {
+		if versionErr := s.checkVersion(record, true); versionErr != nil {
+			return versionErr
+		}
+	}
+	for _, entry := range tx.Ledger {
+		if _, exists := s.ledger[entry.SK]; exists {
+			return &ConflictError{SK: entry.SK}
+		}
+	}
+
+	for _, record := range tx.Puts {
+		s.put(record)
+	}
+	for _, record := range tx.Deletes {
+		delete(s.positions, record.SK)
+	}
+	for _, entry := range tx.Ledger {
+		entry.PK = ledgerKey
+		s.ledger[entry.SK] = entry
+	}
+	for _, snapshot := range tx.Snapshots {
+		snapshot.PK = snapshotKey
+		s.snapshots[snapshot.SK] = snapshot
+	}
+	return nil
+}
+
+// GetLedgerEntries returns a page of stored ledger entries matching the query, in sort key order.
+func (s *MemoryStore) GetLedgerEntries(query LedgerQuery) (LedgerPage, error) {
+	var page LedgerPage
+
+	var after string
+	if query.Cursor != "" {
+		sortKey, cursorErr := decodeCursor(query.Cursor)
+		if cursorErr != nil {
+			return page, cursorErr
+		}
+		after = sortKey
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sortKeys []string
+	for sortKey := range s.ledger {
+		sortKeys = append(sortKeys, sortKey)
+	}
+	sort.Strings(sortKeys)
+
+	start, end := query.sortKeyRange()
+	for _, sortKey := range sortKeys {
+		if sortKey <= after || sortKey < start || sortKey > end || !query.matches(s.ledger[sortKey]) {
+			continue
+		}
+		if query.Limit > 0 && int64(len(page.Entries)) == query.Limit {
+			page.NextCursor = encodeCursor(page.Entries[len(page.Entries)-1].SK)
+			break
+		}
+		page.Entries = append(page.Entries, s.ledger[sortKey])
+	}
+
+	return page, nil
+}
+
+// GetSnapshots returns the stored snapshots between two dates, oldest first.
+func (s *MemoryStore) GetSnapshots(from, to string) ([]PortfolioSnapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, end := snapshotKeyRange(from, to)
+	var snapshots []PortfolioSnapshot
+	for sortKey, snapshot := range s.snapshots {
+		if sortKey >= start && sortKey <= end {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].SK < snapshots[j].SK
+	})
+
+	return snapshots, nil
+}
+
+// GetPriceHistory returns a copy of the cached bars of a symbol.
+func (s *MemoryStore) GetPriceHistory(symbol string) (PriceHistory, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := mergePrices(PriceHistory{}, s.prices[symbol])
+	history.Symbol = symbol
+	return history, nil
+}
+
+// PutPriceHistory adds bars to the cache of a symbol.
+func (s *MemoryStore) PutPriceHistory(history PriceHistory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prices[history.Symbol] = mergePrices(s.prices[history.Symbol], history)
+	return nil
+}
+
+// checkVersion compares a record's version to the stored copy. A missing record has version 0, unless it must exist, as for updates
+// & deletes. The caller must hold the lock.
+func (s *MemoryStore) checkVersion(record OpenStockPosition, mustExist bool) error {
+	stored, exists := s.positions[record.SK]
+	if (mustExist && !exists) || stored.Version != record.Version {
+		return &ConflictError{SK: record.SK}
+	}
+	return nil
+}
+
+// put stores a record with its version incremented. The caller must hold the lock.
+func (s *MemoryStore) put(record OpenStockPosition) {
+	record.PK = openPositionKey
+	record.Version++
+	s.positions[record.SK] = record
+}
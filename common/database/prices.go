@@ -0,0 +1,80 @@
+package database
+
+import (
+	"Investing-API/common/types"
+	"os"
+)
+
+const (
+	// pricePrefix starts the partition key of every cached price, which is followed by the price's symbol.
+	pricePrefix = "PRICE#"
+
+	// dayPrefix starts the sort key of every cached price, which is followed by the price's trading day.
+	dayPrefix = "DAY#"
+
+	// priceFetchKey is the sort key of the record of when a symbol's prices were last fetched.
+	priceFetchKey = "FETCHED"
+
+	// maxBatchWriteItems is the most items a single BatchWriteItem call can write.
+	maxBatchWriteItems = 25
+
+	// maxBatchWriteAttempts is how many times a batch is written before the items DynamoDB leaves unprocessed are given up on.
+	maxBatchWriteAttempts = 5
+)
+
+// PriceStore caches the daily bars fetched from the price providers, so they don't have to be downloaded again.
+// DynamoStore & MemoryStore cache them alongside the portfolio, and FilePriceStore in local files for development.
+type PriceStore interface {
+	// GetPriceHistory returns every cached bar of a symbol. An empty history is returned for a symbol which isn't cached.
+	GetPriceHistory(symbol string) (PriceHistory, error)
+
+	// PutPriceHistory adds bars to a symbol's cache, replacing any cached bars of the same days, and records when (and from
+	// which date) the symbol's bars were fetched. An empty FetchedFrom keeps the cached one.
+	PutPriceHistory(history PriceHistory) error
+}
+
+// PriceHistory is the cached daily bars of a symbol.
+type PriceHistory struct {
+	Symbol      string      `json:"Symbol"`
+	Bars        []types.Bar `json:"Bars"`        // Sorted by date.
+	FetchedOn   string      `json:"FetchedOn"`   // The day the bars were last fetched, YYYY-MM-DD. Empty if never.
+	FetchedFrom string      `json:"FetchedFrom"` // The earliest day bars have been fetched from, YYYY-MM-DD.
+}
+
+// PriceRecord is a single cached bar, as stored in the PORTFOLIO table.
+type PriceRecord struct {
+	PK string `json:"PK"` // PRICE#<Symbol>, e.g. PRICE#AAPL
+	SK string `json:"SK"` // DAY#<Date>, e.g. DAY#2022-04-11
+	types.Bar
+}
+
+// PriceFetchRecord is the record of when a symbol's prices were last fetched, as stored in the PORTFOLIO table.
+type PriceFetchRecord struct {
+	PK          string `json:"PK"` // PRICE#<Symbol>
+	SK          string `json:"SK"` // FETCHED
+	FetchedOn   string `json:"FetchedOn"`
+	FetchedFrom string `json:"FetchedFrom"`
+}
+
+// ConfiguredPriceStore returns the price cache to use: local files in the PRICE_CACHE_DIR directory when it's set, for
+// development, or the DynamoDB PORTFOLIO table.
+func ConfiguredPriceStore() PriceStore {
+	if directory := os.Getenv("PRICE_CACHE_DIR"); directory != "" {
+		return NewFilePriceStore(directory)
+	}
+	return NewDynamoStore(Login())
+}
+
+// mergePrices adds an update to a cached history.
+func mergePrices(cached, update PriceHistory) PriceHistory {
+	merged := PriceHistory{
+		Symbol:      update.Symbol,
+		Bars:        types.MergeBars(cached.Bars, update.Bars),
+		FetchedOn:   update.FetchedOn,
+		FetchedFrom: update.FetchedFrom,
+	}
+	if merged.FetchedFrom == "" {
+		merged.FetchedFrom = cached.FetchedFrom
+	}
+	return merged
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPriceStores checks that cached bars are added to, rather than replaced, by both local price stores.
+func TestPriceStores(t *testing.T) {
+	stores := map[string]PriceStore{
+		"Memory": NewMemoryStore(),
+		"File":   NewFilePriceStore(t.TempDir()),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			empty, getErr := store.GetPriceHistory("../AAPL")
+			assert.NoError(t, getErr)
+			assert.Equal(t, PriceHistory{Symbol: "../AAPL", Bars: []types.Bar{}}, empty)
+
+			assert.NoError(t, store.PutPriceHistory(PriceHistory{
+				Symbol:      "../AAPL",
+				Bars:        []types.Bar{bar("2022-04-07", "172.14"), bar("2022-04-08", "170")},
+				FetchedOn:   "2022-04-08",
+				FetchedFrom: "2022-04-07",
+			}))
+			// A later fetch corrects the last bar, and keeps the date bars were first fetched from.
+			assert.NoError(t, store.PutPriceHistory(PriceHistory{
+				Symbol:    "../AAPL",
+				Bars:      []types.Bar{bar("2022-04-11", "165.75"), bar("2022-04-08", "170.09")},
+				FetchedOn: "2022-04-11",
+			}))
+
+			history, getErr := store.GetPriceHistory("../AAPL")
+			assert.NoError(t, getErr)
+			assert.Equal(t, PriceHistory{
+				Symbol:      "../AAPL",
+				Bars:        []types.Bar{bar("2022-04-07", "172.14"), bar("2022-04-08", "170.09"), bar("2022-04-11", "165.75")},
+				FetchedOn:   "2022-04-11",
+				FetchedFrom: "2022-04-07",
+			}, history)
+		})
+	}
+}
+
+// bar builds the bar of a day, with the same open, high, low & close, to keep the test cases short.
+func bar(date, price string) types.Bar {
+	return types.Bar{Date: date, Open: types.MustParseDecimal(price), High: types.MustParseDecimal(price), Low: types.MustParseDecimal(price), Close: types.MustParseDecimal(price), Volume: 1000}
+}
@@ -0,0 +1,31 @@
+package database
+
+const (
+	// snapshotKey is the partition key shared by every daily portfolio snapshot.
+	snapshotKey = "SNAPSHOT"
+
+	// snapshotPrefix starts the sort key of every snapshot, which is followed by the snapshot's date.
+	snapshotPrefix = "SNAPSHOT#"
+)
+
+// NewSnapshot creates an empty snapshot of the portfolio on a date (YYYY-MM-DD).
+func NewSnapshot(date string) PortfolioSnapshot {
+	return PortfolioSnapshot{
+		PK:   snapshotKey,
+		SK:   snapshotPrefix + date,
+		Date: date,
+	}
+}
+
+// snapshotKeyRange returns the inclusive range of snapshot sort keys between two dates. An empty date leaves that end of the
+// range open.
+func snapshotKeyRange(from, to string) (string, string) {
+	start, end := snapshotPrefix, snapshotPrefix+"~"
+	if from != "" {
+		start += from
+	}
+	if to != "" {
+		end = snapshotPrefix + to
+	}
+	return start, end
+}
@@ -0,0 +1,36 @@
+package database
+
+// PortfolioStore is the set of operations the Lambdas use to read and write portfolio records.
+// DynamoStore is the production implementation, and MemoryStore is used for local development & unit tests.
+type PortfolioStore interface {
+	// GetAllOpenPositions returns every active portfolio position, ordered by symbol.
+	GetAllOpenPositions() ([]OpenStockPosition, error)
+
+	// GetOpenPosition returns the portfolio position of a single symbol.
+	// An empty record is returned when the symbol is not held.
+	GetOpenPosition(symbol string) (OpenStockPosition, error)
+
+	// AddNewPosition creates a new open portfolio position.
+	// A ConflictError is returned if the symbol already has a position.
+	AddNewPosition(record OpenStockPosition) error
+
+	// UpdateOpenPosition overwrites the values of an existing portfolio position.
+	// A ConflictError is returned if the position isn't stored, or the stored record's version doesn't match record.Version.
+	UpdateOpenPosition(record OpenStockPosition) error
+
+	// DeleteOpenPosition removes a portfolio position.
+	// A ConflictError is returned if the position isn't stored, or the stored record's version doesn't match record.Version.
+	DeleteOpenPosition(record OpenStockPosition) error
+
+	// CommitTransaction applies every write in the transaction atomically.
+	// A ConflictError is returned, and nothing is written, if any record's version doesn't match the stored record, or a
+	// deleted record isn't stored. A put of version 0 creates the record.
+	CommitTransaction(tx Transaction) error
+
+	// GetLedgerEntries returns a page of ledger entries matching the query, in sort key order.
+	GetLedgerEntries(query LedgerQuery) (LedgerPage, error)
+
+	// GetSnapshots returns the daily portfolio snapshots between two dates (YYYY-MM-DD, inclusive), oldest first.
+	// An empty date leaves that end of the range open.
+	GetSnapshots(from, to string) ([]PortfolioSnapshot, error)
+}
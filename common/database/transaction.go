@@ -0,0 +1,53 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxTransactionItems is the largest number of writes DynamoDB accepts in a single TransactWriteItems call.
+const maxTransactionItems = 100
+
+// ErrEmptyTransaction is returned when a transaction without any writes is committed.
+var ErrEmptyTransaction = errors.New("transaction contains no writes")
+
+// validate checks the transaction against the same limits DynamoDB applies, so the in-memory store rejects the same input.
+func (tx Transaction) validate() error {
+	itemCount := len(tx.Puts) + len(tx.Deletes) + len(tx.Ledger) + len(tx.Snapshots)
+	if itemCount == 0 {
+		return ErrEmptyTransaction
+	}
+	if itemCount > maxTransactionItems {
+		return fmt.Errorf("transaction contains %v writes, the maximum is %v", itemCount, maxTransactionItems)
+	}
+
+	// DynamoDB rejects transactions which write to the same item more than once.
+	seen := make(map[string]bool, itemCount)
+	for _, sortKey := range tx.sortKeys() {
+		if seen[sortKey] {
+			return fmt.Errorf("transaction writes to %v more than once", sortKey)
+		}
+		seen[sortKey] = true
+	}
+
+	return nil
+}
+
+// sortKeys lists the sort key of every write in the transaction, in the order they're sent to DynamoDB.
+// Positions, ledger entries & snapshots have distinct sort keys, so a sort key is enough to identify each item.
+func (tx Transaction) sortKeys() []string {
+	var sortKeys []string
+	for _, record := range tx.Puts {
+		sortKeys = append(sortKeys, record.SK)
+	}
+	for _, record := range tx.Deletes {
+		sortKeys = append(sortKeys, record.SK)
+	}
+	for _, entry := range tx.Ledger {
+		sortKeys = append(sortKeys, entry.SK)
+	}
+	for _, snapshot := range tx.Snapshots {
+		sortKeys = append(sortKeys, snapshot.SK)
+	}
+	return sortKeys
+}
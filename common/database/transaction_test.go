@@ -0,0 +1,108 @@
+package database
+
+import (
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCommitTransaction checks that a transaction is either applied in full, or leaves the store untouched.
+func TestCommitTransaction(t *testing.T) {
+	var startingPositions = []OpenStockPosition{
+		{PK: "OPEN-POSITION", SK: "AAPL", PurchaseValue: types.MustParseDecimal("400"), Shares: types.MustParseDecimal("4")},
+		{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("600")},
+	}
+
+	tests := map[string]struct {
+		transaction       Transaction
+		expectErr         bool
+		expectedPositions []OpenStockPosition
+	}{
+		"Put & Delete": {
+			Transaction{
+				Puts:    []OpenStockPosition{{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000")}},
+				Deletes: []OpenStockPosition{{SK: "AAPL"}},
+			},
+			false,
+			[]OpenStockPosition{
+				{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), Version: 1},
+			},
+		},
+		"Duplicate Item": {
+			Transaction{
+				Puts:    []OpenStockPosition{{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000")}},
+				Deletes: []OpenStockPosition{{SK: "CASH"}},
+			},
+			true,
+			startingPositions,
+		},
+		"Stale Version": {
+			Transaction{
+				Puts: []OpenStockPosition{{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), Version: 3}},
+			},
+			true,
+			startingPositions,
+		},
+		"Delete Missing Position": {
+			Transaction{
+				Deletes: []OpenStockPosition{{SK: "TSLA"}},
+			},
+			true,
+			startingPositions,
+		},
+		"Empty Transaction": {
+			Transaction{},
+			true,
+			startingPositions,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := NewMemoryStore(startingPositions...)
+
+			commitErr := store.CommitTransaction(testCase.transaction)
+			assert.Equal(t, testCase.expectErr, commitErr != nil)
+
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+		})
+	}
+}
+
+// TestUpdateOpenPosition checks that an update only overwrites a stored position with the same version, and never creates one.
+func TestUpdateOpenPosition(t *testing.T) {
+	tests := map[string]struct {
+		record            OpenStockPosition
+		expectErr         bool
+		expectedPositions []OpenStockPosition
+	}{
+		"Stored Position": {
+			OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), Version: 1},
+			false,
+			[]OpenStockPosition{{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), Version: 2}},
+		},
+		"Stale Version": {
+			OpenStockPosition{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000")},
+			true,
+			[]OpenStockPosition{{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), Version: 1}},
+		},
+		"Missing Position": {
+			OpenStockPosition{SK: "AAPL", Shares: types.MustParseDecimal("4")},
+			true,
+			[]OpenStockPosition{{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), Version: 1}},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := NewMemoryStore(OpenStockPosition{PK: "OPEN-POSITION", SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), Version: 1})
+
+			updateErr := store.UpdateOpenPosition(testCase.record)
+			assert.Equal(t, testCase.expectErr, updateErr != nil)
+			storedPositions, _ := store.GetAllOpenPositions()
+			assert.Equal(t, testCase.expectedPositions, storedPositions)
+		})
+	}
+}
@@ -1,13 +1,112 @@
 package database
 
+import "Investing-API/common/types"
+
 // OpenStockPosition is the data structure of a portfolio record in DynamoDB.
 type OpenStockPosition struct {
-	PK                  string  `json:"PK"`
-	SK                  string  `json:"SK"`
-	PurchaseValue       float64 `json:"PurchaseValue"`
-	PortfolioPercentage float64 `json:"PortfolioPercentage"`
-	AveragePrice        float64 `json:"AveragePrice"`
-	PercentageReturn    float64 `json:"PercentageReturn"`
-	Shares              uint    `json:"Shares"`
-	CurrentStockPrice   float64 `json:"CurrentStockPrice"`
+	PK                  string        `json:"PK"`
+	SK                  string        `json:"SK"`
+	PurchaseValue       types.Decimal `json:"PurchaseValue"`
+	CurrentValue        types.Decimal `json:"CurrentValue"`
+	PortfolioPercentage types.Decimal `json:"PortfolioPercentage"`
+	AveragePrice        types.Decimal `json:"AveragePrice"`
+	PercentageReturn    types.Decimal `json:"PercentageReturn"`
+	Shares              types.Decimal `json:"Shares"`
+	CurrentStockPrice   types.Decimal `json:"CurrentStockPrice"`
+	Lots                []Lot         `json:"Lots,omitempty"` // The shares still held from each buy, oldest first.
+	Version             uint          `json:"Version"`        // Incremented on every write. Used for optimistic concurrency control.
+}
+
+// Lot is the shares of a single buy which are still held in a position.
+type Lot struct {
+	Acquired string        `json:"Acquired"` // Timestamp of the buy. Empty for shares bought before lots were tracked.
+	Quantity types.Decimal `json:"Quantity"`
+	Cost     types.Decimal `json:"Cost"` // The cost basis of the lot's remaining shares.
+}
+
+// LotSale is the part of a sell which was matched against a single lot.
+type LotSale struct {
+	Acquired          string        `json:"Acquired"`
+	Quantity          types.Decimal `json:"Quantity"`
+	CostBasis         types.Decimal `json:"CostBasis"`
+	Proceeds          types.Decimal `json:"Proceeds"`
+	RealizedPnL       types.Decimal `json:"RealizedPnL"`
+	HoldingPeriodDays int           `json:"HoldingPeriodDays"`
+}
+
+// Transaction is a group of portfolio writes which must be applied together: either every write succeeds, or none are applied.
+// Each record must carry the Version it was read with. If any stored record has since changed, the transaction fails with a
+// ConflictError.
+type Transaction struct {
+	Puts      []OpenStockPosition // Positions to create or overwrite.
+	Deletes   []OpenStockPosition // Positions to remove.
+	Ledger    []LedgerEntry       // Entries to append to the ledger. Existing ledger entries are never overwritten.
+	Snapshots []PortfolioSnapshot // Daily snapshots to create, or overwrite if the day has already been snapshotted.
+}
+
+// LedgerEntry is an append-only record of a single event which changed the portfolio, such as a trade.
+type LedgerEntry struct {
+	PK        string        `json:"PK"`
+	SK        string        `json:"SK"`        // <EntryType>#<Timestamp>, e.g. TRADE#2022-04-13T09:30:00.000000000Z
+	EntryType string        `json:"EntryType"` // The kind of event, e.g. TRADE, CORPORATE_ACTION, DIVIDEND or CASH_FLOW.
+	Timestamp string        `json:"Timestamp"` // The time of the event, in UTC.
+	RequestID string        `json:"RequestID"` // The API request which caused the event.
+	Symbol    string        `json:"Symbol"`
+	Side      string        `json:"Side"` // BUY or SELL for trades, DEPOSIT or WITHDRAWAL for cash flows. Empty for other entry types.
+	Quantity  types.Decimal `json:"Quantity"`
+	Price     types.Decimal `json:"Price"`
+	Fees      types.Decimal `json:"Fees"` // Total dealing charges paid on the trade.
+
+	// Sells only: how the shares were matched against the position's lots, and the resulting gain or loss. CostBasis & RealizedPnL
+	// are 0 on buys.
+	LotMethod   string        `json:"LotMethod,omitempty"`
+	CostBasis   types.Decimal `json:"CostBasis"`
+	RealizedPnL types.Decimal `json:"RealizedPnL"`
+	LotsSold    []LotSale     `json:"LotsSold,omitempty"`
+
+	// Corporate actions only: SPLIT or DIVIDEND, the ex-date (YYYY-MM-DD), and for splits the shares each share became. The Price
+	// of a dividend is its amount per share.
+	Action string        `json:"Action,omitempty"`
+	ExDate string        `json:"ExDate,omitempty"`
+	Ratio  types.Decimal `json:"Ratio"`
+
+	// Dividends only: the net cash credited to CASH, and the tax withheld at source. The Price of a dividend is its gross amount
+	// per share, and its ExDate the day the shares went ex-dividend. Cash flows also record their change to CASH as the Amount.
+	Amount         types.Decimal `json:"Amount"`
+	WithholdingTax types.Decimal `json:"WithholdingTax"`
+}
+
+// LedgerQuery filters and paginates the entries returned from the ledger.
+type LedgerQuery struct {
+	EntryType string // Only return entries of this type. Empty for every type.
+	Symbol    string // Only return entries for this symbol. Empty for every symbol.
+	From      string // Only return entries on or after this date (YYYY-MM-DD). Empty for no lower bound.
+	To        string // Only return entries on or before this date (YYYY-MM-DD). Empty for no upper bound.
+	Limit     int64  // The maximum number of entries returned in one page. Zero for no limit.
+	Cursor    string // The NextCursor of the previous page. Empty for the first page.
+}
+
+// LedgerPage is a single page of ledger entries, in sort key order (time order within each entry type).
+type LedgerPage struct {
+	Entries    []LedgerEntry `json:"Entries"`
+	NextCursor string        `json:"NextCursor,omitempty"` // Empty when there are no more pages.
+}
+
+// PortfolioSnapshot is the value of the whole portfolio at the close of a single day.
+type PortfolioSnapshot struct {
+	PK         string             `json:"PK"`
+	SK         string             `json:"SK"`         // SNAPSHOT#<Date>, e.g. SNAPSHOT#2022-04-11
+	Date       string             `json:"Date"`       // The trading day the positions were valued at, YYYY-MM-DD.
+	TotalValue types.Decimal      `json:"TotalValue"` // The market value of every position, including cash.
+	Cash       types.Decimal      `json:"Cash"`
+	CostBasis  types.Decimal      `json:"CostBasis"` // The purchase value of every stock position, excluding cash.
+	Positions  []SnapshotPosition `json:"Positions"` // Every stock position, ordered by symbol.
+}
+
+// SnapshotPosition is the value of a single stock position in a PortfolioSnapshot.
+type SnapshotPosition struct {
+	Symbol              string        `json:"Symbol"`
+	Shares              types.Decimal `json:"Shares"`
+	Value               types.Decimal `json:"Value"`
+	PortfolioPercentage types.Decimal `json:"PortfolioPercentage"`
 }
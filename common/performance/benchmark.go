@@ -0,0 +1,108 @@
+package performance
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"fmt"
+)
+
+// ComparisonPoint is the value of the portfolio's stock positions, and of the same money invested in the benchmark, at a
+// snapshot, with their time-weighted returns since the start of the comparison.
+type ComparisonPoint struct {
+	Date            string        `json:"Date"`
+	PortfolioValue  types.Decimal `json:"PortfolioValue"`
+	BenchmarkValue  types.Decimal `json:"BenchmarkValue"`
+	PortfolioReturn types.Decimal `json:"PortfolioReturn"`
+	BenchmarkReturn types.Decimal `json:"BenchmarkReturn"`
+	ExcessReturn    types.Decimal `json:"ExcessReturn"` // The portfolio's return, less the benchmark's.
+}
+
+// Comparison is the performance of the portfolio's stock positions against a benchmark symbol over a period.
+type Comparison struct {
+	Benchmark       string            `json:"Benchmark"`
+	From            string            `json:"From"`
+	To              string            `json:"To"`
+	PortfolioReturn types.Decimal     `json:"PortfolioReturn"`
+	BenchmarkReturn types.Decimal     `json:"BenchmarkReturn"`
+	ExcessReturn    types.Decimal     `json:"ExcessReturn"`
+	Curve           []ComparisonPoint `json:"Curve"` // One point for each snapshot, oldest first.
+}
+
+// CompareBenchmark measures the portfolio's stock positions against a benchmark, by simulating the same investments in the
+// benchmark: the value of the positions at the start of the period buys the benchmark, every later buy buys the same amount of
+// it, and every later sell or dividend (money paid out of the positions into cash) sells the same amount of it. Cash isn't
+// invested in either, so is left out of both. The benchmark's prices are a lookup map of [date] => closing-price, which should be
+// adjusted for dividends, as the positions' dividends are counted; a date without a price uses the last price before it.
+func CompareBenchmark(snapshots []database.PortfolioSnapshot, entries []database.LedgerEntry, benchmark string, benchmarkPrices map[string]types.Decimal, from, to string) (Comparison, error) {
+	window := selectSnapshots(snapshots, from, to)
+	if len(window) == 0 {
+		return Comparison{}, ErrNoSnapshots
+	}
+	comparison := Comparison{Benchmark: benchmark, From: window[0].Date, To: window[len(window)-1].Date}
+
+	portfolio, simulated := newSeries(len(window)), newSeries(len(window))
+	for index, snapshot := range window {
+		portfolio.values[index] = snapshot.TotalValue.Sub(snapshot.Cash)
+	}
+
+	// Buy the benchmark with the starting value of the positions, then follow the portfolio's trades & dividends, in date order.
+	var shares types.Decimal
+	if portfolio.values[0].Sign() > 0 {
+		startPrice, priceErr := closingPrice(benchmarkPrices, comparison.From)
+		if priceErr != nil {
+			return comparison, fmt.Errorf("%v: %v", benchmark, priceErr)
+		}
+		shares = portfolio.values[0].Div(startPrice, 10)
+	}
+	flows, next := symbolFlows(window, entries), 0
+	for index, snapshot := range window {
+		for ; next < len(flows) && flows[next].index == index; next++ {
+			flow := flows[next]
+			flowPrice, priceErr := closingPrice(benchmarkPrices, flow.date.Format(dateFormat))
+			if priceErr != nil {
+				return comparison, fmt.Errorf("%v: %v", benchmark, priceErr)
+			}
+			shares = shares.Add(flow.amount.Div(flowPrice, 10))
+			portfolio.addFlow(flow.index, flow.date, flow.amount)
+			simulated.addFlow(flow.index, flow.date, flow.amount)
+		}
+
+		price, priceErr := closingPrice(benchmarkPrices, snapshot.Date)
+		if priceErr != nil {
+			return comparison, fmt.Errorf("%v: %v", benchmark, priceErr)
+		}
+		simulated.values[index] = shares.Mul(price).Round(2)
+	}
+
+	portfolioReturns := cumulativeReturns(portfolio.values, portfolio.inflows, portfolio.outflows)
+	benchmarkReturns := cumulativeReturns(simulated.values, simulated.inflows, simulated.outflows)
+	for index, snapshot := range window {
+		point := ComparisonPoint{
+			Date:            snapshot.Date,
+			PortfolioValue:  portfolio.values[index],
+			BenchmarkValue:  simulated.values[index],
+			PortfolioReturn: types.DecimalFromFloat(portfolioReturns[index], 4),
+			BenchmarkReturn: types.DecimalFromFloat(benchmarkReturns[index], 4),
+		}
+		point.ExcessReturn = point.PortfolioReturn.Sub(point.BenchmarkReturn)
+		comparison.Curve = append(comparison.Curve, point)
+	}
+
+	last := comparison.Curve[len(comparison.Curve)-1]
+	comparison.PortfolioReturn, comparison.BenchmarkReturn, comparison.ExcessReturn = last.PortfolioReturn, last.BenchmarkReturn, last.ExcessReturn
+	return comparison, nil
+}
+
+// closingPrice returns the price on a date, or the last price before it when the market was closed on the date.
+func closingPrice(prices map[string]types.Decimal, date string) (types.Decimal, error) {
+	var latest string
+	for priceDate, price := range prices {
+		if priceDate <= date && priceDate > latest && price.Sign() > 0 {
+			latest = priceDate
+		}
+	}
+	if latest == "" {
+		return types.Decimal{}, fmt.Errorf("no price data on or before %v", date)
+	}
+	return prices[latest], nil
+}
@@ -0,0 +1,102 @@
+package performance
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompareBenchmark checks that the portfolio's trades are followed in the benchmark, including on days without a price.
+func TestCompareBenchmark(t *testing.T) {
+	snapshots := []database.PortfolioSnapshot{
+		snapshot("2022-01-03", "1000", "800", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal("200")}),
+		snapshot("2022-01-05", "1050", "700",
+			database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal("240")},
+			database.SnapshotPosition{Symbol: "TSLA", Shares: types.MustParseDecimal("1"), Value: types.MustParseDecimal("110")},
+		),
+		snapshot("2022-01-07", "1060", "850",
+			database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("1"), Value: types.MustParseDecimal("150")},
+			database.SnapshotPosition{Symbol: "TSLA", Shares: types.MustParseDecimal("1"), Value: types.MustParseDecimal("60")},
+		),
+	}
+	entries := []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("150")}, database.SideSell, "", time.Date(2022, 1, 6, 15, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "TSLA", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("100")}, database.SideBuy, "", time.Date(2022, 1, 4, 15, 0, 0, 0, time.UTC)),
+	}
+	// There is no price on 2022-01-06, so the sell uses the price of 2022-01-05.
+	prices := map[string]types.Decimal{"2022-01-03": types.MustParseDecimal("100"), "2022-01-04": types.MustParseDecimal("110"), "2022-01-05": types.MustParseDecimal("105"), "2022-01-07": types.MustParseDecimal("120")}
+
+	tests := map[string]struct {
+		prices             map[string]types.Decimal
+		from               string
+		expectedComparison Comparison
+		expectErr          bool
+	}{
+		"Follow Trades": {
+			prices, "",
+			Comparison{
+				Benchmark: "SPY", From: "2022-01-03", To: "2022-01-07",
+				PortfolioReturn: types.MustParseDecimal("0.2"), BenchmarkReturn: types.MustParseDecimal("0.0922"), ExcessReturn: types.MustParseDecimal("0.1078"),
+				Curve: []ComparisonPoint{
+					{Date: "2022-01-03", PortfolioValue: types.MustParseDecimal("200"), BenchmarkValue: types.MustParseDecimal("200")},
+					{Date: "2022-01-05", PortfolioValue: types.MustParseDecimal("350"), BenchmarkValue: types.MustParseDecimal("305.45"), PortfolioReturn: types.MustParseDecimal("0.1667"), BenchmarkReturn: types.MustParseDecimal("0.0182"), ExcessReturn: types.MustParseDecimal("0.1485")},
+					{Date: "2022-01-07", PortfolioValue: types.MustParseDecimal("210"), BenchmarkValue: types.MustParseDecimal("177.66"), PortfolioReturn: types.MustParseDecimal("0.2"), BenchmarkReturn: types.MustParseDecimal("0.0922"), ExcessReturn: types.MustParseDecimal("0.1078")},
+				},
+			},
+			false,
+		},
+		"Later Start": {
+			prices, "2022-01-05",
+			Comparison{
+				Benchmark: "SPY", From: "2022-01-05", To: "2022-01-07",
+				PortfolioReturn: types.MustParseDecimal("0.0286"), BenchmarkReturn: types.MustParseDecimal("0.0816"), ExcessReturn: types.MustParseDecimal("-0.053"),
+				Curve: []ComparisonPoint{
+					{Date: "2022-01-05", PortfolioValue: types.MustParseDecimal("350"), BenchmarkValue: types.MustParseDecimal("350")},
+					{Date: "2022-01-07", PortfolioValue: types.MustParseDecimal("210"), BenchmarkValue: types.MustParseDecimal("228.57"), PortfolioReturn: types.MustParseDecimal("0.0286"), BenchmarkReturn: types.MustParseDecimal("0.0816"), ExcessReturn: types.MustParseDecimal("-0.053")},
+				},
+			},
+			false,
+		},
+		"Missing Prices": {
+			map[string]types.Decimal{"2022-01-04": types.MustParseDecimal("110")}, "",
+			Comparison{Benchmark: "SPY", From: "2022-01-03", To: "2022-01-07"},
+			true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			comparison, compareErr := CompareBenchmark(snapshots, entries, "SPY", testCase.prices, testCase.from, "")
+			assert.Equal(t, testCase.expectErr, compareErr != nil)
+			assert.Equal(t, testCase.expectedComparison, comparison)
+		})
+	}
+}
+
+// TestCompareBenchmarkDividends checks that a dividend is followed in the benchmark as a sell, as the benchmark's adjusted closes
+// already pay its own dividends.
+func TestCompareBenchmarkDividends(t *testing.T) {
+	snapshots := []database.PortfolioSnapshot{
+		snapshot("2022-01-03", "1000", "800", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal("200")}),
+		snapshot("2022-01-07", "1010", "810", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal("200")}),
+	}
+	entries := []database.LedgerEntry{
+		database.NewDividendEntry(types.NewDividend{Symbol: "AAPL", ExDate: "2022-01-04", AmountPerShare: types.MustParseDecimal("5"), Quantity: types.MustParseDecimal("2")}, "", time.Date(2022, 1, 5, 6, 0, 0, 0, time.UTC)),
+	}
+	prices := map[string]types.Decimal{"2022-01-03": types.MustParseDecimal("100"), "2022-01-05": types.MustParseDecimal("100"), "2022-01-07": types.MustParseDecimal("100")}
+
+	// The benchmark sells 10 of its 200 on the dividend, and is flat, so is left with 190: the portfolio's 5% is all dividends.
+	comparison, compareErr := CompareBenchmark(snapshots, entries, "SPY", prices, "", "")
+	assert.NoError(t, compareErr)
+	assert.Equal(t, Comparison{
+		Benchmark: "SPY", From: "2022-01-03", To: "2022-01-07",
+		PortfolioReturn: types.MustParseDecimal("0.05"), ExcessReturn: types.MustParseDecimal("0.05"),
+		Curve: []ComparisonPoint{
+			{Date: "2022-01-03", PortfolioValue: types.MustParseDecimal("200"), BenchmarkValue: types.MustParseDecimal("200")},
+			{Date: "2022-01-07", PortfolioValue: types.MustParseDecimal("200"), BenchmarkValue: types.MustParseDecimal("190"), PortfolioReturn: types.MustParseDecimal("0.05"), ExcessReturn: types.MustParseDecimal("0.05")},
+		},
+	}, comparison)
+}
@@ -0,0 +1,312 @@
+// Package performance measures the return of the whole portfolio, and of each symbol, over a period.
+//
+// Both returns are calculated from the daily portfolio snapshots, and the cash flows recorded in the ledger:
+//   - The time-weighted return (TWR) chains together the growth between each pair of snapshots, so it measures how the
+//     investments performed, regardless of when money was added or taken out.
+//   - The money-weighted return (XIRR) is the annual rate which discounts every cash flow to zero, so it measures the return on
+//     the money actually invested, including the effect of when it was invested.
+//
+// Trades & dividends are cash flows of a symbol, and deposits & withdrawals are cash flows of the whole portfolio, so none of them
+// are counted as a gain or a loss of the portfolio.
+package performance
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The named periods a report can be measured over, ending on the date of the latest snapshot.
+const (
+	Period1M             = "1M"
+	Period3M             = "3M"
+	PeriodYTD            = "YTD"
+	Period1Y             = "1Y"
+	PeriodSinceInception = "ALL"
+)
+
+// dateFormat is the format of snapshot dates & report periods.
+const dateFormat = "2006-01-02"
+
+// ErrNoSnapshots is returned when a period has no snapshots to measure returns from.
+var ErrNoSnapshots = errors.New("no portfolio snapshots in the period")
+
+// Return is the performance of the whole portfolio, or a single symbol, over a period.
+type Return struct {
+	Symbol              string         `json:"Symbol,omitempty"` // Empty for the whole portfolio.
+	StartValue          types.Decimal  `json:"StartValue"`
+	EndValue            types.Decimal  `json:"EndValue"`
+	NetInflow           types.Decimal  `json:"NetInflow"`                     // Money paid in, less money taken out, during the period.
+	TimeWeightedReturn  types.Decimal  `json:"TimeWeightedReturn"`            // Over the whole period, not annualised.
+	MoneyWeightedReturn *types.Decimal `json:"MoneyWeightedReturn,omitempty"` // Annualised. Missing when no rate solves the cash flows.
+}
+
+// Report is the performance of the portfolio, and of each symbol, between two snapshots.
+type Report struct {
+	Period    string   `json:"Period,omitempty"` // The named period, if the report was requested by one.
+	From      string   `json:"From"`             // The date of the snapshot the period is measured from.
+	To        string   `json:"To"`               // The date of the last snapshot in the period.
+	Portfolio Return   `json:"Portfolio"`
+	Symbols   []Return `json:"Symbols"` // Every symbol held or traded in the period, ordered by symbol.
+}
+
+// cashFlow is money paid into an investment (positive), or taken out of it (negative), on a date.
+type cashFlow struct {
+	date   time.Time
+	amount types.Decimal
+	symbol string // The symbol traded, for the flows of trades.
+	index  int    // The index of the snapshot the flow is valued in, for the flows read from the ledger.
+}
+
+// series is the value of an investment at each snapshot of a period, and the cash flows since the previous snapshot.
+type series struct {
+	values   []types.Decimal
+	inflows  []types.Decimal
+	outflows []types.Decimal
+	flows    []cashFlow
+}
+
+// PeriodStart returns the date a named period, ending on a date (YYYY-MM-DD), is measured from. The start is empty for the period
+// since inception.
+func PeriodStart(period, end string) (string, error) {
+	endDate, parseErr := time.Parse(dateFormat, end)
+	if parseErr != nil {
+		return "", fmt.Errorf("incorrect date format. expecting YYYY-MM-DD, but got: %v", end)
+	}
+
+	switch period {
+	case Period1M:
+		return endDate.AddDate(0, -1, 0).Format(dateFormat), nil
+	case Period3M:
+		return endDate.AddDate(0, -3, 0).Format(dateFormat), nil
+	case PeriodYTD:
+		// Measured from the close of the previous year's last day.
+		return time.Date(endDate.Year()-1, 12, 31, 0, 0, 0, 0, time.UTC).Format(dateFormat), nil
+	case Period1Y:
+		return endDate.AddDate(-1, 0, 0).Format(dateFormat), nil
+	case PeriodSinceInception:
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown period %v, expecting one of %v, %v, %v, %v or %v", period, Period1M, Period3M, PeriodYTD, Period1Y, PeriodSinceInception)
+}
+
+// ResolvePeriod works out the period a report is measured over, from either a named period or a from date (YYYY-MM-DD), but not
+// both. Without either, the report is measured since inception. It returns the named period, which is empty for a from date, and
+// the date the period is measured from. A named period ends on the date of the latest snapshot, end.
+func ResolvePeriod(period, from, end string) (string, string, error) {
+	if period != "" && from != "" {
+		return "", "", errors.New("period can't be combined with a from date")
+	}
+	if from != "" {
+		return "", from, nil
+	}
+	if period == "" {
+		period = PeriodSinceInception
+	}
+	start, periodErr := PeriodStart(period, end)
+	if periodErr != nil {
+		return "", "", periodErr
+	}
+	return period, start, nil
+}
+
+// Measure calculates the returns between two dates (YYYY-MM-DD), from the snapshots & ledger entries. The period is measured from
+// the last snapshot on or before from (or the first snapshot, if there is none), to the last snapshot on or before to. Either date
+// can be empty, to measure from the first snapshot, or to the latest.
+func Measure(snapshots []database.PortfolioSnapshot, entries []database.LedgerEntry, from, to string) (Report, error) {
+	window := selectSnapshots(snapshots, from, to)
+	if len(window) == 0 {
+		return Report{}, ErrNoSnapshots
+	}
+	report := Report{From: window[0].Date, To: window[len(window)-1].Date}
+
+	portfolio := newSeries(len(window))
+	symbols := make(map[string]*series)
+	symbolSeries := func(symbol string) *series {
+		if _, exists := symbols[symbol]; !exists {
+			symbols[symbol] = newSeries(len(window))
+		}
+		return symbols[symbol]
+	}
+
+	for index, snapshot := range window {
+		portfolio.values[index] = snapshot.TotalValue
+		for _, position := range snapshot.Positions {
+			symbolSeries(position.Symbol).values[index] = position.Value
+		}
+	}
+
+	// Trades & dividends only move money between cash & the symbol's position, so they are flows of the symbol, but not of the
+	// portfolio. A dividend is paid out of the symbol, so it is part of the symbol's return.
+	for _, flow := range symbolFlows(window, entries) {
+		symbolSeries(flow.symbol).addFlow(flow.index, flow.date, flow.amount)
+	}
+	// Deposits & withdrawals only move money into or out of cash, so they are flows of the portfolio, but not of any symbol.
+	for _, flow := range externalFlows(window, entries) {
+		portfolio.addFlow(flow.index, flow.date, flow.amount)
+	}
+
+	report.Portfolio = portfolio.measure(window)
+	report.Symbols = []Return{}
+	for symbol, symbolReturns := range symbols {
+		symbolReturn := symbolReturns.measure(window)
+		symbolReturn.Symbol = symbol
+		report.Symbols = append(report.Symbols, symbolReturn)
+	}
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		return report.Symbols[i].Symbol < report.Symbols[j].Symbol
+	})
+
+	return report, nil
+}
+
+// symbolFlows returns the cash flow of every trade & dividend made after the first snapshot of the window, and on or before its
+// last, with the money paid for a buy as an inflow, and the money received for a sell or as a dividend (after withholding tax) as
+// an outflow.
+func symbolFlows(window []database.PortfolioSnapshot, entries []database.LedgerEntry) []cashFlow {
+	var flows []cashFlow
+	for _, entry := range entries {
+		if entry.EntryType != database.EntryTypeTrade && entry.EntryType != database.EntryTypeDividend {
+			continue
+		}
+		flow, inWindow := ledgerFlow(window, entry)
+		if !inWindow {
+			continue
+		}
+		flow.symbol = entry.Symbol
+
+		trade := types.NewStockTrade{Quantity: entry.Quantity, Price: entry.Price, Commission: entry.Fees}
+		switch {
+		case entry.EntryType == database.EntryTypeDividend:
+			flow.amount = entry.Amount.Neg()
+		case entry.Side == database.SideBuy:
+			flow.amount = trade.Cost()
+		case entry.Side == database.SideSell:
+			flow.amount = trade.Proceeds().Neg()
+		default:
+			continue
+		}
+		flows = append(flows, flow)
+	}
+
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].date.Before(flows[j].date)
+	})
+	return flows
+}
+
+// externalFlows returns the cash flow of every deposit & withdrawal made after the first snapshot of the window, and on or before
+// its last, with a deposit as an inflow, and a withdrawal as an outflow.
+func externalFlows(window []database.PortfolioSnapshot, entries []database.LedgerEntry) []cashFlow {
+	var flows []cashFlow
+	for _, entry := range entries {
+		if entry.EntryType != database.EntryTypeCashFlow {
+			continue
+		}
+		flow, inWindow := ledgerFlow(window, entry)
+		if !inWindow {
+			continue
+		}
+		flow.amount = entry.Amount
+		flows = append(flows, flow)
+	}
+
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].date.Before(flows[j].date)
+	})
+	return flows
+}
+
+// ledgerFlow dates a ledger entry's cash flow, and finds the snapshot it is valued in: the first snapshot taken on or after its
+// date. It reports false for an entry outside the window, including one on the first day, as that is already in its value.
+func ledgerFlow(window []database.PortfolioSnapshot, entry database.LedgerEntry) (cashFlow, bool) {
+	if len(entry.Timestamp) < len(dateFormat) {
+		return cashFlow{}, false
+	}
+	date := entry.Timestamp[:len(dateFormat)]
+	if date <= window[0].Date || date > window[len(window)-1].Date {
+		return cashFlow{}, false
+	}
+
+	flow := cashFlow{}
+	flow.date, _ = time.Parse(dateFormat, date)
+	flow.index = sort.Search(len(window), func(i int) bool {
+		return window[i].Date >= date
+	})
+	return flow, true
+}
+
+// MeasuredFrom returns the date (YYYY-MM-DD) of the snapshot a period starting on from is measured from: the last snapshot on or
+// before it, or the first snapshot if there is none. It returns an empty date if there are no snapshots.
+func MeasuredFrom(snapshots []database.PortfolioSnapshot, from string) string {
+	window := selectSnapshots(snapshots, from, "")
+	if len(window) == 0 {
+		return ""
+	}
+	return window[0].Date
+}
+
+// selectSnapshots returns the snapshots in a period, oldest first, starting with the snapshot the period is measured from.
+func selectSnapshots(snapshots []database.PortfolioSnapshot, from, to string) []database.PortfolioSnapshot {
+	sorted := append([]database.PortfolioSnapshot{}, snapshots...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Date < sorted[j].Date
+	})
+
+	var window []database.PortfolioSnapshot
+	for _, snapshot := range sorted {
+		if to != "" && snapshot.Date > to {
+			break
+		}
+		// Only keep the latest snapshot on or before the start of the period.
+		if len(window) == 1 && window[0].Date <= from && snapshot.Date <= from {
+			window = window[:0]
+		}
+		window = append(window, snapshot)
+	}
+	return window
+}
+
+func newSeries(length int) *series {
+	return &series{
+		values:   make([]types.Decimal, length),
+		inflows:  make([]types.Decimal, length),
+		outflows: make([]types.Decimal, length),
+	}
+}
+
+// addFlow records a cash flow which happened after the previous snapshot, and on or before the snapshot at index.
+func (s *series) addFlow(index int, date time.Time, amount types.Decimal) {
+	if amount.Sign() > 0 {
+		s.inflows[index] = s.inflows[index].Add(amount)
+	} else {
+		s.outflows[index] = s.outflows[index].Sub(amount)
+	}
+	s.flows = append(s.flows, cashFlow{date: date, amount: amount})
+}
+
+// measure calculates the returns of the series, whose values were taken on the dates of the window's snapshots.
+func (s *series) measure(window []database.PortfolioSnapshot) Return {
+	last := len(window) - 1
+	result := Return{StartValue: s.values[0], EndValue: s.values[last]}
+
+	// XIRR takes the investor's side of the flows: the starting value & every inflow are paid, and the end value is received.
+	startDate, _ := time.Parse(dateFormat, window[0].Date)
+	endDate, _ := time.Parse(dateFormat, window[last].Date)
+	investorFlows := []cashFlow{{date: startDate, amount: s.values[0].Neg()}}
+	for _, flow := range s.flows {
+		result.NetInflow = result.NetInflow.Add(flow.amount)
+		investorFlows = append(investorFlows, cashFlow{date: flow.date, amount: flow.amount.Neg()})
+	}
+	investorFlows = append(investorFlows, cashFlow{date: endDate, amount: s.values[last]})
+
+	result.TimeWeightedReturn = types.DecimalFromFloat(timeWeightedReturn(s.values, s.inflows, s.outflows), 4)
+	if rate, rateErr := xirr(investorFlows); rateErr == nil {
+		moneyWeightedReturn := types.DecimalFromFloat(rate, 4)
+		result.MoneyWeightedReturn = &moneyWeightedReturn
+	}
+	return result
+}
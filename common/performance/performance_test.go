@@ -0,0 +1,215 @@
+package performance
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPeriodStart checks the date each named period is measured from.
+func TestPeriodStart(t *testing.T) {
+	tests := map[string]struct {
+		period        string
+		end           string
+		expectedStart string
+		expectErr     bool
+	}{
+		"One Month":       {Period1M, "2022-04-12", "2022-03-12", false},
+		"Three Months":    {Period3M, "2022-04-12", "2022-01-12", false},
+		"Year To Date":    {PeriodYTD, "2022-04-12", "2021-12-31", false},
+		"One Year":        {Period1Y, "2022-04-12", "2021-04-12", false},
+		"Since Inception": {PeriodSinceInception, "2022-04-12", "", false},
+		"Unknown Period":  {"2W", "2022-04-12", "", true},
+		"Incorrect Date":  {Period1M, "2022-4-12", "", true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			start, periodErr := PeriodStart(testCase.period, testCase.end)
+			assert.Equal(t, testCase.expectErr, periodErr != nil)
+			assert.Equal(t, testCase.expectedStart, start)
+		})
+	}
+}
+
+// TestResolvePeriod checks that a report is measured over a named period or from a date, and since inception by default.
+func TestResolvePeriod(t *testing.T) {
+	tests := map[string]struct {
+		period         string
+		from           string
+		expectedPeriod string
+		expectedStart  string
+		expectErr      bool
+	}{
+		"Named Period":    {Period1M, "", Period1M, "2022-03-12", false},
+		"From Date":       {"", "2022-01-01", "", "2022-01-01", false},
+		"Since Inception": {"", "", PeriodSinceInception, "", false},
+		"Period And From": {Period1M, "2022-01-01", "", "", true},
+		"Unknown Period":  {"2W", "", "", "", true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			period, start, periodErr := ResolvePeriod(testCase.period, testCase.from, "2022-04-12")
+			assert.Equal(t, testCase.expectErr, periodErr != nil)
+			assert.Equal(t, testCase.expectedPeriod, period)
+			assert.Equal(t, testCase.expectedStart, start)
+		})
+	}
+}
+
+// TestMeasure checks the time & money-weighted returns of a portfolio which buys AAPL, and later sells half of it.
+func TestMeasure(t *testing.T) {
+	snapshots := []database.PortfolioSnapshot{
+		snapshot("2022-01-04", "1080", "930", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("1"), Value: types.MustParseDecimal("150")}),
+		snapshot("2021-01-04", "1000", "1000"),
+		snapshot("2021-07-05", "1040", "800", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal("240")}),
+	}
+	entries := []database.LedgerEntry{
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("100")}, database.SideBuy, "", time.Date(2021, 1, 5, 15, 0, 0, 0, time.UTC)),
+		database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("130")}, database.SideSell, "", time.Date(2021, 7, 6, 15, 0, 0, 0, time.UTC)),
+	}
+
+	tests := map[string]struct {
+		from           string
+		to             string
+		expectedReport Report
+		expectErr      bool
+	}{
+		"Since Inception": {
+			"", "",
+			Report{
+				From: "2021-01-04", To: "2022-01-04",
+				Portfolio: Return{StartValue: types.MustParseDecimal("1000"), EndValue: types.MustParseDecimal("1080"), TimeWeightedReturn: types.MustParseDecimal("0.08"), MoneyWeightedReturn: rate("0.08")},
+				Symbols: []Return{
+					{Symbol: "AAPL", EndValue: types.MustParseDecimal("150"), NetInflow: types.MustParseDecimal("70"), TimeWeightedReturn: types.MustParseDecimal("0.4"), MoneyWeightedReturn: rate("0.5644")},
+				},
+			},
+			false,
+		},
+		"Second Half": {
+			"2021-07-05", "2022-01-04",
+			Report{
+				From: "2021-07-05", To: "2022-01-04",
+				Portfolio: Return{StartValue: types.MustParseDecimal("1040"), EndValue: types.MustParseDecimal("1080"), TimeWeightedReturn: types.MustParseDecimal("0.0385"), MoneyWeightedReturn: rate("0.0782")},
+				Symbols: []Return{
+					{Symbol: "AAPL", StartValue: types.MustParseDecimal("240"), EndValue: types.MustParseDecimal("150"), NetInflow: types.MustParseDecimal("-130"), TimeWeightedReturn: types.MustParseDecimal("0.1667"), MoneyWeightedReturn: rate("0.849")},
+				},
+			},
+			false,
+		},
+		"Starts Between Snapshots": {
+			"2021-03-01", "2021-12-31",
+			Report{
+				From: "2021-01-04", To: "2021-07-05",
+				Portfolio: Return{StartValue: types.MustParseDecimal("1000"), EndValue: types.MustParseDecimal("1040"), TimeWeightedReturn: types.MustParseDecimal("0.04"), MoneyWeightedReturn: rate("0.0818")},
+				Symbols: []Return{
+					{Symbol: "AAPL", EndValue: types.MustParseDecimal("240"), NetInflow: types.MustParseDecimal("200"), TimeWeightedReturn: types.MustParseDecimal("0.2"), MoneyWeightedReturn: rate("0.4444")},
+				},
+			},
+			false,
+		},
+		"Before First Snapshot": {"", "2020-12-31", Report{}, true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			report, measureErr := Measure(snapshots, entries, testCase.from, testCase.to)
+			assert.Equal(t, testCase.expectErr, measureErr != nil)
+			assert.Equal(t, testCase.expectedReport, report)
+		})
+	}
+}
+
+// TestMeasureCashFlows checks that deposits & withdrawals are counted as flows of the portfolio, not as gains or losses.
+func TestMeasureCashFlows(t *testing.T) {
+	snapshots := []database.PortfolioSnapshot{
+		snapshot("2021-01-04", "1000", "1000"),
+		snapshot("2021-07-05", "1550", "1550"),
+		snapshot("2022-01-04", "1450", "1450"),
+	}
+	entries := []database.LedgerEntry{
+		database.NewCashFlowEntry(types.NewCashFlow{Amount: types.MustParseDecimal("1000")}, database.SideDeposit, "", time.Date(2021, 1, 4, 9, 0, 0, 0, time.UTC)),
+		database.NewCashFlowEntry(types.NewCashFlow{Amount: types.MustParseDecimal("500")}, database.SideDeposit, "", time.Date(2021, 3, 1, 9, 0, 0, 0, time.UTC)),
+		database.NewCashFlowEntry(types.NewCashFlow{Amount: types.MustParseDecimal("100")}, database.SideWithdrawal, "", time.Date(2021, 10, 1, 9, 0, 0, 0, time.UTC)),
+	}
+
+	report, measureErr := Measure(snapshots, entries, "", "")
+	assert.NoError(t, measureErr)
+	assert.Equal(t, types.MustParseDecimal("400"), report.Portfolio.NetInflow)
+	assert.Equal(t, types.MustParseDecimal("0.0333"), report.Portfolio.TimeWeightedReturn)
+	assert.Equal(t, rate("0.0358"), report.Portfolio.MoneyWeightedReturn)
+	assert.Empty(t, report.Symbols)
+}
+
+// TestMeasureDividends checks that a dividend is counted in the return of the symbol paying it, but not as a flow of the portfolio.
+func TestMeasureDividends(t *testing.T) {
+	snapshots := []database.PortfolioSnapshot{
+		snapshot("2021-01-04", "1000", "0", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("10"), Value: types.MustParseDecimal("1000")}),
+		snapshot("2021-07-05", "1050", "50", database.SnapshotPosition{Symbol: "AAPL", Shares: types.MustParseDecimal("10"), Value: types.MustParseDecimal("1000")}),
+	}
+	entries := []database.LedgerEntry{
+		database.NewDividendEntry(types.NewDividend{Symbol: "AAPL", ExDate: "2021-02-05", AmountPerShare: types.MustParseDecimal("5"), Quantity: types.MustParseDecimal("10")}, "", time.Date(2021, 2, 6, 6, 0, 0, 0, time.UTC)),
+	}
+
+	report, measureErr := Measure(snapshots, entries, "", "")
+	assert.NoError(t, measureErr)
+	assert.Equal(t, types.MustParseDecimal("0"), report.Portfolio.NetInflow)
+	assert.Equal(t, types.MustParseDecimal("0.05"), report.Portfolio.TimeWeightedReturn)
+	assert.Len(t, report.Symbols, 1)
+	assert.Equal(t, types.MustParseDecimal("-50"), report.Symbols[0].NetInflow)
+	assert.Equal(t, types.MustParseDecimal("0.05"), report.Symbols[0].TimeWeightedReturn)
+}
+
+// TestXIRR checks XIRR against known solutions, and that it reports flows without a solution.
+func TestXIRR(t *testing.T) {
+	day := func(year, month, d int) time.Time { return time.Date(year, time.Month(month), d, 0, 0, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		flows        []cashFlow
+		expectedRate float64
+		expectErr    bool
+	}{
+		"One Year": {
+			[]cashFlow{{date: day(2021, 1, 1), amount: types.MustParseDecimal("-100")}, {date: day(2022, 1, 1), amount: types.MustParseDecimal("110")}},
+			0.1, false,
+		},
+		"Total Loss": {
+			[]cashFlow{{date: day(2021, 1, 1), amount: types.MustParseDecimal("-100")}, {date: day(2022, 1, 1), amount: types.MustParseDecimal("1")}},
+			-0.99, false,
+		},
+		"Unsorted Flows": {
+			[]cashFlow{{date: day(2023, 1, 1), amount: types.MustParseDecimal("121")}, {date: day(2021, 1, 1), amount: types.MustParseDecimal("-100")}},
+			0.1, false,
+		},
+		"Only Payments": {
+			[]cashFlow{{date: day(2021, 1, 1), amount: types.MustParseDecimal("-100")}, {date: day(2022, 1, 1), amount: types.MustParseDecimal("-10")}},
+			0, true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			calculatedRate, rateErr := xirr(testCase.flows)
+			assert.Equal(t, testCase.expectErr, rateErr != nil)
+			assert.InDelta(t, testCase.expectedRate, calculatedRate, 1e-6)
+		})
+	}
+}
+
+// snapshot builds the snapshot of a day, to keep the test cases short.
+func snapshot(date, totalValue, cash string, positions ...database.SnapshotPosition) database.PortfolioSnapshot {
+	portfolioSnapshot := database.NewSnapshot(date)
+	portfolioSnapshot.TotalValue, portfolioSnapshot.Cash = types.MustParseDecimal(totalValue), types.MustParseDecimal(cash)
+	portfolioSnapshot.Positions = positions
+	return portfolioSnapshot
+}
+
+// rate reads an optional return from a constant.
+func rate(value string) *types.Decimal {
+	parsed := types.MustParseDecimal(value)
+	return &parsed
+}
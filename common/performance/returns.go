@@ -0,0 +1,90 @@
+package performance
+
+import (
+	"Investing-API/common/types"
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	// daysPerYear converts the time between cash flows into years, the same as a spreadsheet's XIRR.
+	daysPerYear = 365
+
+	// maxRate is the highest annual rate XIRR searches for a solution up to.
+	maxRate = 1e6
+
+	// rateTolerance is how close XIRR's rate must be to the exact solution.
+	rateTolerance = 1e-10
+)
+
+// errNoRate is returned when no rate discounts a set of cash flows to zero.
+var errNoRate = errors.New("cash flows have no rate of return")
+
+// timeWeightedReturn chains together the growth of an investment between each pair of valuations.
+func timeWeightedReturn(values, inflows, outflows []types.Decimal) float64 {
+	returns := cumulativeReturns(values, inflows, outflows)
+	return returns[len(returns)-1]
+}
+
+// cumulativeReturns returns the time-weighted return of an investment from its first valuation up to each valuation.
+// Inflows are treated as arriving at the start of each sub-period, and outflows as leaving at its end, so the growth of a
+// position which is opened or closed in a sub-period is measured against the money actually invested in it.
+func cumulativeReturns(values, inflows, outflows []types.Decimal) []float64 {
+	returns := make([]float64, len(values))
+	growth := 1.0
+	for index := 1; index < len(values); index++ {
+		// A sub-period with nothing invested in it has no growth.
+		if invested := values[index-1].Float64() + inflows[index].Float64(); invested > 0 {
+			growth *= (values[index].Float64() + outflows[index].Float64()) / invested
+		}
+		returns[index] = growth - 1
+	}
+	return returns
+}
+
+// xirr finds the annual rate which discounts every cash flow to a net present value of zero. It needs at least one flow paid
+// (negative) and one received (positive).
+func xirr(flows []cashFlow) (float64, error) {
+	sort.SliceStable(flows, func(i, j int) bool {
+		return flows[i].date.Before(flows[j].date)
+	})
+
+	var paid, received bool
+	amounts, years := make([]float64, len(flows)), make([]float64, len(flows))
+	for index, flow := range flows {
+		amounts[index] = flow.amount.Float64()
+		years[index] = flow.date.Sub(flows[0].date).Hours() / 24 / daysPerYear
+		paid = paid || amounts[index] < 0
+		received = received || amounts[index] > 0
+	}
+	if !paid || !received {
+		return 0, errNoRate
+	}
+
+	netPresentValue := func(rate float64) float64 {
+		var total float64
+		for index, amount := range amounts {
+			total += amount / math.Pow(1+rate, years[index])
+		}
+		return total
+	}
+
+	// Bracket a solution between a total loss and an ever higher rate, then narrow it down by bisection.
+	low, high := -1+rateTolerance, 1.0
+	for netPresentValue(low)*netPresentValue(high) > 0 {
+		if high >= maxRate {
+			return 0, errNoRate
+		}
+		high *= 10
+	}
+	for high-low > rateTolerance {
+		middle := (low + high) / 2
+		if netPresentValue(low)*netPresentValue(middle) <= 0 {
+			high = middle
+		} else {
+			low = middle
+		}
+	}
+	return (low + high) / 2, nil
+}
@@ -0,0 +1,32 @@
+package risk
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// defaultBenchmark is the symbol beta is measured against when BENCHMARK_SYMBOL isn't set.
+const defaultBenchmark = "SPY"
+
+// BenchmarkSymbol returns the symbol beta is measured against: the BENCHMARK_SYMBOL environment variable, otherwise SPY.
+func BenchmarkSymbol() string {
+	if symbol := os.Getenv("BENCHMARK_SYMBOL"); symbol != "" {
+		return symbol
+	}
+	return defaultBenchmark
+}
+
+// RiskFreeRate returns the annual risk-free rate of the Sharpe & Sortino ratios: the RISK_FREE_RATE environment variable
+// (e.g. 0.02 for 2%), otherwise 0.
+func RiskFreeRate() (float64, error) {
+	value := os.Getenv("RISK_FREE_RATE")
+	if value == "" {
+		return 0, nil
+	}
+	rate, parseErr := strconv.ParseFloat(value, 64)
+	if parseErr != nil {
+		return 0, fmt.Errorf("invalid RISK_FREE_RATE %q: %v", value, parseErr)
+	}
+	return rate, nil
+}
@@ -0,0 +1,266 @@
+// Package risk measures the risk of each portfolio position, and of the whole portfolio, from daily closing prices.
+//
+// Every measure is calculated from the simple daily returns of the prices, and annualised over 252 trading days:
+//   - Volatility is the standard deviation of the returns.
+//   - The Sharpe ratio is the return in excess of the risk-free rate, per unit of volatility.
+//   - The Sortino ratio is the same excess return, per unit of downside deviation (only returns below the risk-free rate).
+//   - Maximum drawdown is the largest fall from a peak price to a later trough, as a fraction of the peak.
+//   - Beta is how much the price moves with a benchmark: the covariance of their returns, over the benchmark's variance.
+package risk
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// tradingDaysPerYear annualises the daily statistics.
+	tradingDaysPerYear = 252
+
+	// minPrices is the fewest prices which give enough daily returns to measure their standard deviation.
+	minPrices = 3
+)
+
+// ErrTooFewPrices is returned when there aren't enough daily prices to measure risk from.
+var ErrTooFewPrices = fmt.Errorf("at least %v daily prices are needed to measure risk", minPrices)
+
+// Prices is the daily closing prices of a symbol, adjusted for splits & dividends, as a lookup map of [date] => closing-price
+// (see types.AdjustedClosingPrices).
+type Prices map[string]types.Decimal
+
+// Metrics is the risk of a single position, or the whole portfolio, over the dates its prices cover.
+type Metrics struct {
+	Symbol        string         `json:"Symbol,omitempty"` // Empty for the whole portfolio.
+	From          string         `json:"From"`
+	To            string         `json:"To"`
+	Volatility    types.Decimal  `json:"Volatility"`
+	SharpeRatio   *types.Decimal `json:"SharpeRatio,omitempty"`   // Missing when the price never moved.
+	SortinoRatio  *types.Decimal `json:"SortinoRatio,omitempty"`  // Missing when no daily return was below the risk-free rate.
+	MaxDrawdown   types.Decimal  `json:"MaxDrawdown"`             // e.g. 0.25 for a 25% fall. 0 when the price never fell.
+	DrawdownStart string         `json:"DrawdownStart,omitempty"` // The date of the peak.
+	DrawdownEnd   string         `json:"DrawdownEnd,omitempty"`   // The date of the trough.
+	Beta          *types.Decimal `json:"Beta,omitempty"`          // Missing without enough benchmark prices, or when the benchmark never moved.
+}
+
+// Report is the risk of every open position, and of the whole portfolio.
+type Report struct {
+	Benchmark    string        `json:"Benchmark"`
+	RiskFreeRate types.Decimal `json:"RiskFreeRate"`
+	Portfolio    Metrics       `json:"Portfolio"`
+	Positions    []Metrics     `json:"Positions"` // Ordered by symbol.
+}
+
+// BuildReport measures the risk of each open position from its prices, and of the whole portfolio as it is held today. Prices
+// must hold the prices of every open position, and of the benchmark symbol.
+func BuildReport(openPositions []database.OpenStockPosition, prices map[string]Prices, benchmark string, riskFreeRate float64) (Report, error) {
+	report := Report{
+		Benchmark:    benchmark,
+		RiskFreeRate: types.DecimalFromFloat(riskFreeRate, 4),
+		Positions:    []Metrics{},
+	}
+
+	for _, position := range openPositions {
+		if position.SK == "CASH" {
+			continue
+		}
+		metrics, measureErr := Measure(prices[position.SK], prices[benchmark], riskFreeRate)
+		if measureErr != nil {
+			return report, fmt.Errorf("%v: %v", position.SK, measureErr)
+		}
+		metrics.Symbol = position.SK
+		report.Positions = append(report.Positions, metrics)
+	}
+	sort.Slice(report.Positions, func(i, j int) bool {
+		return report.Positions[i].Symbol < report.Positions[j].Symbol
+	})
+
+	portfolio, measureErr := Measure(PortfolioPrices(openPositions, prices), prices[benchmark], riskFreeRate)
+	if measureErr != nil {
+		return report, fmt.Errorf("portfolio: %v", measureErr)
+	}
+	report.Portfolio = portfolio
+
+	return report, nil
+}
+
+// PortfolioPrices values the portfolio, as it is held today, on every date which all of its stock positions have a price for.
+// Cash is included at its current value.
+func PortfolioPrices(openPositions []database.OpenStockPosition, prices map[string]Prices) Prices {
+	var cash types.Decimal
+	var holdings []database.OpenStockPosition
+	for _, position := range openPositions {
+		if position.SK == "CASH" {
+			cash = position.PurchaseValue
+		} else {
+			holdings = append(holdings, position)
+		}
+	}
+	if len(holdings) == 0 {
+		return Prices{}
+	}
+
+	values := make(Prices)
+	for date := range prices[holdings[0].SK] {
+		value, priced := cash, true
+		for _, position := range holdings {
+			price, exists := prices[position.SK][date]
+			if !exists {
+				priced = false
+				break
+			}
+			value = value.Add(price.Mul(position.Shares))
+		}
+		if priced {
+			values[date] = value.Round(2)
+		}
+	}
+	return values
+}
+
+// Measure calculates the risk of a series of prices, with beta measured against the benchmark's prices.
+// The risk-free rate is annual, e.g. 0.02 for 2%.
+func Measure(prices, benchmark Prices, riskFreeRate float64) (Metrics, error) {
+	dates, closes := sortedPrices(prices)
+	if len(dates) < minPrices {
+		return Metrics{}, ErrTooFewPrices
+	}
+	metrics := Metrics{From: dates[0], To: dates[len(dates)-1]}
+
+	returns := dailyReturns(closes)
+	mean, standardDeviation := meanAndStandardDeviation(returns)
+	annualisation := math.Sqrt(tradingDaysPerYear)
+	volatility := standardDeviation * annualisation
+	excessReturn := mean*tradingDaysPerYear - riskFreeRate
+
+	metrics.Volatility = types.DecimalFromFloat(volatility, 4)
+	if volatility > 0 {
+		metrics.SharpeRatio = ratio(excessReturn / volatility)
+	}
+	if downside := downsideDeviation(returns, riskFreeRate/tradingDaysPerYear) * annualisation; downside > 0 {
+		metrics.SortinoRatio = ratio(excessReturn / downside)
+	}
+
+	drawdown, peak, trough := maxDrawdown(closes)
+	metrics.MaxDrawdown = types.DecimalFromFloat(drawdown, 4)
+	if drawdown > 0 {
+		metrics.DrawdownStart, metrics.DrawdownEnd = dates[peak], dates[trough]
+	}
+
+	if benchmarkBeta, betaErr := beta(prices, benchmark); betaErr == nil {
+		metrics.Beta = ratio(benchmarkBeta)
+	}
+
+	return metrics, nil
+}
+
+// errFlatBenchmark is returned when beta can't be measured, because the benchmark's price never moved.
+var errFlatBenchmark = errors.New("benchmark price never moved")
+
+// beta measures the returns of the prices against the benchmark's, over the dates both have a price for.
+func beta(prices, benchmark Prices) (float64, error) {
+	common, commonBenchmark := make(Prices), make(Prices)
+	for date, price := range prices {
+		if benchmarkPrice, exists := benchmark[date]; exists {
+			common[date], commonBenchmark[date] = price, benchmarkPrice
+		}
+	}
+	_, closes := sortedPrices(common)
+	_, benchmarkCloses := sortedPrices(commonBenchmark)
+	if len(closes) < minPrices || len(closes) != len(benchmarkCloses) {
+		return 0, ErrTooFewPrices
+	}
+
+	returns, benchmarkReturns := dailyReturns(closes), dailyReturns(benchmarkCloses)
+	mean, _ := meanAndStandardDeviation(returns)
+	benchmarkMean, _ := meanAndStandardDeviation(benchmarkReturns)
+	var covariance, variance float64
+	for index := range returns {
+		covariance += (returns[index] - mean) * (benchmarkReturns[index] - benchmarkMean)
+		variance += math.Pow(benchmarkReturns[index]-benchmarkMean, 2)
+	}
+	if variance == 0 {
+		return 0, errFlatBenchmark
+	}
+	return covariance / variance, nil
+}
+
+// sortedPrices returns the dates & prices of a series in date order. Prices of 0 or less can't have a return, so are left out.
+func sortedPrices(prices Prices) ([]string, []float64) {
+	var dates []string
+	for date, price := range prices {
+		if price.Sign() > 0 {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	closes := make([]float64, len(dates))
+	for index, date := range dates {
+		closes[index] = prices[date].Float64()
+	}
+	return dates, closes
+}
+
+// dailyReturns returns the simple return from each price to the next.
+func dailyReturns(closes []float64) []float64 {
+	returns := make([]float64, len(closes)-1)
+	for index := 1; index < len(closes); index++ {
+		returns[index-1] = closes[index]/closes[index-1] - 1
+	}
+	return returns
+}
+
+// meanAndStandardDeviation returns the mean & sample standard deviation of the returns.
+func meanAndStandardDeviation(returns []float64) (float64, float64) {
+	var total float64
+	for _, value := range returns {
+		total += value
+	}
+	mean := total / float64(len(returns))
+	if len(returns) < 2 {
+		return mean, 0
+	}
+
+	var squares float64
+	for _, value := range returns {
+		squares += math.Pow(value-mean, 2)
+	}
+	return mean, math.Sqrt(squares / float64(len(returns)-1))
+}
+
+// downsideDeviation returns the root mean square of the returns' shortfall below a target return.
+func downsideDeviation(returns []float64, target float64) float64 {
+	var squares float64
+	for _, value := range returns {
+		if value < target {
+			squares += math.Pow(value-target, 2)
+		}
+	}
+	return math.Sqrt(squares / float64(len(returns)))
+}
+
+// maxDrawdown returns the largest fall from a peak price to a later trough, as a fraction of the peak, with the indexes of the
+// peak & the trough.
+func maxDrawdown(closes []float64) (float64, int, int) {
+	var drawdown float64
+	var peak, drawdownPeak, drawdownTrough int
+	for index, price := range closes {
+		if price > closes[peak] {
+			peak = index
+		}
+		if fall := (closes[peak] - price) / closes[peak]; fall > drawdown {
+			drawdown, drawdownPeak, drawdownTrough = fall, peak, index
+		}
+	}
+	return drawdown, drawdownPeak, drawdownTrough
+}
+
+// ratio rounds a ratio for a report.
+func ratio(value float64) *types.Decimal {
+	rounded := types.DecimalFromFloat(value, 4)
+	return &rounded
+}
@@ -0,0 +1,108 @@
+package risk
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMeasure checks each risk measure against values worked out by hand.
+func TestMeasure(t *testing.T) {
+	// Daily returns of +10%, -10% & +10%, and a benchmark which moves half as much.
+	prices := Prices{"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("110"), "2022-04-06": types.MustParseDecimal("99"), "2022-04-07": types.MustParseDecimal("108.9")}
+	benchmark := Prices{"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("105"), "2022-04-06": types.MustParseDecimal("99.75"), "2022-04-07": types.MustParseDecimal("104.7375")}
+
+	tests := map[string]struct {
+		prices          Prices
+		benchmark       Prices
+		riskFreeRate    float64
+		expectedMetrics Metrics
+		expectErr       bool
+	}{
+		"Volatile Prices": {
+			prices, benchmark, 0,
+			Metrics{
+				From: "2022-04-04", To: "2022-04-07",
+				Volatility: types.MustParseDecimal("1.833"), SharpeRatio: ratio(4.5826), SortinoRatio: ratio(9.1652),
+				MaxDrawdown: types.MustParseDecimal("0.1"), DrawdownStart: "2022-04-05", DrawdownEnd: "2022-04-06",
+				Beta: ratio(2),
+			},
+			false,
+		},
+		"Risk-Free Rate": {
+			prices, benchmark, 0.4,
+			Metrics{
+				From: "2022-04-04", To: "2022-04-07",
+				Volatility: types.MustParseDecimal("1.833"), SharpeRatio: ratio(4.3644), SortinoRatio: ratio(8.5923),
+				MaxDrawdown: types.MustParseDecimal("0.1"), DrawdownStart: "2022-04-05", DrawdownEnd: "2022-04-06",
+				Beta: ratio(2),
+			},
+			false,
+		},
+		"Rising Prices Without Benchmark": {
+			Prices{"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("101"), "2022-04-06": types.MustParseDecimal("103")},
+			nil, 0,
+			Metrics{From: "2022-04-04", To: "2022-04-06", Volatility: types.MustParseDecimal("0.11"), SharpeRatio: ratio(34.1285)},
+			false,
+		},
+		"Too Few Prices": {
+			Prices{"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("101")},
+			benchmark, 0,
+			Metrics{},
+			true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			metrics, measureErr := Measure(testCase.prices, testCase.benchmark, testCase.riskFreeRate)
+			assert.Equal(t, testCase.expectErr, measureErr != nil)
+			assert.Equal(t, testCase.expectedMetrics, metrics)
+		})
+	}
+}
+
+// TestPortfolioPrices checks that the portfolio is valued on the dates every position has a price for, including its cash.
+func TestPortfolioPrices(t *testing.T) {
+	openPositions := []database.OpenStockPosition{
+		{SK: "AAPL", Shares: types.MustParseDecimal("2")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("100"), CurrentValue: types.MustParseDecimal("100")},
+		{SK: "TSLA", Shares: types.MustParseDecimal("0.5")},
+	}
+	prices := map[string]Prices{
+		"AAPL": {"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("110"), "2022-04-06": types.MustParseDecimal("99")},
+		"TSLA": {"2022-04-04": types.MustParseDecimal("1000"), "2022-04-06": types.MustParseDecimal("1010.01")},
+	}
+
+	expectedPrices := Prices{"2022-04-04": types.MustParseDecimal("800"), "2022-04-06": types.MustParseDecimal("803.01")}
+	assert.Equal(t, expectedPrices, PortfolioPrices(openPositions, prices))
+	assert.Equal(t, Prices{}, PortfolioPrices(openPositions[1:2], prices))
+}
+
+// TestBuildReport checks that every position, and the whole portfolio, is measured against the benchmark.
+func TestBuildReport(t *testing.T) {
+	openPositions := []database.OpenStockPosition{
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("100"), CurrentValue: types.MustParseDecimal("100")},
+		{SK: "AAPL", Shares: types.MustParseDecimal("2")},
+	}
+	prices := map[string]Prices{
+		"AAPL": {"2022-04-04": types.MustParseDecimal("100"), "2022-04-05": types.MustParseDecimal("110"), "2022-04-06": types.MustParseDecimal("99")},
+		"SPY":  {"2022-04-04": types.MustParseDecimal("400"), "2022-04-05": types.MustParseDecimal("404"), "2022-04-06": types.MustParseDecimal("400")},
+	}
+
+	report, reportErr := BuildReport(openPositions, prices, "SPY", 0.02)
+	assert.NoError(t, reportErr)
+	assert.Equal(t, "SPY", report.Benchmark)
+	assert.Equal(t, types.MustParseDecimal("0.02"), report.RiskFreeRate)
+	assert.Len(t, report.Positions, 1)
+	assert.Equal(t, "AAPL", report.Positions[0].Symbol)
+	assert.Equal(t, "", report.Portfolio.Symbol)
+	// The cash dampens the portfolio's moves, so it is less volatile than its only position.
+	assert.Equal(t, -1, report.Portfolio.Volatility.Cmp(report.Positions[0].Volatility))
+	assert.Equal(t, types.MustParseDecimal("0.0688"), report.Portfolio.MaxDrawdown)
+
+	_, missingErr := BuildReport(append(openPositions, database.OpenStockPosition{SK: "TSLA", Shares: types.MustParseDecimal("1")}), prices, "SPY", 0)
+	assert.Error(t, missingErr)
+}
@@ -0,0 +1,289 @@
+// Package tax calculates UK capital gains on share disposals from the trade ledger, and the splits applied to the shares.
+//
+// Disposals are matched against acquisitions of the same symbol in the order HMRC requires (TCGA 1992 s105 & s106A):
+//  1. Same day: acquisitions made on the same day as the disposal.
+//  2. Bed & breakfast: acquisitions made in the 30 days after the disposal, earliest first.
+//  3. Section 104: the pool of every other share held, at its average cost.
+//
+// A split changes the number of shares held, but not what they cost, so the pool is rescaled on the split's ex-date, and shares
+// bought after a split are matched with a disposal before it in the shares of the time of the disposal.
+package tax
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"Investing-API/common/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	_ "time/tzdata" // Trades are dated in UK time, which the Lambda runtime has no zoneinfo for.
+)
+
+// The matching rules, in the order they are applied.
+const (
+	RuleSameDay         = "SAME-DAY"
+	RuleBedAndBreakfast = "BED-AND-BREAKFAST"
+	RuleSection104      = "SECTION-104"
+)
+
+// bedAndBreakfastDays is how many days after a disposal an acquisition is matched with it.
+const bedAndBreakfastDays = 30
+
+// ukTime is the time zone used to decide which day a trade was made on.
+var ukTime, _ = time.LoadLocation("Europe/London")
+
+// Match is the part of a disposal matched against one acquisition (or the Section 104 pool).
+type Match struct {
+	Rule            string        `json:"Rule"`
+	AcquisitionDate string        `json:"AcquisitionDate,omitempty"` // Empty for matches against the Section 104 pool.
+	Quantity        types.Decimal `json:"Quantity"`
+	AllowableCost   types.Decimal `json:"AllowableCost"`
+}
+
+// Disposal is every sell of a symbol made on a single day, which HMRC treats as one disposal.
+type Disposal struct {
+	Date          string        `json:"Date"`
+	Symbol        string        `json:"Symbol"`
+	Quantity      types.Decimal `json:"Quantity"`
+	Proceeds      types.Decimal `json:"Proceeds"`
+	AllowableCost types.Decimal `json:"AllowableCost"` // The matched acquisition costs, plus the fees paid on the disposal.
+	Gain          types.Decimal `json:"Gain"`          // Negative for a loss.
+	Matches       []Match       `json:"Matches"`
+}
+
+// TaxYearReport summarises the disposals made in a single UK tax year (6 April to 5 April).
+type TaxYearReport struct {
+	TaxYear             string        `json:"TaxYear"` // e.g. 2021/22
+	Disposals           []Disposal    `json:"Disposals"`
+	TotalProceeds       types.Decimal `json:"TotalProceeds"`
+	TotalAllowableCosts types.Decimal `json:"TotalAllowableCosts"`
+	TotalGains          types.Decimal `json:"TotalGains"`
+	TotalLosses         types.Decimal `json:"TotalLosses"`
+	NetGain             types.Decimal `json:"NetGain"`
+}
+
+// tradingDay is the combined buys & sells of a symbol on a single day. The bought quantity & cost are reduced as acquisitions are
+// matched with disposals.
+type tradingDay struct {
+	date       string
+	bought     types.Decimal
+	boughtCost types.Decimal
+	disposal   *Disposal
+	unmatched  types.Decimal // The quantity of the disposal still to be matched.
+	splitRatio types.Decimal // The shares each share became, when the day is the ex-date of a split. The day's trades follow it.
+}
+
+// section104Pool is every share of a symbol not matched by the same day or bed & breakfast rules, and what they cost together.
+type section104Pool struct {
+	Shares types.Decimal
+	Cost   types.Decimal
+}
+
+// BuildReports matches every sell in the ledger with its acquisitions, and groups the disposals by tax year, oldest first.
+func BuildReports(entries []database.LedgerEntry) ([]TaxYearReport, error) {
+	var disposals []Disposal
+	for symbol, days := range groupTradingDays(entries) {
+		if matchErr := matchDisposals(days); matchErr != nil {
+			return nil, fmt.Errorf("%v: %v", symbol, matchErr)
+		}
+		for _, day := range days {
+			if day.disposal != nil {
+				day.disposal.Gain = day.disposal.Proceeds.Sub(day.disposal.AllowableCost)
+				disposals = append(disposals, *day.disposal)
+			}
+		}
+	}
+	sort.Slice(disposals, func(i, j int) bool {
+		if disposals[i].Date != disposals[j].Date {
+			return disposals[i].Date < disposals[j].Date
+		}
+		return disposals[i].Symbol < disposals[j].Symbol
+	})
+
+	var reports []TaxYearReport
+	for _, disposal := range disposals {
+		taxYear := TaxYear(disposal.Date)
+		if len(reports) == 0 || reports[len(reports)-1].TaxYear != taxYear {
+			reports = append(reports, TaxYearReport{TaxYear: taxYear})
+		}
+		report := &reports[len(reports)-1]
+		report.Disposals = append(report.Disposals, disposal)
+		report.TotalProceeds = report.TotalProceeds.Add(disposal.Proceeds)
+		report.TotalAllowableCosts = report.TotalAllowableCosts.Add(disposal.AllowableCost)
+		if disposal.Gain.Sign() >= 0 {
+			report.TotalGains = report.TotalGains.Add(disposal.Gain)
+		} else {
+			report.TotalLosses = report.TotalLosses.Sub(disposal.Gain)
+		}
+		report.NetGain = report.TotalGains.Sub(report.TotalLosses)
+	}
+
+	return reports, nil
+}
+
+// TaxYear returns the UK tax year (e.g. 2021/22) which a date (YYYY-MM-DD) falls in.
+func TaxYear(date string) string {
+	year, _ := strconv.Atoi(date[:4])
+	if date[5:] < "04-06" {
+		year--
+	}
+	return fmt.Sprintf("%v/%02d", year, (year+1)%100)
+}
+
+// groupTradingDays combines the trades of each symbol by UK date, along with its splits by ex-date, in date order.
+func groupTradingDays(entries []database.LedgerEntry) map[string][]*tradingDay {
+	lookup := make(map[string]*tradingDay)
+	daysBySymbol := make(map[string][]*tradingDay)
+	dayOf := func(symbol, date string) *tradingDay {
+		day, exists := lookup[symbol+"#"+date]
+		if !exists {
+			day = &tradingDay{date: date}
+			lookup[symbol+"#"+date] = day
+			daysBySymbol[symbol] = append(daysBySymbol[symbol], day)
+		}
+		return day
+	}
+
+	for _, entry := range entries {
+		if entry.EntryType == database.EntryTypeCorporateAction && entry.Action == types.ActionSplit {
+			day := dayOf(entry.Symbol, entry.ExDate)
+			if day.splitRatio.IsZero() {
+				day.splitRatio = types.NewDecimal(1, 0)
+			}
+			day.splitRatio = day.splitRatio.Mul(entry.Ratio)
+			continue
+		}
+		if entry.EntryType != database.EntryTypeTrade {
+			continue
+		}
+		timestamp, parseErr := time.Parse(time.RFC3339Nano, entry.Timestamp)
+		if parseErr != nil {
+			continue
+		}
+		date := timestamp.In(ukTime).Format("2006-01-02")
+		day := dayOf(entry.Symbol, date)
+
+		value := types.NewStockTrade{Quantity: entry.Quantity, Price: entry.Price}.Value()
+		switch entry.Side {
+		case database.SideBuy:
+			day.bought = day.bought.Add(entry.Quantity)
+			day.boughtCost = day.boughtCost.Add(value).Add(entry.Fees)
+		case database.SideSell:
+			if day.disposal == nil {
+				day.disposal = &Disposal{Date: date, Symbol: entry.Symbol}
+			}
+			day.disposal.Quantity = day.disposal.Quantity.Add(entry.Quantity)
+			day.disposal.Proceeds = day.disposal.Proceeds.Add(value)
+			day.disposal.AllowableCost = day.disposal.AllowableCost.Add(entry.Fees)
+			day.unmatched = day.unmatched.Add(entry.Quantity)
+		}
+	}
+
+	for _, days := range daysBySymbol {
+		sort.Slice(days, func(i, j int) bool {
+			return days[i].date < days[j].date
+		})
+	}
+	return daysBySymbol
+}
+
+// matchDisposals applies each of the matching rules, in order, to the trading days of a single symbol.
+func matchDisposals(days []*tradingDay) error {
+	one := types.NewDecimal(1, 0)
+
+	// 1. Same day.
+	for _, day := range days {
+		if day.disposal != nil {
+			match(day, day, minQuantity(day.unmatched, day.bought), one, RuleSameDay)
+		}
+	}
+
+	// 2. Bed & breakfast: acquisitions in the 30 days after each disposal, with earlier disposals matched first. Shares bought after
+	// a split are counted in the shares of the time of the disposal.
+	for index, day := range days {
+		if day.disposal == nil {
+			continue
+		}
+		disposalDate, _ := time.Parse("2006-01-02", day.date)
+		lastDate := disposalDate.AddDate(0, 0, bedAndBreakfastDays).Format("2006-01-02")
+		splitSince := one
+		for _, later := range days[index+1:] {
+			if later.date > lastDate || day.unmatched.IsZero() {
+				break
+			}
+			if !later.splitRatio.IsZero() {
+				splitSince = splitSince.Mul(later.splitRatio)
+			}
+			available := later.bought
+			if splitSince.Cmp(one) != 0 {
+				available = later.bought.Div(splitSince, utils.QuantityPrecision())
+			}
+			match(day, later, minQuantity(day.unmatched, available), splitSince, RuleBedAndBreakfast)
+		}
+	}
+
+	// 3. Section 104: every unmatched acquisition joins the pool, and every unmatched disposal is taken from it at average cost.
+	var pool section104Pool
+	for _, day := range days {
+		// The split comes before the day's trades, and only changes the number of shares in the pool.
+		if !day.splitRatio.IsZero() {
+			pool.Shares = pool.Shares.Mul(day.splitRatio).Round(utils.QuantityPrecision())
+		}
+		if day.bought.Sign() > 0 {
+			pool.Shares = pool.Shares.Add(day.bought)
+			pool.Cost = pool.Cost.Add(day.boughtCost)
+			day.bought, day.boughtCost = types.Decimal{}, types.Decimal{}
+		}
+		if day.disposal == nil || day.unmatched.IsZero() {
+			continue
+		}
+		if day.unmatched.Cmp(pool.Shares) > 0 {
+			return fmt.Errorf("disposal of %v shares on %v is more than the %v shares held", day.unmatched, day.date, pool.Shares)
+		}
+
+		poolCost := pool.Cost.Mul(day.unmatched).Div(pool.Shares, 2)
+		day.disposal.Matches = append(day.disposal.Matches, Match{Rule: RuleSection104, Quantity: day.unmatched, AllowableCost: poolCost})
+		day.disposal.AllowableCost = day.disposal.AllowableCost.Add(poolCost)
+		pool.Shares = pool.Shares.Sub(day.unmatched)
+		pool.Cost = pool.Cost.Sub(poolCost)
+		day.unmatched = types.Decimal{}
+	}
+
+	return nil
+}
+
+// match allocates part of an acquisition day's shares, at their proportion of the day's cost, to a disposal. The quantity is in the
+// shares of the disposal, each of which became splitSince shares by the day of the acquisition.
+func match(disposalDay, acquisitionDay *tradingDay, quantity, splitSince types.Decimal, rule string) {
+	if quantity.Sign() <= 0 {
+		return
+	}
+
+	acquired := quantity.Mul(splitSince)
+	if acquired.Cmp(acquisitionDay.bought) > 0 {
+		acquired = acquisitionDay.bought
+	}
+	cost := acquisitionDay.boughtCost
+	if acquired.Cmp(acquisitionDay.bought) < 0 {
+		cost = acquisitionDay.boughtCost.Mul(acquired).Div(acquisitionDay.bought, 2)
+	}
+	acquisitionDay.bought = acquisitionDay.bought.Sub(acquired)
+	acquisitionDay.boughtCost = acquisitionDay.boughtCost.Sub(cost)
+
+	disposalDay.unmatched = disposalDay.unmatched.Sub(quantity)
+	disposalDay.disposal.AllowableCost = disposalDay.disposal.AllowableCost.Add(cost)
+	disposalDay.disposal.Matches = append(disposalDay.disposal.Matches, Match{
+		Rule:            rule,
+		AcquisitionDate: acquisitionDay.date,
+		Quantity:        quantity,
+		AllowableCost:   cost,
+	})
+}
+
+func minQuantity(a, b types.Decimal) types.Decimal {
+	if a.Cmp(b) < 0 {
+		return a
+	}
+	return b
+}
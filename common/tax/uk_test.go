@@ -0,0 +1,168 @@
+package tax
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// trade builds a trade ledger entry made at midday (UK time) on the given date.
+func trade(date, side, quantity, price, fees string) database.LedgerEntry {
+	at, _ := time.ParseInLocation("2006-01-02 15:04", date+" 12:00", ukTime)
+	entry := database.NewTradeEntry(types.NewStockTrade{Symbol: "VUSA", Quantity: types.MustParseDecimal(quantity), Price: types.MustParseDecimal(price)}, side, "", at)
+	entry.Fees = types.MustParseDecimal(fees)
+	return entry
+}
+
+// split builds the ledger entry of a split with the given ex-date, applied the night after it.
+func split(exDate, ratio string) database.LedgerEntry {
+	at, _ := time.Parse("2006-01-02", exDate)
+	return database.NewCorporateActionEntry(types.CorporateAction{Symbol: "VUSA", Date: exDate, Type: types.ActionSplit, Ratio: types.MustParseDecimal(ratio)}, types.MustParseDecimal("0"), at.AddDate(0, 0, 1))
+}
+
+// TestBuildReports checks that disposals are matched by the same-day, bed & breakfast, then Section 104 rules.
+func TestBuildReports(t *testing.T) {
+	tests := map[string]struct {
+		entries           []database.LedgerEntry
+		expectedDisposals []Disposal
+	}{
+		"Section 104 Pool": {
+			[]database.LedgerEntry{
+				trade("2021-01-04", database.SideBuy, "100", "10", "0"),
+				trade("2021-02-01", database.SideBuy, "100", "20", "0"),
+				trade("2021-05-04", database.SideSell, "50", "30", "0"),
+			},
+			[]Disposal{
+				{Date: "2021-05-04", Symbol: "VUSA", Quantity: types.MustParseDecimal("50"), Proceeds: types.MustParseDecimal("1500"), AllowableCost: types.MustParseDecimal("750"), Gain: types.MustParseDecimal("750"), Matches: []Match{
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("50"), AllowableCost: types.MustParseDecimal("750")},
+				}},
+			},
+		},
+		"Same Day Before Pool": {
+			[]database.LedgerEntry{
+				trade("2021-01-04", database.SideBuy, "100", "10", "0"),
+				trade("2021-06-01", database.SideSell, "50", "20", "0"),
+				trade("2021-06-01", database.SideBuy, "20", "15", "0"),
+			},
+			[]Disposal{
+				{Date: "2021-06-01", Symbol: "VUSA", Quantity: types.MustParseDecimal("50"), Proceeds: types.MustParseDecimal("1000"), AllowableCost: types.MustParseDecimal("600"), Gain: types.MustParseDecimal("400"), Matches: []Match{
+					{Rule: RuleSameDay, AcquisitionDate: "2021-06-01", Quantity: types.MustParseDecimal("20"), AllowableCost: types.MustParseDecimal("300")},
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("30"), AllowableCost: types.MustParseDecimal("300")},
+				}},
+			},
+		},
+		"Bed & Breakfast": {
+			[]database.LedgerEntry{
+				trade("2021-01-04", database.SideBuy, "100", "10", "0"),
+				trade("2021-06-01", database.SideSell, "100", "5", "0"),
+				trade("2021-06-15", database.SideBuy, "60", "6", "0"),
+				trade("2021-07-02", database.SideBuy, "40", "7", "0"), // 31 days after the disposal, so it joins the pool.
+				trade("2021-08-02", database.SideSell, "100", "8", "0"),
+			},
+			[]Disposal{
+				{Date: "2021-06-01", Symbol: "VUSA", Quantity: types.MustParseDecimal("100"), Proceeds: types.MustParseDecimal("500"), AllowableCost: types.MustParseDecimal("760"), Gain: types.MustParseDecimal("-260"), Matches: []Match{
+					{Rule: RuleBedAndBreakfast, AcquisitionDate: "2021-06-15", Quantity: types.MustParseDecimal("60"), AllowableCost: types.MustParseDecimal("360")},
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("40"), AllowableCost: types.MustParseDecimal("400")},
+				}},
+				{Date: "2021-08-02", Symbol: "VUSA", Quantity: types.MustParseDecimal("100"), Proceeds: types.MustParseDecimal("800"), AllowableCost: types.MustParseDecimal("880"), Gain: types.MustParseDecimal("-80"), Matches: []Match{
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("100"), AllowableCost: types.MustParseDecimal("880")},
+				}},
+			},
+		},
+		"Split In Pool": {
+			[]database.LedgerEntry{
+				trade("2022-01-04", database.SideBuy, "10", "100", "0"),
+				split("2022-03-01", "4"),
+				trade("2022-05-04", database.SideSell, "30", "30", "0"),
+			},
+			[]Disposal{
+				{Date: "2022-05-04", Symbol: "VUSA", Quantity: types.MustParseDecimal("30"), Proceeds: types.MustParseDecimal("900"), AllowableCost: types.MustParseDecimal("750"), Gain: types.MustParseDecimal("150"), Matches: []Match{
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("30"), AllowableCost: types.MustParseDecimal("750")},
+				}},
+			},
+		},
+		"Bed & Breakfast After Split": {
+			[]database.LedgerEntry{
+				trade("2021-01-04", database.SideBuy, "100", "10", "0"),
+				trade("2021-06-01", database.SideSell, "100", "5", "0"),
+				split("2021-06-10", "2"),
+				trade("2021-06-15", database.SideBuy, "120", "3", "0"),
+			},
+			[]Disposal{
+				{Date: "2021-06-01", Symbol: "VUSA", Quantity: types.MustParseDecimal("100"), Proceeds: types.MustParseDecimal("500"), AllowableCost: types.MustParseDecimal("760"), Gain: types.MustParseDecimal("-260"), Matches: []Match{
+					{Rule: RuleBedAndBreakfast, AcquisitionDate: "2021-06-15", Quantity: types.MustParseDecimal("60"), AllowableCost: types.MustParseDecimal("360")},
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("40"), AllowableCost: types.MustParseDecimal("400")},
+				}},
+			},
+		},
+		"Fees Are Allowable Costs": {
+			[]database.LedgerEntry{
+				trade("2021-01-04", database.SideBuy, "10", "100", "10"),
+				trade("2021-03-01", database.SideSell, "10", "120", "12"),
+			},
+			[]Disposal{
+				{Date: "2021-03-01", Symbol: "VUSA", Quantity: types.MustParseDecimal("10"), Proceeds: types.MustParseDecimal("1200"), AllowableCost: types.MustParseDecimal("1022"), Gain: types.MustParseDecimal("178"), Matches: []Match{
+					{Rule: RuleSection104, Quantity: types.MustParseDecimal("10"), AllowableCost: types.MustParseDecimal("1010")},
+				}},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			reports, reportErr := BuildReports(testCase.entries)
+			assert.NoError(t, reportErr)
+
+			var disposals []Disposal
+			for _, report := range reports {
+				disposals = append(disposals, report.Disposals...)
+			}
+			assert.Equal(t, testCase.expectedDisposals, disposals)
+		})
+	}
+}
+
+// TestReportTotals checks that disposals are grouped by tax year, with gains & losses totalled separately.
+func TestReportTotals(t *testing.T) {
+	reports, reportErr := BuildReports([]database.LedgerEntry{
+		trade("2021-04-01", database.SideBuy, "30", "10", "0"),
+		trade("2022-04-04", database.SideSell, "10", "15", "0"),
+		trade("2022-04-05", database.SideSell, "10", "8", "0"),
+		trade("2022-04-06", database.SideSell, "10", "12", "0"),
+	})
+	assert.NoError(t, reportErr)
+
+	assert.Len(t, reports, 2)
+	assert.Equal(t, "2021/22", reports[0].TaxYear)
+	assert.Equal(t, types.MustParseDecimal("230.0"), reports[0].TotalProceeds)
+	assert.Equal(t, types.MustParseDecimal("200.0"), reports[0].TotalAllowableCosts)
+	assert.Equal(t, types.MustParseDecimal("50.0"), reports[0].TotalGains)
+	assert.Equal(t, types.MustParseDecimal("20.0"), reports[0].TotalLosses)
+	assert.Equal(t, types.MustParseDecimal("30.0"), reports[0].NetGain)
+	assert.Equal(t, "2022/23", reports[1].TaxYear)
+	assert.Equal(t, types.MustParseDecimal("20.0"), reports[1].NetGain)
+
+	_, oversellErr := BuildReports([]database.LedgerEntry{trade("2021-04-01", database.SideSell, "1", "10", "0")})
+	assert.Error(t, oversellErr)
+}
+
+// TestTaxYear checks the tax year boundary on 6 April.
+func TestTaxYear(t *testing.T) {
+	tests := map[string]struct {
+		date     string
+		expected string
+	}{
+		"Last Day Of Year":  {"2022-04-05", "2021/22"},
+		"First Day Of Year": {"2022-04-06", "2022/23"},
+		"New Year's Day":    {"2000-01-01", "1999/00"},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, TaxYear(testCase.date))
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package types
+
+import "sort"
+
+// The types of corporate action.
+const (
+	ActionSplit    = "SPLIT"
+	ActionDividend = "DIVIDEND"
+)
+
+// AdjustedBar is a daily bar along with the corporate actions of the day, and its close adjusted for every split & dividend since.
+type AdjustedBar struct {
+	Bar
+	AdjustedClose    Decimal `json:"AdjustedClose"`
+	Dividend         Decimal `json:"Dividend"`         // The dividend per share which went ex on the day. 0 if none.
+	SplitCoefficient Decimal `json:"SplitCoefficient"` // The shares each share became on the day, e.g. 4 for a 4-for-1 split. 1 if none.
+}
+
+// CorporateAction is a split or dividend of a symbol, which went ex on a trading day.
+type CorporateAction struct {
+	Symbol string  `json:"Symbol"`
+	Date   string  `json:"Date"`             // The ex-date, YYYY-MM-DD.
+	Type   string  `json:"Type"`             // SPLIT or DIVIDEND.
+	Ratio  Decimal `json:"Ratio,omitempty"`  // Splits only: the shares each share became, e.g. 0.1 for a 1-for-10 reverse split.
+	Amount Decimal `json:"Amount,omitempty"` // Dividends only: the dividend per share.
+}
+
+// IsSplit reports whether a bar's day had a split. A missing split coefficient is read as no split.
+func (a AdjustedBar) IsSplit() bool {
+	return !a.SplitCoefficient.IsZero() && a.SplitCoefficient.Cmp(DecimalFromInt(1)) != 0
+}
+
+// SortAdjustedBars sorts adjusted bars by date, oldest first, the same as SortBars.
+func SortAdjustedBars(bars []AdjustedBar) {
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Date < bars[j].Date
+	})
+}
+
+// CorporateActions lists the splits & dividends of a symbol's adjusted bars, in date order. A split and a dividend on the same day
+// are listed split first.
+func CorporateActions(symbol string, bars []AdjustedBar) []CorporateAction {
+	actions := []CorporateAction{}
+	for _, bar := range bars {
+		if bar.IsSplit() {
+			actions = append(actions, CorporateAction{Symbol: symbol, Date: bar.Date, Type: ActionSplit, Ratio: bar.SplitCoefficient})
+		}
+		if bar.Dividend.Sign() > 0 {
+			actions = append(actions, CorporateAction{Symbol: symbol, Date: bar.Date, Type: ActionDividend, Amount: bar.Dividend})
+		}
+	}
+	return actions
+}
+
+// AdjustedClosingPrices converts adjusted bars into a lookup map of [date] => adjusted-closing-price.
+func AdjustedClosingPrices(bars []AdjustedBar) map[string]Decimal {
+	prices := make(map[string]Decimal, len(bars))
+	for _, bar := range bars {
+		prices[bar.Date] = bar.AdjustedClose
+	}
+	return prices
+}
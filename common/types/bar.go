@@ -0,0 +1,65 @@
+package types
+
+import "sort"
+
+// Bar is the prices of a symbol over a single trading day.
+type Bar struct {
+	Date   string  `json:"Date"` // The trading day, YYYY-MM-DD.
+	Open   Decimal `json:"Open"`
+	High   Decimal `json:"High"`
+	Low    Decimal `json:"Low"`
+	Close  Decimal `json:"Close"`
+	Volume int64   `json:"Volume"` // The number of shares traded.
+}
+
+// SortBars sorts bars by date, oldest first.
+func SortBars(bars []Bar) {
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Date < bars[j].Date
+	})
+}
+
+// BarsBetween returns the bars between two dates (YYYY-MM-DD, inclusive) of bars sorted by date. An empty date leaves that end
+// of the range open.
+func BarsBetween(bars []Bar, from, to string) []Bar {
+	start := sort.Search(len(bars), func(i int) bool {
+		return bars[i].Date >= from
+	})
+	end := len(bars)
+	if to != "" {
+		end = sort.Search(len(bars), func(i int) bool {
+			return bars[i].Date > to
+		})
+	}
+	if start >= end {
+		return []Bar{}
+	}
+	return append([]Bar{}, bars[start:end]...)
+}
+
+// ClosingPrices converts bars into a lookup map of [date] => closing-price.
+func ClosingPrices(bars []Bar) map[string]Decimal {
+	prices := make(map[string]Decimal, len(bars))
+	for _, bar := range bars {
+		prices[bar.Date] = bar.Close
+	}
+	return prices
+}
+
+// MergeBars combines two series of bars into one sorted by date. A bar in newer replaces the bar of the same day in older.
+func MergeBars(older, newer []Bar) []Bar {
+	byDate := make(map[string]Bar, len(older)+len(newer))
+	for _, bar := range older {
+		byDate[bar.Date] = bar
+	}
+	for _, bar := range newer {
+		byDate[bar.Date] = bar
+	}
+
+	merged := make([]Bar, 0, len(byDate))
+	for _, bar := range byDate {
+		merged = append(merged, bar)
+	}
+	SortBars(merged)
+	return merged
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBarsBetween checks that bars are selected by an inclusive date range, with either end left open.
+func TestBarsBetween(t *testing.T) {
+	bars := []Bar{{Date: "2022-04-07"}, {Date: "2022-04-08"}, {Date: "2022-04-11"}, {Date: "2022-04-12"}}
+
+	tests := map[string]struct {
+		from          string
+		to            string
+		expectedDates []string
+	}{
+		"Whole Range":     {"", "", []string{"2022-04-07", "2022-04-08", "2022-04-11", "2022-04-12"}},
+		"Inclusive Range": {"2022-04-08", "2022-04-11", []string{"2022-04-08", "2022-04-11"}},
+		"Weekend Bounds":  {"2022-04-09", "2022-04-10", []string{}},
+		"Open Start":      {"", "2022-04-08", []string{"2022-04-07", "2022-04-08"}},
+		"Open End":        {"2022-04-09", "", []string{"2022-04-11", "2022-04-12"}},
+		"Backwards Range": {"2022-04-12", "2022-04-07", []string{}},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			selected := BarsBetween(bars, testCase.from, testCase.to)
+			dates := []string{}
+			for _, bar := range selected {
+				dates = append(dates, bar.Date)
+			}
+			assert.Equal(t, testCase.expectedDates, dates)
+		})
+	}
+}
+
+// TestMergeBars checks that merged bars are sorted by date, and that newer bars replace older bars of the same day.
+func TestMergeBars(t *testing.T) {
+	older := []Bar{{Date: "2022-04-08", Close: MustParseDecimal("170")}, {Date: "2022-04-07", Close: MustParseDecimal("172.14")}}
+	newer := []Bar{{Date: "2022-04-11", Close: MustParseDecimal("165.75")}, {Date: "2022-04-08", Close: MustParseDecimal("170.09")}}
+
+	assert.Equal(t, []Bar{
+		{Date: "2022-04-07", Close: MustParseDecimal("172.14")},
+		{Date: "2022-04-08", Close: MustParseDecimal("170.09")},
+		{Date: "2022-04-11", Close: MustParseDecimal("165.75")},
+	}, MergeBars(older, newer))
+	assert.Equal(t, map[string]Decimal{"2022-04-08": MustParseDecimal("170.09"), "2022-04-11": MustParseDecimal("165.75")}, ClosingPrices(newer))
+}
@@ -0,0 +1,292 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// maxDecimalScale limits how many decimal places a parsed number can have, so a hostile exponent (e.g. 1e-999999999) can't
+// allocate a huge coefficient.
+const maxDecimalScale = 1000
+
+var bigOne, bigTen = big.NewInt(1), big.NewInt(10)
+
+// Decimal is an exact decimal number, used for money so that arithmetic never picks up binary rounding errors, and never
+// overflows. The zero value is 0. Decimals are immutable: every operation returns a new value.
+//
+// Values are kept in a canonical form (no trailing zeros after the decimal point), so equal numbers are always deeply equal,
+// e.g. in assert.Equal.
+type Decimal struct {
+	coefficient *big.Int // The digits of the number. nil for 0.
+	scale       int32    // How many of the coefficient's digits are after the decimal point. Never negative.
+}
+
+// NewDecimal returns value × 10^-scale, e.g. NewDecimal(1999, 2) is 19.99.
+func NewDecimal(value int64, scale int32) Decimal {
+	return normalise(big.NewInt(value), int64(scale))
+}
+
+// DecimalFromInt returns the Decimal of a whole number.
+func DecimalFromInt(value int64) Decimal {
+	return normalise(big.NewInt(value), 0)
+}
+
+// ParseDecimal reads a Decimal from its text form, e.g. "-12.345" or "1.5e3".
+func ParseDecimal(value string) (Decimal, error) {
+	mantissa, exponent := value, int64(0)
+	if index := strings.IndexAny(value, "eE"); index >= 0 {
+		parsedExponent, parseErr := strconv.ParseInt(value[index+1:], 10, 32)
+		if parseErr != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+		}
+		mantissa, exponent = value[:index], parsedExponent
+	}
+
+	sign, digits := "", mantissa
+	if strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		sign, digits = digits[:1], digits[1:]
+	}
+	var scale int64
+	if index := strings.IndexByte(digits, '.'); index >= 0 {
+		scale = int64(len(digits) - index - 1)
+		digits = digits[:index] + digits[index+1:]
+	}
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", value)
+	}
+
+	scale -= exponent
+	if scale > maxDecimalScale || scale < -maxDecimalScale {
+		return Decimal{}, fmt.Errorf("decimal %q is out of range", value)
+	}
+	coefficient, _ := new(big.Int).SetString(sign+digits, 10)
+	return normalise(coefficient, scale), nil
+}
+
+// MustParseDecimal is ParseDecimal for constant values, which panics if the value isn't a valid number.
+func MustParseDecimal(value string) Decimal {
+	decimal, parseErr := ParseDecimal(value)
+	if parseErr != nil {
+		panic(parseErr)
+	}
+	return decimal
+}
+
+// DecimalFromFloat rounds a float64 statistic to the given number of decimal places. NaN & infinite values are returned as 0.
+func DecimalFromFloat(value float64, places int32) Decimal {
+	decimal, parseErr := ParseDecimal(strconv.FormatFloat(value, 'f', int(places), 64))
+	if parseErr != nil {
+		return Decimal{}
+	}
+	return decimal
+}
+
+// Add returns d + other.
+func (d Decimal) Add(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return normalise(new(big.Int).Add(d.rescaled(scale), other.rescaled(scale)), int64(scale))
+}
+
+// Sub returns d - other.
+func (d Decimal) Sub(other Decimal) Decimal {
+	scale := maxScale(d, other)
+	return normalise(new(big.Int).Sub(d.rescaled(scale), other.rescaled(scale)), int64(scale))
+}
+
+// Mul returns d × other, without any rounding.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return normalise(new(big.Int).Mul(d.coef(), other.coef()), int64(d.scale)+int64(other.scale))
+}
+
+// Div returns d ÷ other, rounded half away from zero to the given number of decimal places. Dividing by zero panics, so callers
+// must check the divisor first.
+func (d Decimal) Div(other Decimal, places int32) Decimal {
+	if other.IsZero() {
+		panic("types: Decimal division by zero")
+	}
+	// d ÷ other = (d.coefficient × 10^other.scale) ÷ (other.coefficient × 10^d.scale), which is scaled up by 10^places.
+	numerator := new(big.Int).Mul(d.coef(), pow10(int64(other.scale)+int64(places)))
+	denominator := new(big.Int).Mul(other.coef(), pow10(int64(d.scale)))
+	return normalise(divRound(numerator, denominator), int64(places))
+}
+
+// Round rounds d half away from zero to the given (non-negative) number of decimal places, e.g. 2.345 to 2 places is 2.35.
+func (d Decimal) Round(places int32) Decimal {
+	if d.scale <= places {
+		return d
+	}
+	return normalise(divRound(d.coef(), pow10(int64(d.scale-places))), int64(places))
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return normalise(new(big.Int).Neg(d.coef()), int64(d.scale))
+}
+
+// Cmp compares d and other, returning -1 if d < other, 0 if they are equal, and +1 if d > other.
+func (d Decimal) Cmp(other Decimal) int {
+	scale := maxScale(d, other)
+	return d.rescaled(scale).Cmp(other.rescaled(scale))
+}
+
+// Sign returns -1, 0 or +1 for a negative, zero or positive value.
+func (d Decimal) Sign() int {
+	return d.coef().Sign()
+}
+
+// Places returns how many decimal places d has, e.g. 1 for 1.50.
+func (d Decimal) Places() int32 {
+	return d.scale
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool {
+	return d.coefficient == nil
+}
+
+// Float64 converts d to the nearest float64, for statistics which don't need to be exact.
+func (d Decimal) Float64() float64 {
+	value, _ := strconv.ParseFloat(d.String(), 64)
+	return value
+}
+
+// String returns the shortest exact text form of d, e.g. "-12.5".
+func (d Decimal) String() string {
+	if d.coefficient == nil {
+		return "0"
+	}
+
+	digits := new(big.Int).Abs(d.coefficient).String()
+	if d.scale > 0 {
+		if padding := int(d.scale) + 1 - len(digits); padding > 0 {
+			digits = strings.Repeat("0", padding) + digits
+		}
+		digits = digits[:len(digits)-int(d.scale)] + "." + digits[len(digits)-int(d.scale):]
+	}
+	if d.coefficient.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalJSON writes d as a JSON number, with every digit kept.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads d from a JSON number, or a string holding a number. null is read as 0.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if unquoted, unquoteErr := strconv.Unquote(text); unquoteErr == nil {
+		text = unquoted
+	}
+
+	decimal, parseErr := ParseDecimal(text)
+	if parseErr != nil {
+		return parseErr
+	}
+	*d = decimal
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue stores d as a DynamoDB number, which holds up to 38 significant digits exactly.
+func (d Decimal) MarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	av.N = aws.String(d.String())
+	return nil
+}
+
+// UnmarshalDynamoDBAttributeValue reads d from a DynamoDB number (or string). A missing or NULL attribute is read as 0.
+func (d *Decimal) UnmarshalDynamoDBAttributeValue(av *dynamodb.AttributeValue) error {
+	var text string
+	switch {
+	case av.N != nil:
+		text = aws.StringValue(av.N)
+	case av.S != nil:
+		text = aws.StringValue(av.S)
+	default:
+		*d = Decimal{}
+		return nil
+	}
+
+	decimal, parseErr := ParseDecimal(text)
+	if parseErr != nil {
+		return parseErr
+	}
+	*d = decimal
+	return nil
+}
+
+// coef returns the coefficient of d, which is never nil.
+func (d Decimal) coef() *big.Int {
+	if d.coefficient == nil {
+		return new(big.Int)
+	}
+	return d.coefficient
+}
+
+// rescaled returns the coefficient of d with the given number of decimal places, which can't be less than d's own.
+func (d Decimal) rescaled(scale int32) *big.Int {
+	return new(big.Int).Mul(d.coef(), pow10(int64(scale-d.scale)))
+}
+
+// normalise builds the canonical Decimal of coefficient × 10^-scale, by removing trailing zeros after the decimal point.
+// The coefficient is owned by the returned Decimal, so it must not be modified afterwards.
+func normalise(coefficient *big.Int, scale int64) Decimal {
+	if coefficient.Sign() == 0 {
+		return Decimal{}
+	}
+	if scale < 0 {
+		coefficient.Mul(coefficient, pow10(-scale))
+		scale = 0
+	}
+
+	quotient, remainder := new(big.Int), new(big.Int)
+	for scale > 0 {
+		quotient.QuoRem(coefficient, bigTen, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		coefficient.Set(quotient)
+		scale--
+	}
+	return Decimal{coefficient: coefficient, scale: int32(scale)}
+}
+
+// divRound divides two integers, rounding half away from zero.
+func divRound(numerator, denominator *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	// The quotient is truncated towards zero, so move it one away from zero when the remainder is at least half the divisor.
+	doubled := new(big.Int).Abs(remainder)
+	doubled.Lsh(doubled, 1)
+	if doubled.Cmp(new(big.Int).Abs(denominator)) >= 0 {
+		if numerator.Sign() == denominator.Sign() {
+			quotient.Add(quotient, bigOne)
+		} else {
+			quotient.Sub(quotient, bigOne)
+		}
+	}
+	return quotient
+}
+
+// pow10 returns 10^exponent.
+func pow10(exponent int64) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(exponent), nil)
+}
+
+// maxScale returns the larger number of decimal places of two Decimals.
+func maxScale(a, b Decimal) int32 {
+	if a.scale > b.scale {
+		return a.scale
+	}
+	return b.scale
+}
@@ -0,0 +1,138 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseDecimal checks that numbers are read exactly, and stored in their canonical form.
+func TestParseDecimal(t *testing.T) {
+	tests := map[string]struct {
+		input          string
+		expectedString string
+		expectErr      bool
+	}{
+		"Whole Number":     {"150", "150", false},
+		"Decimal":          {"0.1", "0.1", false},
+		"Trailing Zeros":   {"10.500", "10.5", false},
+		"Negative":         {"-0.05", "-0.05", false},
+		"Negative Zero":    {"-0.00", "0", false},
+		"Positive Sign":    {"+7", "7", false},
+		"Exponent":         {"1.5e3", "1500", false},
+		"Small Exponent":   {"15E-4", "0.0015", false},
+		"Beyond float64":   {"12345678901234567890.123456789", "12345678901234567890.123456789", false},
+		"Empty":            {"", "", true},
+		"Letters":          {"12a", "", true},
+		"Two Points":       {"1.2.3", "", true},
+		"Huge Exponent":    {"1e-999999", "", true},
+		"Missing Exponent": {"1e", "", true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			decimal, parseErr := ParseDecimal(testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, parseErr)
+				return
+			}
+			assert.NoError(t, parseErr)
+			assert.Equal(t, testCase.expectedString, decimal.String())
+		})
+	}
+}
+
+// TestDecimalArithmetic checks that arithmetic is exact, and that division & rounding go half away from zero.
+func TestDecimalArithmetic(t *testing.T) {
+	tests := map[string]struct {
+		calculated Decimal
+		expected   Decimal
+	}{
+		"Add Without Float Error":   {MustParseDecimal("0.1").Add(MustParseDecimal("0.2")), MustParseDecimal("0.3")},
+		"Add To Zero":               {Decimal{}.Add(NewDecimal(1999, 2)), MustParseDecimal("19.99")},
+		"Sub To Zero":               {MustParseDecimal("10.50").Sub(MustParseDecimal("10.5")), Decimal{}},
+		"Sub Below Zero":            {MustParseDecimal("1").Sub(MustParseDecimal("1.01")), MustParseDecimal("-0.01")},
+		"Mul":                       {MustParseDecimal("1.15").Mul(DecimalFromInt(3)), MustParseDecimal("3.45")},
+		"Mul Beyond int64":          {DecimalFromInt(1e12).Mul(DecimalFromInt(1e12)), MustParseDecimal("1e24")},
+		"Div":                       {DecimalFromInt(350).Div(DecimalFromInt(2), 2), DecimalFromInt(175)},
+		"Div Rounds":                {DecimalFromInt(1000).Div(DecimalFromInt(1200), 4), MustParseDecimal("0.8333")},
+		"Div Rounds Half Up":        {DecimalFromInt(1).Div(DecimalFromInt(8), 2), MustParseDecimal("0.13")},
+		"Div Rounds Negative":       {DecimalFromInt(-1).Div(DecimalFromInt(8), 2), MustParseDecimal("-0.13")},
+		"Div Negative Divisor":      {DecimalFromInt(1).Div(DecimalFromInt(-8), 2), MustParseDecimal("-0.13")},
+		"Round Half Up":             {MustParseDecimal("2.345").Round(2), MustParseDecimal("2.35")},
+		"Round Down":                {MustParseDecimal("52.3849").Round(2), MustParseDecimal("52.38")},
+		"Round Negative":            {MustParseDecimal("-2.345").Round(2), MustParseDecimal("-2.35")},
+		"Round To Whole":            {MustParseDecimal("52.9999").Round(1), DecimalFromInt(53)},
+		"Round With Fewer Places":   {MustParseDecimal("1.5").Round(2), MustParseDecimal("1.5")},
+		"Round Large Value":         {MustParseDecimal("92233720368547758.075").Round(2), MustParseDecimal("92233720368547758.08")},
+		"Neg":                       {MustParseDecimal("4.2").Neg(), MustParseDecimal("-4.2")},
+		"Float Rounding Is Avoided": {MustParseDecimal("1.005").Round(2), MustParseDecimal("1.01")},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, testCase.calculated)
+		})
+	}
+
+	assert.Panics(t, func() { DecimalFromInt(1).Div(Decimal{}, 2) })
+}
+
+// TestDecimalCompare checks the comparison & inspection of Decimals with different numbers of decimal places.
+func TestDecimalCompare(t *testing.T) {
+	assert.Equal(t, 0, MustParseDecimal("1.50").Cmp(MustParseDecimal("1.5")))
+	assert.Equal(t, -1, MustParseDecimal("1.49").Cmp(MustParseDecimal("1.5")))
+	assert.Equal(t, 1, DecimalFromInt(2).Cmp(MustParseDecimal("1.999")))
+	assert.Equal(t, -1, MustParseDecimal("-0.01").Sign())
+	assert.True(t, MustParseDecimal("0.000").IsZero())
+	assert.Equal(t, 150.25, MustParseDecimal("150.25").Float64())
+	assert.Equal(t, int32(1), MustParseDecimal("1.50").Places())
+	assert.Equal(t, int32(0), DecimalFromInt(100).Places())
+	assert.Equal(t, MustParseDecimal("0.1235"), DecimalFromFloat(0.12349999, 4))
+	assert.Equal(t, MustParseDecimal("-2.5"), DecimalFromFloat(-2.5, 4))
+	assert.Equal(t, Decimal{}, DecimalFromFloat(math.NaN(), 4))
+}
+
+// TestDecimalMarshalling checks that Decimals survive a round trip through JSON & DynamoDB without losing any digits.
+func TestDecimalMarshalling(t *testing.T) {
+	type record struct {
+		Price Decimal `json:"Price"`
+	}
+	price := MustParseDecimal("12345678901234567.89")
+
+	body, marshallErr := json.Marshal(record{Price: price})
+	assert.NoError(t, marshallErr)
+	assert.Equal(t, `{"Price":12345678901234567.89}`, string(body))
+
+	tests := map[string]struct {
+		body     string
+		expected Decimal
+	}{
+		"Number":     {`{"Price": 12345678901234567.89}`, price},
+		"String":     {`{"Price": "12345678901234567.89"}`, price},
+		"Null":       {`{"Price": null}`, Decimal{}},
+		"Missing":    {`{}`, Decimal{}},
+		"Float Text": {`{"Price": 0.1}`, MustParseDecimal("0.1")},
+	}
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var decoded record
+			assert.NoError(t, json.Unmarshal([]byte(testCase.body), &decoded))
+			assert.Equal(t, testCase.expected, decoded.Price)
+		})
+	}
+
+	var decoded record
+	assert.Error(t, json.Unmarshal([]byte(`{"Price": "ten"}`), &decoded))
+
+	item, dynamoMarshallErr := dynamodbattribute.MarshalMap(record{Price: price})
+	assert.NoError(t, dynamoMarshallErr)
+	assert.Equal(t, "12345678901234567.89", *item["Price"].N)
+
+	var dynamoDecoded record
+	assert.NoError(t, dynamodbattribute.UnmarshalMap(item, &dynamoDecoded))
+	assert.Equal(t, price, dynamoDecoded.Price)
+}
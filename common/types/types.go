@@ -2,7 +2,74 @@ package types
 
 // NewStockTrade is the data structure of a new stock trade made.
 type NewStockTrade struct {
-	Symbol   string  `json:"Symbol"`
-	Quantity uint    `json:"Quantity"`
-	Price    float64 `json:"Price"`
+	Symbol    string  `json:"Symbol"`
+	Quantity  Decimal `json:"Quantity"`
+	Price     Decimal `json:"Price"`
+	LotMethod string  `json:"LotMethod,omitempty"` // Sells only: overrides the default lot matching method.
+	Exchange  string  `json:"Exchange,omitempty"`  // The exchange the trade was made on, which picks its fee schedule. e.g. LSE
+
+	// Dealing charges. When none are given, they are filled in from the fee schedule of the trade's exchange.
+	Commission Decimal `json:"Commission"`
+	FXFee      Decimal `json:"FXFee"`
+	StampDuty  Decimal `json:"StampDuty"`
+}
+
+// Value returns the cost of the trade's shares, to the penny.
+func (trade NewStockTrade) Value() Decimal {
+	return trade.Price.Mul(trade.Quantity).Round(2)
+}
+
+// TotalFees returns the sum of the trade's dealing charges.
+func (trade NewStockTrade) TotalFees() Decimal {
+	return trade.Commission.Add(trade.FXFee).Add(trade.StampDuty)
+}
+
+// Cost returns the total cost of a buy: the value of its shares plus its fees.
+func (trade NewStockTrade) Cost() Decimal {
+	return trade.Value().Add(trade.TotalFees())
+}
+
+// Proceeds returns the cash received from a sell: the value of its shares less its fees.
+func (trade NewStockTrade) Proceeds() Decimal {
+	return trade.Value().Sub(trade.TotalFees())
+}
+
+// NewDividend is the data structure of a dividend received on a position.
+type NewDividend struct {
+	Symbol         string  `json:"Symbol"`
+	ExDate         string  `json:"ExDate"`             // The day the shares went ex-dividend, YYYY-MM-DD.
+	PayDate        string  `json:"PayDate,omitempty"`  // The day the dividend was paid, YYYY-MM-DD. Defaults to the ex-date.
+	AmountPerShare Decimal `json:"AmountPerShare"`     // The gross dividend paid on each share.
+	Quantity       Decimal `json:"Quantity"`           // The shares the dividend was paid on. Defaults to the shares held.
+	Exchange       string  `json:"Exchange,omitempty"` // The exchange the shares are listed on, which picks the withholding tax rate. e.g. NYSE
+
+	// Tax withheld at source. When none is given, it is filled in from the withholding tax rate of the dividend's exchange.
+	WithholdingTax Decimal `json:"WithholdingTax"`
+
+	// Reinvest buys more shares of the symbol with the net dividend (DRIP), at the ReinvestPrice. When no price is given, the
+	// closing price on the pay date is used.
+	Reinvest      bool    `json:"Reinvest"`
+	ReinvestPrice Decimal `json:"ReinvestPrice"`
+}
+
+// Gross returns the dividend paid before tax, to the penny.
+func (dividend NewDividend) Gross() Decimal {
+	return dividend.AmountPerShare.Mul(dividend.Quantity).Round(2)
 }
+
+// Net returns the dividend received: the gross dividend less the tax withheld.
+func (dividend NewDividend) Net() Decimal {
+	return dividend.Gross().Sub(dividend.WithholdingTax)
+}
+
+// NewCashFlow is the data structure of money deposited into, or withdrawn from, the portfolio's cash.
+type NewCashFlow struct {
+	Amount Decimal `json:"Amount"` // Always positive. Whether it is paid in or taken out depends on the endpoint.
+}
+
+// Lot matching methods, which decide the cost basis of the shares being sold.
+const (
+	LotMethodFIFO    = "FIFO"    // Sell the oldest shares first.
+	LotMethodLIFO    = "LIFO"    // Sell the newest shares first.
+	LotMethodAverage = "AVERAGE" // Sell at the average cost of every share held.
+)
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"errors"
+)
+
+// ErrInsufficientCash is returned when the portfolio doesn't hold enough cash to pay for a buy.
+var ErrInsufficientCash = errors.New("not enough cash to enter position")
+
+// ApplyBuy adds the shares of a buy, made at the acquired timestamp, to the portfolio: to the existing position in the symbol, or
+// as a new position, along with a new lot. The cost of the buy, including fees, is taken from CASH. An existing position's market
+// value is updated at its last share price, but portfolio ratios aren't recalculated. ErrInsufficientCash is returned, and the portfolio left unchanged, if CASH can't pay for the buy.
+func ApplyBuy(openPositions []database.OpenStockPosition, trade types.NewStockTrade, acquired string) ([]database.OpenStockPosition, error) {
+	cost := trade.Cost()
+	if !canAffordTrade(openPositions, cost) {
+		return openPositions, ErrInsufficientCash
+	}
+
+	// If a position in the stock exists, combine the two records. Otherwise, create a new portfolio record.
+	var positionAlreadyExists bool
+	for index, position := range openPositions {
+		if position.SK == trade.Symbol {
+			positionAlreadyExists = true
+			openPositions[index] = revalueShares(AddLot(CombinePositions(position, trade), trade, acquired))
+		}
+	}
+	if !positionAlreadyExists {
+		openPositions = append(openPositions, AddLot(NewPosition(trade), trade, acquired))
+	}
+
+	return AdjustCash(openPositions, cost.Neg()), nil
+}
+
+// AdjustCash adds an amount of cash to the CASH position, or removes it when the amount is negative.
+func AdjustCash(openPositions []database.OpenStockPosition, amount types.Decimal) []database.OpenStockPosition {
+	for index, position := range openPositions {
+		if position.SK == "CASH" {
+			newValue := position.PurchaseValue.Add(amount)
+			openPositions[index].PurchaseValue = newValue
+			openPositions[index].CurrentValue = newValue
+		}
+	}
+	return openPositions
+}
+
+// canAffordTrade checks that there is enough cash in the portfolio to afford a trade.
+func canAffordTrade(openPositions []database.OpenStockPosition, tradeValue types.Decimal) bool {
+	var totalCash types.Decimal
+	for _, position := range openPositions {
+		if position.SK == "CASH" {
+			totalCash = position.CurrentValue
+		}
+	}
+	return totalCash.Cmp(tradeValue) >= 0
+}
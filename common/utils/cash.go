@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"fmt"
+)
+
+// cashPrecision is how many decimal places an amount of cash can have: whole pennies or cents.
+const cashPrecision = 2
+
+// ValidateCashAmount checks that an amount deposited or withdrawn is more than 0, and is a whole number of pennies.
+func ValidateCashAmount(amount types.Decimal) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("amount must be more than 0, but got: %v", amount)
+	}
+	if amount.Places() > cashPrecision {
+		return fmt.Errorf("amount %v has more than %v decimal places", amount, cashPrecision)
+	}
+	return nil
+}
+
+// ApplyDeposit adds money paid into the portfolio to CASH, creating the CASH position if the portfolio doesn't have one yet.
+// Portfolio ratios aren't recalculated.
+func ApplyDeposit(openPositions []database.OpenStockPosition, amount types.Decimal) []database.OpenStockPosition {
+	if !holdsCash(openPositions) {
+		openPositions = append(openPositions, database.OpenStockPosition{SK: "CASH"})
+	}
+	return AdjustCash(openPositions, amount)
+}
+
+// ApplyWithdrawal takes money out of the portfolio's CASH. Portfolio ratios aren't recalculated. ErrInsufficientCash is returned,
+// and the portfolio left unchanged, if CASH holds less than the amount.
+func ApplyWithdrawal(openPositions []database.OpenStockPosition, amount types.Decimal) ([]database.OpenStockPosition, error) {
+	if !canAffordTrade(openPositions, amount) {
+		return openPositions, ErrInsufficientCash
+	}
+	return AdjustCash(openPositions, amount.Neg()), nil
+}
+
+// holdsCash checks whether the portfolio has a CASH position.
+func holdsCash(openPositions []database.OpenStockPosition) bool {
+	for _, position := range openPositions {
+		if position.SK == "CASH" {
+			return true
+		}
+	}
+	return false
+}
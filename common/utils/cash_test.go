@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateCashAmount checks that only positive amounts of whole pennies can be deposited or withdrawn.
+func TestValidateCashAmount(t *testing.T) {
+	tests := map[string]struct {
+		amount    types.Decimal
+		expectErr bool
+	}{
+		"Whole Amount":         {types.MustParseDecimal("500"), false},
+		"Pennies":              {types.MustParseDecimal("500.25"), false},
+		"Zero":                 {types.MustParseDecimal("0"), true},
+		"Negative":             {types.MustParseDecimal("-500"), true},
+		"Fractions Of A Penny": {types.MustParseDecimal("500.255"), true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testCase.expectErr, ValidateCashAmount(testCase.amount) != nil)
+		})
+	}
+}
+
+// TestApplyCashFlows checks that deposits add to CASH, creating it if needed, and that no more than the cash held can be withdrawn.
+func TestApplyCashFlows(t *testing.T) {
+	aapl := database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), Shares: types.MustParseDecimal("4")}
+
+	deposited := ApplyDeposit([]database.OpenStockPosition{aapl}, types.MustParseDecimal("500"))
+	assert.Equal(t, []database.OpenStockPosition{aapl, {SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500")}}, deposited)
+
+	deposited = ApplyDeposit(deposited, types.MustParseDecimal("250.5"))
+	assert.Equal(t, types.MustParseDecimal("750.5"), deposited[1].CurrentValue)
+
+	withdrawn, withdrawErr := ApplyWithdrawal(deposited, types.MustParseDecimal("750.5"))
+	assert.NoError(t, withdrawErr)
+	assert.Equal(t, types.MustParseDecimal("0"), withdrawn[1].CurrentValue)
+
+	withdrawn, withdrawErr = ApplyWithdrawal(withdrawn, types.MustParseDecimal("0.01"))
+	assert.ErrorIs(t, withdrawErr, ErrInsufficientCash)
+	assert.Equal(t, types.MustParseDecimal("0"), withdrawn[1].CurrentValue)
+}
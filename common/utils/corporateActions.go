@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"sort"
+)
+
+// ApplySplit rescales a position for a split, where each share became ratio shares (e.g. 4 for a 4-for-1 split, or 0.1 for a
+// 1-for-10 reverse split). Only the lots bought before the split's ex-date are rescaled, as lots bought on or after it were
+// already bought at the split price, and shares which aren't in any lot are treated as bought before it. The purchase value
+// doesn't change, so neither does the return of the shares held before the split.
+func ApplySplit(openPosition database.OpenStockPosition, split types.CorporateAction) database.OpenStockPosition {
+	precision := QuantityPrecision()
+	untracked := openPosition.Shares
+	shares := types.Decimal{}
+
+	lots := make([]database.Lot, 0, len(openPosition.Lots))
+	for _, lot := range openPosition.Lots {
+		untracked = untracked.Sub(lot.Quantity)
+		if lotDate(lot) < split.Date {
+			lot.Quantity = lot.Quantity.Mul(split.Ratio).Round(precision)
+		}
+		shares = shares.Add(lot.Quantity)
+		lots = append(lots, lot)
+	}
+	if len(lots) > 0 {
+		openPosition.Lots = lots
+	}
+
+	openPosition.Shares = shares.Add(untracked.Mul(split.Ratio).Round(precision))
+	if !openPosition.Shares.IsZero() {
+		openPosition.AveragePrice = openPosition.PurchaseValue.Div(openPosition.Shares, 2)
+	}
+	openPosition.CurrentStockPrice = openPosition.CurrentStockPrice.Div(split.Ratio, 2)
+	return openPosition
+}
+
+// PendingActions picks the corporate actions of a type (e.g. SPLIT) which haven't been applied to a position yet, in date order:
+// those after the last one applied (YYYY-MM-DD, empty if none), up to & including the through date. Actions on or before the day
+// the position's oldest lot was bought are left out too, as those shares were bought without them.
+func PendingActions(openPosition database.OpenStockPosition, actions []types.CorporateAction, actionType, lastApplied, through string) []types.CorporateAction {
+	after := lastApplied
+	if acquired := AcquiredDate(openPosition); acquired > after {
+		after = acquired
+	}
+
+	var pending []types.CorporateAction
+	for _, action := range actions {
+		if action.Type == actionType && action.Date > after && action.Date <= through {
+			pending = append(pending, action)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Date < pending[j].Date
+	})
+	return pending
+}
+
+// AcquiredDate returns the day (YYYY-MM-DD) the oldest lot of a position was bought, or an empty date if it isn't known.
+func AcquiredDate(openPosition database.OpenStockPosition) string {
+	if len(openPosition.Lots) == 0 {
+		return ""
+	}
+	return lotDate(openPosition.Lots[0])
+}
+
+// lotDate returns the day (YYYY-MM-DD) a lot was bought, or an empty date if it isn't known.
+func lotDate(lot database.Lot) string {
+	if len(lot.Acquired) < len("2006-01-02") {
+		return ""
+	}
+	return lot.Acquired[:len("2006-01-02")]
+}
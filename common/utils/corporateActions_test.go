@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplySplit checks that a split rescales the shares & average price of a position, and its lots, without changing its value.
+func TestApplySplit(t *testing.T) {
+	position := database.OpenStockPosition{
+		SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("800"), AveragePrice: types.MustParseDecimal("150"), PercentageReturn: types.MustParseDecimal("0.3333"),
+		Shares: types.MustParseDecimal("4"), CurrentStockPrice: types.MustParseDecimal("200"),
+		Lots: []database.Lot{{Acquired: "2022-03-01T12:00:00.000000000Z", Quantity: types.MustParseDecimal("3"), Cost: types.MustParseDecimal("420")}, {Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("180")}},
+	}
+
+	tests := map[string]struct {
+		split            types.CorporateAction
+		expectedPosition database.OpenStockPosition
+	}{
+		"Forward Split": {
+			types.CorporateAction{Symbol: "AAPL", Date: "2022-04-11", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")},
+			database.OpenStockPosition{
+				SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("800"), AveragePrice: types.MustParseDecimal("37.5"), PercentageReturn: types.MustParseDecimal("0.3333"),
+				Shares: types.MustParseDecimal("16"), CurrentStockPrice: types.MustParseDecimal("50"),
+				Lots: []database.Lot{{Acquired: "2022-03-01T12:00:00.000000000Z", Quantity: types.MustParseDecimal("12"), Cost: types.MustParseDecimal("420")}, {Quantity: types.MustParseDecimal("4"), Cost: types.MustParseDecimal("180")}},
+			},
+		},
+		"Reverse Split": {
+			types.CorporateAction{Symbol: "AAPL", Date: "2022-04-11", Type: types.ActionSplit, Ratio: types.MustParseDecimal("0.1")},
+			database.OpenStockPosition{
+				SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("800"), AveragePrice: types.MustParseDecimal("1500"), PercentageReturn: types.MustParseDecimal("0.3333"),
+				Shares: types.MustParseDecimal("0.4"), CurrentStockPrice: types.MustParseDecimal("2000"),
+				Lots: []database.Lot{{Acquired: "2022-03-01T12:00:00.000000000Z", Quantity: types.MustParseDecimal("0.3"), Cost: types.MustParseDecimal("420")}, {Quantity: types.MustParseDecimal("0.1"), Cost: types.MustParseDecimal("180")}},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, testCase.expectedPosition, ApplySplit(position, testCase.split))
+		})
+	}
+	assert.Equal(t, types.MustParseDecimal("1"), position.Lots[1].Quantity, "the original lots are unchanged")
+}
+
+// TestApplySplitAfterExDate checks that shares bought on or after a split's ex-date, at the split price, aren't rescaled again.
+func TestApplySplitAfterExDate(t *testing.T) {
+	position := database.OpenStockPosition{
+		SK: "AAPL", PurchaseValue: types.MustParseDecimal("1700"), AveragePrice: types.MustParseDecimal("113.33"), Shares: types.MustParseDecimal("15"), CurrentStockPrice: types.MustParseDecimal("160"),
+		Lots: []database.Lot{
+			{Acquired: "2022-03-01T12:00:00.000000000Z", Quantity: types.MustParseDecimal("10"), Cost: types.MustParseDecimal("1500")},
+			{Acquired: "2022-04-11T14:30:00.000000000Z", Quantity: types.MustParseDecimal("5"), Cost: types.MustParseDecimal("200")},
+		},
+	}
+	split := types.CorporateAction{Symbol: "AAPL", Date: "2022-04-11", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")}
+
+	assert.Equal(t, database.OpenStockPosition{
+		SK: "AAPL", PurchaseValue: types.MustParseDecimal("1700"), AveragePrice: types.MustParseDecimal("37.78"), Shares: types.MustParseDecimal("45"), CurrentStockPrice: types.MustParseDecimal("40"),
+		Lots: []database.Lot{
+			{Acquired: "2022-03-01T12:00:00.000000000Z", Quantity: types.MustParseDecimal("40"), Cost: types.MustParseDecimal("1500")},
+			{Acquired: "2022-04-11T14:30:00.000000000Z", Quantity: types.MustParseDecimal("5"), Cost: types.MustParseDecimal("200")},
+		},
+	}, ApplySplit(position, split))
+}
+
+// TestPendingActions checks that only the actions since the position was bought, which haven't been applied yet, are picked.
+func TestPendingActions(t *testing.T) {
+	actions := []types.CorporateAction{
+		{Symbol: "AAPL", Date: "2020-08-31", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")},
+		{Symbol: "AAPL", Date: "2022-02-04", Type: types.ActionDividend, Amount: types.MustParseDecimal("0.22")},
+		{Symbol: "AAPL", Date: "2022-03-07", Type: types.ActionSplit, Ratio: types.MustParseDecimal("2")},
+		{Symbol: "AAPL", Date: "2022-04-11", Type: types.ActionSplit, Ratio: types.MustParseDecimal("3")},
+	}
+	bought := database.OpenStockPosition{SK: "AAPL", Lots: []database.Lot{{Acquired: "2022-03-01T12:00:00.000000000Z"}}}
+	untracked := database.OpenStockPosition{SK: "AAPL", Lots: []database.Lot{{Quantity: types.MustParseDecimal("1")}}}
+
+	tests := map[string]struct {
+		position      database.OpenStockPosition
+		actionType    string
+		lastApplied   string
+		through       string
+		expectedDates []string
+	}{
+		"Since Bought":           {bought, types.ActionSplit, "", "2022-04-11", []string{"2022-03-07", "2022-04-11"}},
+		"Already Applied":        {bought, types.ActionSplit, "2022-03-07", "2022-04-11", []string{"2022-04-11"}},
+		"Not Ex Yet":             {bought, types.ActionSplit, "", "2022-04-08", []string{"2022-03-07"}},
+		"Unknown Purchase Date":  {untracked, types.ActionSplit, "2020-08-31", "2022-04-11", []string{"2022-03-07", "2022-04-11"}},
+		"Nothing Pending":        {bought, types.ActionSplit, "2022-04-11", "2022-04-11", nil},
+		"Dividends":              {untracked, types.ActionDividend, "", "2022-04-11", []string{"2022-02-04"}},
+		"Dividend Before Bought": {bought, types.ActionDividend, "", "2022-04-11", nil},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			var dates []string
+			for _, split := range PendingActions(testCase.position, actions, testCase.actionType, testCase.lastApplied, testCase.through) {
+				dates = append(dates, split.Date)
+			}
+			assert.Equal(t, testCase.expectedDates, dates)
+		})
+	}
+}
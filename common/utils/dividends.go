@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"Investing-API/common/calendar"
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultWithholdingRate is the WITHHOLDING_TAX entry used for dividends on an exchange without its own entry.
+const defaultWithholdingRate = "DEFAULT"
+
+// ResolveDividend fills in the missing details of a dividend: the shares it was paid on, the exchange, from the symbol, and the tax
+// withheld, from the withholding tax rate of the exchange. The shares paid on are those held at the close before the ex-date: the
+// position's lots bought before the ex-date, along with the shares of those lots sold on or after it, from the sells given.
+// The rates are read from the WITHHOLDING_TAX environment variable, a JSON object of exchange => rate (e.g. {"NYSE": 0.15}). An
+// exchange without a rate uses the DEFAULT rate, and with no DEFAULT rate, nothing is withheld.
+func ResolveDividend(openPositions []database.OpenStockPosition, sells []database.LedgerEntry, dividend types.NewDividend) (types.NewDividend, error) {
+	if dividend.Quantity.IsZero() {
+		dividend.Quantity = sharesHeldBefore(openPositions, sells, dividend.Symbol, dividend.ExDate)
+		if dividend.Quantity.IsZero() {
+			return dividend, fmt.Errorf("no shares of %v were held before its ex-date %v to receive a dividend on", dividend.Symbol, dividend.ExDate)
+		}
+	}
+	if dividend.Exchange == "" {
+		dividend.Exchange = calendar.ForSymbol(dividend.Symbol).Exchange
+	}
+
+	if !dividend.WithholdingTax.IsZero() || os.Getenv("WITHHOLDING_TAX") == "" {
+		return dividend, nil
+	}
+	var rates map[string]types.Decimal
+	if parseErr := json.Unmarshal([]byte(os.Getenv("WITHHOLDING_TAX")), &rates); parseErr != nil {
+		return dividend, fmt.Errorf("invalid WITHHOLDING_TAX: %v", parseErr)
+	}
+	rate, exists := rates[dividend.Exchange]
+	if !exists {
+		rate = rates[defaultWithholdingRate]
+	}
+	dividend.WithholdingTax = dividend.Gross().Mul(rate).Round(2)
+	return dividend, nil
+}
+
+// ValidateDividend checks that a dividend has an ex-date, a positive amount per share, a valid quantity, and that neither the tax
+// withheld nor the reinvestment price is negative. More tax can't be withheld than the dividend pays.
+func ValidateDividend(dividend types.NewDividend) error {
+	if dividend.Symbol == "" {
+		return fmt.Errorf("a dividend needs a symbol")
+	}
+	if _, dateErr := time.Parse("2006-01-02", dividend.ExDate); dateErr != nil {
+		return fmt.Errorf("incorrect ex-date format. expecting YYYY-MM-DD, but got: %q", dividend.ExDate)
+	}
+	if dividend.PayDate != "" {
+		if _, dateErr := time.Parse("2006-01-02", dividend.PayDate); dateErr != nil {
+			return fmt.Errorf("incorrect pay date format. expecting YYYY-MM-DD, but got: %q", dividend.PayDate)
+		}
+		if dividend.PayDate < dividend.ExDate {
+			return fmt.Errorf("pay date %v is before the ex-date %v", dividend.PayDate, dividend.ExDate)
+		}
+	}
+	if dividend.AmountPerShare.Sign() <= 0 {
+		return fmt.Errorf("amount per share must be more than 0, but got: %v", dividend.AmountPerShare)
+	}
+	if quantityErr := ValidateQuantity(dividend.Quantity); quantityErr != nil {
+		return quantityErr
+	}
+	if dividend.WithholdingTax.Sign() < 0 || dividend.WithholdingTax.Cmp(dividend.Gross()) > 0 {
+		return fmt.Errorf("withholding tax must be between 0 and the gross dividend of %v, but got: %v", dividend.Gross(), dividend.WithholdingTax)
+	}
+	if dividend.ReinvestPrice.Sign() < 0 {
+		return fmt.Errorf("ReinvestPrice can't be negative, but got: %v", dividend.ReinvestPrice)
+	}
+	return nil
+}
+
+// ApplyDividend credits CASH with the net amount of a resolved dividend received at the given time, and records it in the ledger.
+// A reinvested dividend then buys as many shares of the symbol as the net amount pays for, through ApplyBuy, which is also recorded
+// in the ledger. Reinvested shares are bought without dealing charges. Portfolio ratios aren't recalculated.
+func ApplyDividend(openPositions []database.OpenStockPosition, dividend types.NewDividend, requestID string, at time.Time) ([]database.OpenStockPosition, []database.LedgerEntry, error) {
+	openPositions = ApplyDeposit(openPositions, dividend.Net())
+	entries := []database.LedgerEntry{database.NewDividendEntry(dividend, requestID, at)}
+	if !dividend.Reinvest {
+		return openPositions, entries, nil
+	}
+
+	if dividend.ReinvestPrice.Sign() <= 0 {
+		return openPositions, entries, fmt.Errorf("no price to reinvest the dividend of %v at", dividend.Symbol)
+	}
+	trade := ReinvestmentTrade(dividend)
+	if trade.Quantity.Sign() <= 0 {
+		return openPositions, entries, nil
+	}
+	// The buy is timestamped after the dividend, so the ledger replays the cash before it's spent.
+	tradeEntry := database.NewTradeEntry(trade, database.SideBuy, requestID, at.Add(time.Nanosecond))
+	openPositions, buyErr := ApplyBuy(openPositions, trade, tradeEntry.Timestamp)
+	if buyErr != nil {
+		return openPositions, entries, buyErr
+	}
+	return openPositions, append(entries, tradeEntry), nil
+}
+
+// ReinvestmentTrade builds the buy of a reinvested dividend: the most shares, to the QuantityPrecision, which the net dividend
+// pays for at the reinvestment price.
+func ReinvestmentTrade(dividend types.NewDividend) types.NewStockTrade {
+	precision := QuantityPrecision()
+	trade := types.NewStockTrade{Symbol: dividend.Symbol, Price: dividend.ReinvestPrice, Exchange: dividend.Exchange}
+	trade.Quantity = dividend.Net().Div(dividend.ReinvestPrice, precision)
+	// The shares' value is rounded to the penny, which can take it just over the dividend.
+	if trade.Cost().Cmp(dividend.Net()) > 0 {
+		trade.Quantity = trade.Quantity.Sub(types.NewDecimal(1, precision))
+	}
+	return trade
+}
+
+// ReinvestDividends reports whether dividends found in the price data should be reinvested: the REINVEST_DIVIDENDS environment
+// variable, which is false when it isn't set.
+func ReinvestDividends() bool {
+	reinvest, _ := strconv.ParseBool(os.Getenv("REINVEST_DIVIDENDS"))
+	return reinvest
+}
+
+// sharesHeldBefore counts the shares of a symbol held at the close before a date (YYYY-MM-DD): the lots of the position bought
+// before the date, and the shares of such lots sold on or after it. Shares which aren't in any lot were bought before lots were
+// tracked, so are counted too.
+func sharesHeldBefore(openPositions []database.OpenStockPosition, sells []database.LedgerEntry, symbol, date string) types.Decimal {
+	var held types.Decimal
+	for _, position := range openPositions {
+		if position.SK != symbol {
+			continue
+		}
+		held = position.Shares
+		for _, lot := range position.Lots {
+			if lotDate(lot) >= date {
+				held = held.Sub(lot.Quantity)
+			}
+		}
+	}
+
+	for _, sell := range sells {
+		if sell.EntryType != database.EntryTypeTrade || sell.Side != database.SideSell || sell.Symbol != symbol || sell.Timestamp < date {
+			continue
+		}
+		for _, lotSale := range sell.LotsSold {
+			if lotDate(database.Lot{Acquired: lotSale.Acquired}) < date {
+				held = held.Add(lotSale.Quantity)
+			}
+		}
+	}
+	return held
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestResolveDividend checks that a dividend's shares, exchange & withholding tax are filled in, unless the dividend gives them.
+func TestResolveDividend(t *testing.T) {
+	const rates = `{"NYSE": 0.15, "DEFAULT": 0.3}`
+	openPositions := []database.OpenStockPosition{
+		{SK: "AAPL", Shares: types.MustParseDecimal("4")},
+		{SK: "VOD.LON", Shares: types.MustParseDecimal("100")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("400")},
+	}
+
+	tests := map[string]struct {
+		rates            string
+		dividend         types.NewDividend
+		expectedDividend types.NewDividend
+		expectErr        bool
+	}{
+		"Exchange Rate": {
+			rates, types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5")},
+			types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("4"), Exchange: "NYSE", WithholdingTax: types.MustParseDecimal("1.5")}, false,
+		},
+		"Default Rate": {
+			rates, types.NewDividend{Symbol: "VOD.LON", AmountPerShare: types.MustParseDecimal("0.0445")},
+			types.NewDividend{Symbol: "VOD.LON", AmountPerShare: types.MustParseDecimal("0.0445"), Quantity: types.MustParseDecimal("100"), Exchange: "LSE", WithholdingTax: types.MustParseDecimal("1.34")}, false,
+		},
+		"Given Details": {
+			rates, types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("2"), Exchange: "LSE", WithholdingTax: types.MustParseDecimal("1")},
+			types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("2"), Exchange: "LSE", WithholdingTax: types.MustParseDecimal("1")}, false,
+		},
+		"No Rates": {
+			"", types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5")},
+			types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("4"), Exchange: "NYSE"}, false,
+		},
+		"Invalid Rates": {
+			"0.15", types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5")},
+			types.NewDividend{Symbol: "AAPL", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("4"), Exchange: "NYSE"}, true,
+		},
+		"No Shares Held": {
+			rates, types.NewDividend{Symbol: "MSFT", AmountPerShare: types.MustParseDecimal("0.62")},
+			types.NewDividend{Symbol: "MSFT", AmountPerShare: types.MustParseDecimal("0.62")}, true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("WITHHOLDING_TAX", testCase.rates)
+			resolved, resolveErr := ResolveDividend(openPositions, nil, testCase.dividend)
+			assert.Equal(t, testCase.expectErr, resolveErr != nil)
+			assert.Equal(t, testCase.expectedDividend, resolved)
+		})
+	}
+}
+
+// TestResolveDividendShares checks that a dividend is paid on the shares held before its ex-date, including shares sold since.
+func TestResolveDividendShares(t *testing.T) {
+	t.Setenv("WITHHOLDING_TAX", "")
+	openPositions := []database.OpenStockPosition{
+		{SK: "AAPL", Shares: types.MustParseDecimal("12"), Lots: []database.Lot{
+			{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("7")},
+			{Acquired: "2022-05-06T14:30:00.000000000Z", Quantity: types.MustParseDecimal("5")},
+		}},
+	}
+	sellLots := func(at time.Time, lots ...database.LotSale) database.LedgerEntry {
+		sell := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL"}, database.SideSell, "", at)
+		sell.LotsSold = lots
+		return sell
+	}
+
+	tests := map[string]struct {
+		openPositions    []database.OpenStockPosition
+		sells            []database.LedgerEntry
+		expectedQuantity types.Decimal
+		expectErr        bool
+	}{
+		"Bought On Ex-Date": {openPositions, nil, types.MustParseDecimal("7"), false},
+		"Sold On Ex-Date": {
+			openPositions,
+			[]database.LedgerEntry{sellLots(time.Date(2022, 5, 6, 15, 0, 0, 0, time.UTC), database.LotSale{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("3")})},
+			types.MustParseDecimal("10"), false,
+		},
+		"Whole Position Sold": {
+			nil,
+			[]database.LedgerEntry{sellLots(time.Date(2022, 5, 9, 15, 0, 0, 0, time.UTC), database.LotSale{Acquired: "2022-03-01T15:00:00.000000000Z", Quantity: types.MustParseDecimal("7")})},
+			types.MustParseDecimal("7"), false,
+		},
+		"Only Bought Since": {
+			[]database.OpenStockPosition{{SK: "AAPL", Shares: types.MustParseDecimal("5"), Lots: []database.Lot{{Acquired: "2022-05-06T14:30:00.000000000Z", Quantity: types.MustParseDecimal("5")}}}},
+			nil,
+			types.MustParseDecimal("0"), true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			resolved, resolveErr := ResolveDividend(testCase.openPositions, testCase.sells, types.NewDividend{Symbol: "AAPL", ExDate: "2022-05-06", AmountPerShare: types.MustParseDecimal("2.5")})
+			assert.Equal(t, testCase.expectErr, resolveErr != nil)
+			assert.Equal(t, 0, testCase.expectedQuantity.Cmp(resolved.Quantity))
+		})
+	}
+}
+
+// TestValidateDividend checks that dividends with missing or impossible details are rejected.
+func TestValidateDividend(t *testing.T) {
+	valid := types.NewDividend{Symbol: "AAPL", ExDate: "2022-05-06", PayDate: "2022-05-12", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("4"), WithholdingTax: types.MustParseDecimal("1.5")}
+
+	tests := map[string]struct {
+		change    func(dividend *types.NewDividend)
+		expectErr bool
+	}{
+		"Valid":                  {func(dividend *types.NewDividend) {}, false},
+		"No Symbol":              {func(dividend *types.NewDividend) { dividend.Symbol = "" }, true},
+		"Incorrect Ex-Date":      {func(dividend *types.NewDividend) { dividend.ExDate = "06/05/2022" }, true},
+		"No Pay Date":            {func(dividend *types.NewDividend) { dividend.PayDate = "" }, false},
+		"Paid Before Ex-Date":    {func(dividend *types.NewDividend) { dividend.PayDate = "2022-05-05" }, true},
+		"Zero Amount":            {func(dividend *types.NewDividend) { dividend.AmountPerShare = types.MustParseDecimal("0") }, true},
+		"Negative Quantity":      {func(dividend *types.NewDividend) { dividend.Quantity = types.MustParseDecimal("-4") }, true},
+		"Tax More Than Dividend": {func(dividend *types.NewDividend) { dividend.WithholdingTax = types.MustParseDecimal("10.01") }, true},
+		"Negative Price":         {func(dividend *types.NewDividend) { dividend.ReinvestPrice = types.MustParseDecimal("-1") }, true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			dividend := valid
+			testCase.change(&dividend)
+			assert.Equal(t, testCase.expectErr, ValidateDividend(dividend) != nil)
+		})
+	}
+}
+
+// TestApplyDividend checks that a dividend credits CASH with its net amount, and that a reinvested dividend buys whole units of the
+// quantity precision without spending more than the dividend.
+func TestApplyDividend(t *testing.T) {
+	at := time.Date(2022, 5, 12, 14, 30, 0, 0, time.UTC)
+	dividend := types.NewDividend{Symbol: "AAPL", ExDate: "2022-05-06", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("4"), WithholdingTax: types.MustParseDecimal("1.5")}
+
+	tests := map[string]struct {
+		reinvest       bool
+		reinvestPrice  types.Decimal
+		expectedShares types.Decimal
+		expectedCash   types.Decimal
+		expectedLedger int
+		expectErr      bool
+	}{
+		"Credit Cash":       {false, types.Decimal{}, types.MustParseDecimal("4"), types.MustParseDecimal("408.5"), 1, false},
+		"Reinvest":          {true, types.MustParseDecimal("170"), types.MustParseDecimal("4.05"), types.MustParseDecimal("400"), 2, false},
+		"Reinvest Rounded":  {true, types.MustParseDecimal("3"), types.MustParseDecimal("6.83"), types.MustParseDecimal("400.01"), 2, false},
+		"Too Little To Buy": {true, types.MustParseDecimal("1000"), types.MustParseDecimal("4"), types.MustParseDecimal("408.5"), 1, false},
+		"No Price":          {true, types.Decimal{}, types.MustParseDecimal("4"), types.MustParseDecimal("408.5"), 1, true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("QUANTITY_PRECISION", "2")
+			openPositions := []database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("600"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("4")},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("400"), CurrentValue: types.MustParseDecimal("400")},
+			}
+			reinvested := dividend
+			reinvested.Reinvest, reinvested.ReinvestPrice = testCase.reinvest, testCase.reinvestPrice
+
+			updated, entries, applyErr := ApplyDividend(openPositions, reinvested, "", at)
+			assert.Equal(t, testCase.expectErr, applyErr != nil)
+			assert.Equal(t, testCase.expectedShares, updated[0].Shares)
+			assert.Equal(t, testCase.expectedCash, updated[1].PurchaseValue)
+			assert.Len(t, entries, testCase.expectedLedger)
+			assert.Equal(t, database.EntryTypeDividend, entries[0].EntryType)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// defaultFeeSchedule is the FEE_SCHEDULE entry used for trades on an exchange without its own entry.
+const defaultFeeSchedule = "DEFAULT"
+
+// FeeSchedule is the dealing charges of trades on a single exchange. Rates are fractions of the trade value, e.g. 0.005 for 0.5%.
+type FeeSchedule struct {
+	Commission     types.Decimal `json:"Commission"`     // Flat charge on every trade.
+	CommissionRate types.Decimal `json:"CommissionRate"` // Charge on every trade, on top of the flat commission.
+	FXFeeRate      types.Decimal `json:"FXFeeRate"`      // Currency conversion charge, for exchanges which don't trade in the portfolio's currency.
+	StampDutyRate  types.Decimal `json:"StampDutyRate"`  // Tax on buys only, e.g. 0.005 on UK shares.
+}
+
+// ApplyFeeSchedule fills in the fees of a trade from the fee schedule of its exchange, unless the trade already has fees.
+// The schedules are read from the FEE_SCHEDULE environment variable, a JSON object of exchange => FeeSchedule. An exchange
+// without a schedule uses the DEFAULT schedule, and with no DEFAULT schedule, trades are free.
+func ApplyFeeSchedule(trade types.NewStockTrade, side string) (types.NewStockTrade, error) {
+	if !trade.TotalFees().IsZero() || os.Getenv("FEE_SCHEDULE") == "" {
+		return trade, nil
+	}
+
+	var schedules map[string]FeeSchedule
+	if parseErr := json.Unmarshal([]byte(os.Getenv("FEE_SCHEDULE")), &schedules); parseErr != nil {
+		return trade, fmt.Errorf("invalid FEE_SCHEDULE: %v", parseErr)
+	}
+	schedule, exists := schedules[trade.Exchange]
+	if !exists {
+		schedule = schedules[defaultFeeSchedule]
+	}
+
+	value := trade.Value()
+	trade.Commission = schedule.Commission.Add(value.Mul(schedule.CommissionRate)).Round(2)
+	trade.FXFee = value.Mul(schedule.FXFeeRate).Round(2)
+	if side == database.SideBuy {
+		trade.StampDuty = value.Mul(schedule.StampDutyRate).Round(2)
+	}
+	return trade, nil
+}
+
+// ValidateFees checks that none of a trade's fees are negative.
+func ValidateFees(trade types.NewStockTrade) error {
+	fees := []struct {
+		name  string
+		value types.Decimal
+	}{{"Commission", trade.Commission}, {"FXFee", trade.FXFee}, {"StampDuty", trade.StampDuty}}
+
+	for _, fee := range fees {
+		if fee.value.Sign() < 0 {
+			return fmt.Errorf("%v can't be negative, but got: %v", fee.name, fee.value)
+		}
+	}
+	return nil
+}
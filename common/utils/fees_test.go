@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestApplyFeeSchedule checks that trades are charged the fees of their exchange, unless the trade gives its own fees.
+func TestApplyFeeSchedule(t *testing.T) {
+	const schedule = `{
+		"LSE": {"Commission": 5, "StampDutyRate": 0.005},
+		"NYSE": {"Commission": 1, "CommissionRate": 0.001, "FXFeeRate": 0.0015},
+		"DEFAULT": {"Commission": 10}
+	}`
+
+	tests := map[string]struct {
+		schedule     string
+		trade        types.NewStockTrade
+		side         string
+		expectedFees types.NewStockTrade
+		expectErr    bool
+	}{
+		"Stamp Duty On Buys": {
+			schedule, types.NewStockTrade{Exchange: "LSE", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("123.45")}, database.SideBuy,
+			types.NewStockTrade{Commission: types.MustParseDecimal("5"), StampDuty: types.MustParseDecimal("6.17")}, false,
+		},
+		"No Stamp Duty On Sells": {
+			schedule, types.NewStockTrade{Exchange: "LSE", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("123.45")}, database.SideSell,
+			types.NewStockTrade{Commission: types.MustParseDecimal("5")}, false,
+		},
+		"Commission Rate & FX Fee": {
+			schedule, types.NewStockTrade{Exchange: "NYSE", Quantity: types.MustParseDecimal("3"), Price: types.MustParseDecimal("500")}, database.SideBuy,
+			types.NewStockTrade{Commission: types.MustParseDecimal("2.5"), FXFee: types.MustParseDecimal("2.25")}, false,
+		},
+		"Default Schedule": {
+			schedule, types.NewStockTrade{Exchange: "TSE", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("100")}, database.SideBuy,
+			types.NewStockTrade{Commission: types.MustParseDecimal("10")}, false,
+		},
+		"Fees Given By Trade": {
+			schedule, types.NewStockTrade{Exchange: "LSE", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("100"), Commission: types.MustParseDecimal("1.5")}, database.SideBuy,
+			types.NewStockTrade{Commission: types.MustParseDecimal("1.5")}, false,
+		},
+		"No Schedule": {
+			"", types.NewStockTrade{Exchange: "LSE", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("100")}, database.SideBuy,
+			types.NewStockTrade{}, false,
+		},
+		"Invalid Schedule": {
+			`{"LSE": {"Commission": "five"}}`, types.NewStockTrade{Exchange: "LSE", Quantity: types.MustParseDecimal("10"), Price: types.MustParseDecimal("100")}, database.SideBuy,
+			types.NewStockTrade{}, true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("FEE_SCHEDULE", testCase.schedule)
+
+			trade, scheduleErr := ApplyFeeSchedule(testCase.trade, testCase.side)
+			assert.Equal(t, testCase.expectErr, scheduleErr != nil)
+			assert.Equal(t, testCase.expectedFees.Commission, trade.Commission)
+			assert.Equal(t, testCase.expectedFees.FXFee, trade.FXFee)
+			assert.Equal(t, testCase.expectedFees.StampDuty, trade.StampDuty)
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SaleResult is the outcome of matching a sell against a position's lots.
+type SaleResult struct {
+	LotMethod          string             `json:"LotMethod"`
+	Proceeds           types.Decimal      `json:"Proceeds"`
+	CostBasis          types.Decimal      `json:"CostBasis"`
+	RealizedPnL        types.Decimal      `json:"RealizedPnL"`
+	RemainingCostBasis types.Decimal      `json:"RemainingCostBasis"`
+	LotsSold           []database.LotSale `json:"LotsSold"`
+}
+
+// ResolveLotMethod picks the lot matching method of a sell: the trade's own method, otherwise the LOT_METHOD environment
+// variable, otherwise FIFO.
+func ResolveLotMethod(requested string) (string, error) {
+	method := requested
+	if method == "" {
+		method = os.Getenv("LOT_METHOD")
+	}
+	if method == "" {
+		method = types.LotMethodFIFO
+	}
+
+	switch method {
+	case types.LotMethodFIFO, types.LotMethodLIFO, types.LotMethodAverage:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown lot method %v. expecting one of %v, %v or %v", method, types.LotMethodFIFO, types.LotMethodLIFO, types.LotMethodAverage)
+}
+
+// AddLot records the shares of a buy, made at the acquired timestamp, as a new lot of the position. The lot's cost includes the fees.
+func AddLot(openPosition database.OpenStockPosition, newTrade types.NewStockTrade, acquired string) database.OpenStockPosition {
+	openPosition.Lots = append(openPosition.Lots, database.Lot{
+		Acquired: acquired,
+		Quantity: newTrade.Quantity,
+		Cost:     newTrade.Cost(),
+	})
+	return openPosition
+}
+
+// SellFromLots removes the shares of a sell from the position's lots, using the given lot matching method. The cost basis of the
+// sold shares is compared to the trade proceeds (after fees) to give the realized profit & loss of the sell, split by lot.
+// With the AVERAGE method, lots are still consumed oldest first (for the holding period), but every share costs the same.
+func SellFromLots(openPosition database.OpenStockPosition, newTrade types.NewStockTrade, method, soldAt string) (database.OpenStockPosition, SaleResult, error) {
+	result := SaleResult{LotMethod: method}
+	if newTrade.Quantity.Sign() <= 0 {
+		return openPosition, result, fmt.Errorf("cannot sell %v shares of %v", newTrade.Quantity, openPosition.SK)
+	}
+	if newTrade.Quantity.Cmp(openPosition.Shares) > 0 {
+		return openPosition, result, fmt.Errorf("cannot sell %v shares of %v, only %v are held", newTrade.Quantity, openPosition.SK, openPosition.Shares)
+	}
+
+	// Copy the lots, so the caller's position is never modified.
+	lots := append([]database.Lot{}, trackAllShares(openPosition).Lots...)
+
+	// Consume whole lots, then part of the final lot, in the order given by the lot method.
+	remaining := newTrade.Quantity
+	for step := 0; step < len(lots) && remaining.Sign() > 0; step++ {
+		index := step
+		if method == types.LotMethodLIFO {
+			index = len(lots) - 1 - step
+		}
+
+		lotSale := database.LotSale{Acquired: lots[index].Acquired, Quantity: lots[index].Quantity, CostBasis: lots[index].Cost}
+		if remaining.Cmp(lots[index].Quantity) < 0 {
+			lotSale.Quantity = remaining
+			lotSale.CostBasis = lots[index].Cost.Mul(remaining).Div(lots[index].Quantity, 2)
+		}
+		lots[index].Quantity = lots[index].Quantity.Sub(lotSale.Quantity)
+		lots[index].Cost = lots[index].Cost.Sub(lotSale.CostBasis)
+		remaining = remaining.Sub(lotSale.Quantity)
+
+		lotSale.HoldingPeriodDays = holdingPeriodDays(lotSale.Acquired, soldAt)
+		result.LotsSold = append(result.LotsSold, lotSale)
+	}
+
+	// With average cost, the sold shares carry an equal share of the position's total cost, and so do the shares left over.
+	if method == types.LotMethodAverage {
+		averageCost := openPosition.PurchaseValue.Mul(newTrade.Quantity).Div(openPosition.Shares, 2)
+		allocateByQuantity(averageCost, len(result.LotsSold),
+			func(i int) types.Decimal { return result.LotsSold[i].Quantity },
+			func(i int, amount types.Decimal) { result.LotsSold[i].CostBasis = amount })
+		lots = removeEmptyLots(lots)
+		allocateByQuantity(openPosition.PurchaseValue.Sub(averageCost), len(lots),
+			func(i int) types.Decimal { return lots[i].Quantity },
+			func(i int, amount types.Decimal) { lots[i].Cost = amount })
+	}
+
+	// Split the proceeds between the sold lots, and work out the gain or loss on each.
+	result.Proceeds = newTrade.Proceeds()
+	allocateByQuantity(result.Proceeds, len(result.LotsSold),
+		func(i int) types.Decimal { return result.LotsSold[i].Quantity },
+		func(i int, amount types.Decimal) { result.LotsSold[i].Proceeds = amount })
+	for index, lotSale := range result.LotsSold {
+		result.LotsSold[index].RealizedPnL = lotSale.Proceeds.Sub(lotSale.CostBasis)
+		result.CostBasis = result.CostBasis.Add(lotSale.CostBasis)
+	}
+	result.RealizedPnL = result.Proceeds.Sub(result.CostBasis)
+
+	openPosition.Lots = removeEmptyLots(lots)
+	openPosition.Shares = openPosition.Shares.Sub(newTrade.Quantity)
+	openPosition.PurchaseValue = types.Decimal{}
+	for _, lot := range openPosition.Lots {
+		openPosition.PurchaseValue = openPosition.PurchaseValue.Add(lot.Cost)
+	}
+	if openPosition.Shares.Sign() > 0 {
+		openPosition.AveragePrice = openPosition.PurchaseValue.Div(openPosition.Shares, 2)
+		openPosition = revalueShares(openPosition)
+	}
+	result.RemainingCostBasis = openPosition.PurchaseValue
+
+	return openPosition, result, nil
+}
+
+// trackAllShares adds a lot for any shares which were bought before lots were tracked. The lot holds the cost of every share
+// which isn't in another lot, and is treated as the oldest lot.
+func trackAllShares(openPosition database.OpenStockPosition) database.OpenStockPosition {
+	var lotShares, lotCost types.Decimal
+	for _, lot := range openPosition.Lots {
+		lotShares = lotShares.Add(lot.Quantity)
+		lotCost = lotCost.Add(lot.Cost)
+	}
+	if lotShares.Cmp(openPosition.Shares) >= 0 {
+		return openPosition
+	}
+
+	untrackedLot := database.Lot{
+		Quantity: openPosition.Shares.Sub(lotShares),
+		Cost:     openPosition.PurchaseValue.Sub(lotCost),
+	}
+	openPosition.Lots = append([]database.Lot{untrackedLot}, openPosition.Lots...)
+	return openPosition
+}
+
+// allocateByQuantity splits an amount between items in proportion to their quantities. Each part is rounded to the penny, and the
+// final item takes any remainder, so the parts always add up to the amount.
+func allocateByQuantity(amount types.Decimal, count int, quantity func(int) types.Decimal, assign func(int, types.Decimal)) {
+	var totalQuantity types.Decimal
+	for i := 0; i < count; i++ {
+		totalQuantity = totalQuantity.Add(quantity(i))
+	}
+
+	var allocated types.Decimal
+	for i := 0; i < count; i++ {
+		part := amount.Sub(allocated)
+		if i < count-1 {
+			part = amount.Mul(quantity(i)).Div(totalQuantity, 2)
+		}
+		assign(i, part)
+		allocated = allocated.Add(part)
+	}
+}
+
+// removeEmptyLots drops every lot which has had all of its shares sold.
+func removeEmptyLots(lots []database.Lot) []database.Lot {
+	var remainingLots []database.Lot
+	for _, lot := range lots {
+		if lot.Quantity.Sign() > 0 {
+			remainingLots = append(remainingLots, lot)
+		}
+	}
+	return remainingLots
+}
+
+// holdingPeriodDays counts the whole days between buying & selling a lot. Lots with an unknown buy time have a holding period of 0.
+func holdingPeriodDays(acquired, soldAt string) int {
+	buyTime, buyErr := time.Parse(time.RFC3339Nano, acquired)
+	sellTime, sellErr := time.Parse(time.RFC3339Nano, soldAt)
+	if buyErr != nil || sellErr != nil {
+		return 0
+	}
+	return int(sellTime.Sub(buyTime).Hours() / 24)
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSellFromLots checks the cost basis, realized P&L & holding period of sells matched against lots by each lot method.
+func TestSellFromLots(t *testing.T) {
+	const (
+		januaryBuy = "2022-01-04T15:00:00.000000000Z"
+		marchBuy   = "2022-03-01T15:00:00.000000000Z"
+		aprilSell  = "2022-04-13T15:00:00.000000000Z"
+	)
+	position := database.OpenStockPosition{
+		SK: "AAPL", PurchaseValue: types.MustParseDecimal("450"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("3"),
+		Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("250")}},
+	}
+
+	tests := map[string]struct {
+		position          database.OpenStockPosition
+		quantity          string
+		method            string
+		expectedSale      SaleResult
+		expectedRemaining database.OpenStockPosition
+	}{
+		"FIFO Across Lots": {
+			position, "2", types.LotMethodFIFO,
+			SaleResult{LotMethod: "FIFO", Proceeds: types.MustParseDecimal("360"), CostBasis: types.MustParseDecimal("200"), RealizedPnL: types.MustParseDecimal("160"), RemainingCostBasis: types.MustParseDecimal("250"),
+				LotsSold: []database.LotSale{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("2"), CostBasis: types.MustParseDecimal("200"), Proceeds: types.MustParseDecimal("360"), RealizedPnL: types.MustParseDecimal("160"), HoldingPeriodDays: 99}}},
+			database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("250"), AveragePrice: types.MustParseDecimal("250"), Shares: types.MustParseDecimal("1"),
+				Lots: []database.Lot{{Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("250")}}},
+		},
+		"LIFO Across Lots": {
+			position, "2", types.LotMethodLIFO,
+			SaleResult{LotMethod: "LIFO", Proceeds: types.MustParseDecimal("360"), CostBasis: types.MustParseDecimal("350"), RealizedPnL: types.MustParseDecimal("10"), RemainingCostBasis: types.MustParseDecimal("100"),
+				LotsSold: []database.LotSale{
+					{Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), CostBasis: types.MustParseDecimal("250"), Proceeds: types.MustParseDecimal("180"), RealizedPnL: types.MustParseDecimal("-70"), HoldingPeriodDays: 43},
+					{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1"), CostBasis: types.MustParseDecimal("100"), Proceeds: types.MustParseDecimal("180"), RealizedPnL: types.MustParseDecimal("80"), HoldingPeriodDays: 99},
+				}},
+			database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("100"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("1"),
+				Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("100")}}},
+		},
+		"Average Cost": {
+			position, "2", types.LotMethodAverage,
+			SaleResult{LotMethod: "AVERAGE", Proceeds: types.MustParseDecimal("360"), CostBasis: types.MustParseDecimal("300"), RealizedPnL: types.MustParseDecimal("60"), RemainingCostBasis: types.MustParseDecimal("150"),
+				LotsSold: []database.LotSale{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("2"), CostBasis: types.MustParseDecimal("300"), Proceeds: types.MustParseDecimal("360"), RealizedPnL: types.MustParseDecimal("60"), HoldingPeriodDays: 99}}},
+			database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("150"), AveragePrice: types.MustParseDecimal("150"), Shares: types.MustParseDecimal("1"),
+				Lots: []database.Lot{{Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("150")}}},
+		},
+		"Fractional Shares": {
+			position, "0.5", types.LotMethodFIFO,
+			SaleResult{LotMethod: "FIFO", Proceeds: types.MustParseDecimal("90"), CostBasis: types.MustParseDecimal("50"), RealizedPnL: types.MustParseDecimal("40"), RemainingCostBasis: types.MustParseDecimal("400"),
+				LotsSold: []database.LotSale{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("0.5"), CostBasis: types.MustParseDecimal("50"), Proceeds: types.MustParseDecimal("90"), RealizedPnL: types.MustParseDecimal("40"), HoldingPeriodDays: 99}}},
+			database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("400"), AveragePrice: types.MustParseDecimal("160"), Shares: types.MustParseDecimal("2.5"),
+				Lots: []database.Lot{{Acquired: januaryBuy, Quantity: types.MustParseDecimal("1.5"), Cost: types.MustParseDecimal("150")}, {Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("250")}}},
+		},
+		"Shares Bought Before Lots": {
+			database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("300"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("3"),
+				Lots: []database.Lot{{Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("120")}}},
+			"2", types.LotMethodFIFO,
+			SaleResult{LotMethod: "FIFO", Proceeds: types.MustParseDecimal("360"), CostBasis: types.MustParseDecimal("180"), RealizedPnL: types.MustParseDecimal("180"), RemainingCostBasis: types.MustParseDecimal("120"),
+				LotsSold: []database.LotSale{{Quantity: types.MustParseDecimal("2"), CostBasis: types.MustParseDecimal("180"), Proceeds: types.MustParseDecimal("360"), RealizedPnL: types.MustParseDecimal("180")}}},
+			database.OpenStockPosition{SK: "AAPL", PurchaseValue: types.MustParseDecimal("120"), AveragePrice: types.MustParseDecimal("120"), Shares: types.MustParseDecimal("1"),
+				Lots: []database.Lot{{Acquired: marchBuy, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("120")}}},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			trade := types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal(testCase.quantity), Price: types.MustParseDecimal("180")}
+			remaining, sale, sellErr := SellFromLots(testCase.position, trade, testCase.method, aprilSell)
+			assert.NoError(t, sellErr)
+			assert.Equal(t, testCase.expectedSale, sale)
+			assert.Equal(t, testCase.expectedRemaining, remaining)
+		})
+	}
+
+	_, _, oversellErr := SellFromLots(position, types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("4"), Price: types.MustParseDecimal("180")}, types.LotMethodFIFO, aprilSell)
+	assert.Error(t, oversellErr)
+}
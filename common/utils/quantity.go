@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"Investing-API/common/types"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// defaultQuantityPrecision is how many decimal places a share quantity can have when QUANTITY_PRECISION isn't set.
+const defaultQuantityPrecision = 8
+
+// QuantityPrecision returns how many decimal places a share quantity can have: the QUANTITY_PRECISION environment variable,
+// otherwise 8.
+func QuantityPrecision() int32 {
+	precision, parseErr := strconv.ParseInt(os.Getenv("QUANTITY_PRECISION"), 10, 32)
+	if parseErr != nil || precision < 0 {
+		return defaultQuantityPrecision
+	}
+	return int32(precision)
+}
+
+// ValidateQuantity checks that the share quantity of a trade is more than 0, and has no more decimal places than the
+// QuantityPrecision allows.
+func ValidateQuantity(quantity types.Decimal) error {
+	if quantity.Sign() <= 0 {
+		return fmt.Errorf("quantity must be more than 0, but got: %v", quantity)
+	}
+	if precision := QuantityPrecision(); quantity.Places() > precision {
+		return fmt.Errorf("quantity %v has more than %v decimal places", quantity, precision)
+	}
+	return nil
+}
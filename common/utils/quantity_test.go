@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestValidateQuantity checks that fractional quantities are accepted, up to the configured number of decimal places.
+func TestValidateQuantity(t *testing.T) {
+	tests := map[string]struct {
+		precision string
+		quantity  string
+		expectErr bool
+	}{
+		"Whole Shares":             {"", "10", false},
+		"Default Precision":        {"", "0.12345678", false},
+		"Beyond Default Precision": {"", "0.123456789", true},
+		"Configured Precision":     {"2", "1.25", false},
+		"Beyond Configured":        {"2", "1.255", true},
+		"Trailing Zeros":           {"2", "1.2500", false},
+		"Whole Shares Only":        {"0", "1.5", true},
+		"Invalid Precision":        {"eight", "0.12345678", false},
+		"Zero":                     {"", "0", true},
+		"Negative":                 {"", "-1", true},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("QUANTITY_PRECISION", testCase.precision)
+			assert.Equal(t, testCase.expectErr, ValidateQuantity(types.MustParseDecimal(testCase.quantity)) != nil)
+		})
+	}
+}
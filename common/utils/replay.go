@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"fmt"
+	"sort"
+)
+
+// PositionDrift is a difference between a stored portfolio position, and the same position rebuilt from the ledger.
+type PositionDrift struct {
+	Symbol  string        `json:"Symbol"`
+	Field   string        `json:"Field"`
+	Stored  types.Decimal `json:"Stored"`
+	Rebuilt types.Decimal `json:"Rebuilt"`
+}
+
+// ReplayLedger rebuilds every portfolio position, including CASH, by applying the ledger's trades, splits, dividends & cash flows
+// in time order to an opening cash balance. Trades are applied with the same logic as the BuyPosition & SellPosition Lambdas, and
+// splits as RevaluePortfolio applies them, so a consistent ledger rebuilds the stored portfolio. Reinvested dividends are recorded
+// as trades.
+func ReplayLedger(entries []database.LedgerEntry, openingCash types.Decimal) ([]database.OpenStockPosition, error) {
+	// Ledger entries of different types sort separately, so put every entry back into time order.
+	sortedEntries := append([]database.LedgerEntry{}, entries...)
+	sort.SliceStable(sortedEntries, func(i, j int) bool {
+		return sortedEntries[i].Timestamp < sortedEntries[j].Timestamp
+	})
+
+	cash := database.OpenStockPosition{SK: "CASH", PurchaseValue: openingCash, CurrentValue: openingCash}
+	positions := make(map[string]database.OpenStockPosition)
+
+	for _, entry := range sortedEntries {
+		if entry.EntryType == database.EntryTypeCorporateAction && entry.Action == types.ActionSplit {
+			if position, exists := positions[entry.Symbol]; exists {
+				split := types.CorporateAction{Symbol: entry.Symbol, Date: entry.ExDate, Type: entry.Action, Ratio: entry.Ratio}
+				positions[entry.Symbol] = ApplySplit(position, split)
+			}
+			continue
+		}
+		// Dividends & deposits are credited to CASH, and withdrawals have a negative amount.
+		if entry.EntryType == database.EntryTypeDividend || entry.EntryType == database.EntryTypeCashFlow {
+			cash.PurchaseValue = cash.PurchaseValue.Add(entry.Amount)
+			continue
+		}
+		if entry.EntryType != database.EntryTypeTrade {
+			continue
+		}
+
+		// The ledger only records the total fees of a trade, which is all that affects the portfolio.
+		trade := types.NewStockTrade{Symbol: entry.Symbol, Quantity: entry.Quantity, Price: entry.Price, Commission: entry.Fees}
+		position, exists := positions[trade.Symbol]
+
+		switch entry.Side {
+		case database.SideBuy:
+			if exists {
+				positions[trade.Symbol] = AddLot(CombinePositions(position, trade), trade, entry.Timestamp)
+			} else {
+				positions[trade.Symbol] = AddLot(NewPosition(trade), trade, entry.Timestamp)
+			}
+			cash.PurchaseValue = cash.PurchaseValue.Sub(trade.Cost())
+
+		case database.SideSell:
+			if !exists {
+				return nil, fmt.Errorf("ledger entry %v sells %v shares of %v, but none are held", entry.SK, trade.Quantity, trade.Symbol)
+			}
+			// Sells recorded before lots were tracked have no lot method. FIFO is the default method of the SellPosition Lambda.
+			lotMethod := entry.LotMethod
+			if lotMethod == "" {
+				lotMethod = types.LotMethodFIFO
+			}
+			remainingPosition, _, sellErr := SellFromLots(position, trade, lotMethod, entry.Timestamp)
+			if sellErr != nil {
+				return nil, fmt.Errorf("ledger entry %v: %v", entry.SK, sellErr)
+			}
+			if remainingPosition.Shares.IsZero() {
+				delete(positions, trade.Symbol)
+			} else {
+				positions[trade.Symbol] = remainingPosition
+			}
+			cash.PurchaseValue = cash.PurchaseValue.Add(trade.Proceeds())
+
+		default:
+			return nil, fmt.Errorf("ledger entry %v has unknown side %v", entry.SK, entry.Side)
+		}
+	}
+	cash.CurrentValue = cash.PurchaseValue
+
+	// Order the rebuilt positions by symbol, the same as the stored positions are returned.
+	rebuilt := []database.OpenStockPosition{cash}
+	for _, position := range positions {
+		rebuilt = append(rebuilt, position)
+	}
+	sort.Slice(rebuilt, func(i, j int) bool {
+		return rebuilt[i].SK < rebuilt[j].SK
+	})
+
+	return CalculateMarketRatio(rebuilt), nil
+}
+
+// ComparePositions reports every difference in shares, purchase value & average price between the stored and the rebuilt positions.
+// A position which only exists on one side is compared against an empty position.
+func ComparePositions(stored, rebuilt []database.OpenStockPosition) []PositionDrift {
+	storedLookup := make(map[string]database.OpenStockPosition)
+	rebuiltLookup := make(map[string]database.OpenStockPosition)
+	var symbols []string
+	for _, position := range stored {
+		storedLookup[position.SK] = position
+		symbols = append(symbols, position.SK)
+	}
+	for _, position := range rebuilt {
+		rebuiltLookup[position.SK] = position
+		if _, exists := storedLookup[position.SK]; !exists {
+			symbols = append(symbols, position.SK)
+		}
+	}
+	sort.Strings(symbols)
+
+	var drift []PositionDrift
+	for _, symbol := range symbols {
+		storedPosition, rebuiltPosition := storedLookup[symbol], rebuiltLookup[symbol]
+		fields := []PositionDrift{
+			{symbol, "Shares", storedPosition.Shares, rebuiltPosition.Shares},
+			{symbol, "PurchaseValue", storedPosition.PurchaseValue, rebuiltPosition.PurchaseValue},
+			{symbol, "AveragePrice", storedPosition.AveragePrice, rebuiltPosition.AveragePrice},
+		}
+		for _, field := range fields {
+			if field.Stored.Round(2).Cmp(field.Rebuilt.Round(2)) != 0 {
+				drift = append(drift, field)
+			}
+		}
+	}
+
+	return drift
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReplayLedger checks that replaying trades rebuilds the same positions the Buy & Sell Lambdas store.
+func TestReplayLedger(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2022, 3, d, 12, 0, 0, 0, time.UTC) }
+	aaplBuy := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("100")}, database.SideBuy, "", day(1))
+	aaplTopUp := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("150")}, database.SideBuy, "", day(2))
+	aaplSell := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("200")}, database.SideSell, "", day(3))
+	tslaBuy := database.NewTradeEntry(types.NewStockTrade{Symbol: "TSLA", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("300")}, database.SideBuy, "", day(4))
+	tslaSell := database.NewTradeEntry(types.NewStockTrade{Symbol: "TSLA", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("250")}, database.SideSell, "", day(5))
+	feeBuy := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("2"), Price: types.MustParseDecimal("100"), Commission: types.MustParseDecimal("4")}, database.SideBuy, "", day(1))
+	feeSell := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("1"), Price: types.MustParseDecimal("150"), Commission: types.MustParseDecimal("2")}, database.SideSell, "", day(2))
+	aaplSplit := database.NewCorporateActionEntry(types.CorporateAction{Symbol: "AAPL", Date: "2022-03-03", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")}, types.MustParseDecimal("16"), day(3))
+	aaplSplitSell := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("6"), Price: types.MustParseDecimal("50")}, database.SideSell, "", day(4))
+	exDateBuy := database.NewTradeEntry(types.NewStockTrade{Symbol: "AAPL", Quantity: types.MustParseDecimal("5"), Price: types.MustParseDecimal("40")}, database.SideBuy, "", time.Date(2022, 3, 3, 15, 0, 0, 0, time.UTC))
+	nightlySplit := database.NewCorporateActionEntry(types.CorporateAction{Symbol: "AAPL", Date: "2022-03-03", Type: types.ActionSplit, Ratio: types.MustParseDecimal("4")}, types.MustParseDecimal("13"), day(4))
+	deposit := database.NewCashFlowEntry(types.NewCashFlow{Amount: types.MustParseDecimal("500")}, database.SideDeposit, "", day(1))
+	withdrawal := database.NewCashFlowEntry(types.NewCashFlow{Amount: types.MustParseDecimal("300")}, database.SideWithdrawal, "", day(2))
+	aaplDividend := database.NewDividendEntry(types.NewDividend{Symbol: "AAPL", ExDate: "2022-03-02", AmountPerShare: types.MustParseDecimal("2.5"), Quantity: types.MustParseDecimal("2"), WithholdingTax: types.MustParseDecimal("0.75")}, "", day(3))
+
+	tests := map[string]struct {
+		entries           []database.LedgerEntry
+		expectErr         bool
+		expectedPositions []database.OpenStockPosition
+	}{
+		"Empty Ledger": {
+			nil,
+			false,
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Buys & Sells Out Of Order": {
+			[]database.LedgerEntry{tslaSell, aaplSell, aaplBuy, tslaBuy, aaplTopUp},
+			false,
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("400"), PortfolioPercentage: types.MustParseDecimal("0.381"), AveragePrice: types.MustParseDecimal("133.33"), Shares: types.MustParseDecimal("3"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: aaplBuy.Timestamp, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("100")}, {Acquired: aaplTopUp.Timestamp, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("300")}}},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("650"), CurrentValue: types.MustParseDecimal("650"), PortfolioPercentage: types.MustParseDecimal("0.619")},
+			},
+		},
+		"Trades With Fees": {
+			[]database.LedgerEntry{feeBuy, feeSell},
+			false,
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("102"), PortfolioPercentage: types.MustParseDecimal("0.0975"), AveragePrice: types.MustParseDecimal("102"), Shares: types.MustParseDecimal("1"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: feeBuy.Timestamp, Quantity: types.MustParseDecimal("1"), Cost: types.MustParseDecimal("102")}}},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("944"), CurrentValue: types.MustParseDecimal("944"), PortfolioPercentage: types.MustParseDecimal("0.9025")},
+			},
+		},
+		"Split Before Sell": {
+			[]database.LedgerEntry{aaplBuy, aaplTopUp, aaplSplitSell, aaplSplit},
+			false,
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("350"), PortfolioPercentage: types.MustParseDecimal("0.3043"), AveragePrice: types.MustParseDecimal("35"), Shares: types.MustParseDecimal("10"), CurrentStockPrice: types.MustParseDecimal("25"),
+					Lots: []database.Lot{{Acquired: aaplBuy.Timestamp, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("50")}, {Acquired: aaplTopUp.Timestamp, Quantity: types.MustParseDecimal("8"), Cost: types.MustParseDecimal("300")}}},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("800"), CurrentValue: types.MustParseDecimal("800"), PortfolioPercentage: types.MustParseDecimal("0.6957")},
+			},
+		},
+		"Dividend Credits Cash": {
+			[]database.LedgerEntry{aaplDividend, aaplBuy},
+			false,
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.1992"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("2"), CurrentStockPrice: types.MustParseDecimal("100"),
+					Lots: []database.Lot{{Acquired: aaplBuy.Timestamp, Quantity: types.MustParseDecimal("2"), Cost: types.MustParseDecimal("200")}}},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("804.25"), CurrentValue: types.MustParseDecimal("804.25"), PortfolioPercentage: types.MustParseDecimal("0.8008")},
+			},
+		},
+		"Buy On Ex-Date": {
+			[]database.LedgerEntry{aaplBuy, exDateBuy, nightlySplit},
+			false,
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("400"), PortfolioPercentage: types.MustParseDecimal("0.4"), AveragePrice: types.MustParseDecimal("30.77"), Shares: types.MustParseDecimal("13"), CurrentStockPrice: types.MustParseDecimal("25"),
+					Lots: []database.Lot{{Acquired: aaplBuy.Timestamp, Quantity: types.MustParseDecimal("8"), Cost: types.MustParseDecimal("200")}, {Acquired: exDateBuy.Timestamp, Quantity: types.MustParseDecimal("5"), Cost: types.MustParseDecimal("200")}}},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), CurrentValue: types.MustParseDecimal("600"), PortfolioPercentage: types.MustParseDecimal("0.6")},
+			},
+		},
+		"Deposit & Withdrawal": {
+			[]database.LedgerEntry{withdrawal, deposit},
+			false,
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1200"), CurrentValue: types.MustParseDecimal("1200"), PortfolioPercentage: types.MustParseDecimal("1")},
+			},
+		},
+		"Sell Before Buy": {
+			[]database.LedgerEntry{tslaSell},
+			true,
+			nil,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			rebuilt, replayErr := ReplayLedger(testCase.entries, types.MustParseDecimal("1000"))
+			assert.Equal(t, testCase.expectErr, replayErr != nil)
+			assert.Equal(t, testCase.expectedPositions, rebuilt)
+		})
+	}
+}
+
+// TestComparePositions checks that every field which differs between the stored & rebuilt portfolio is reported.
+func TestComparePositions(t *testing.T) {
+	stored := []database.OpenStockPosition{
+		{SK: "AAPL", PurchaseValue: types.MustParseDecimal("300"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("3")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("700")},
+		{SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), AveragePrice: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1")},
+	}
+	rebuilt := []database.OpenStockPosition{
+		{SK: "AAPL", PurchaseValue: types.MustParseDecimal("300"), AveragePrice: types.MustParseDecimal("100"), Shares: types.MustParseDecimal("3")},
+		{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000")},
+	}
+
+	assert.Empty(t, ComparePositions(stored, stored))
+	assert.Equal(t, []PositionDrift{
+		{"CASH", "PurchaseValue", types.MustParseDecimal("700"), types.MustParseDecimal("1000")},
+		{"TSLA", "Shares", types.MustParseDecimal("1"), types.MustParseDecimal("0")},
+		{"TSLA", "PurchaseValue", types.MustParseDecimal("300"), types.MustParseDecimal("0")},
+		{"TSLA", "AveragePrice", types.MustParseDecimal("300"), types.MustParseDecimal("0")},
+	}, ComparePositions(stored, rebuilt))
+}
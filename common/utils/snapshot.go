@@ -0,0 +1,29 @@
+package utils
+
+import "Investing-API/common/database"
+
+// BuildSnapshot records the value of the portfolio on a date, from positions which have been marked to market. The positions'
+// portfolio percentages are recalculated by market value, the same as CalculateMarketRatio.
+func BuildSnapshot(openPositions []database.OpenStockPosition, date string) database.PortfolioSnapshot {
+	snapshot := database.NewSnapshot(date)
+	snapshot.Positions = []database.SnapshotPosition{}
+
+	for _, position := range CalculateMarketRatio(openPositions) {
+		value := MarketValue(position)
+		snapshot.TotalValue = snapshot.TotalValue.Add(value)
+		if position.SK == "CASH" {
+			snapshot.Cash = snapshot.Cash.Add(value)
+			continue
+		}
+
+		snapshot.CostBasis = snapshot.CostBasis.Add(position.PurchaseValue)
+		snapshot.Positions = append(snapshot.Positions, database.SnapshotPosition{
+			Symbol:              position.SK,
+			Shares:              position.Shares,
+			Value:               value,
+			PortfolioPercentage: position.PortfolioPercentage,
+		})
+	}
+
+	return snapshot
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"Investing-API/common/database"
+	"Investing-API/common/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBuildSnapshot checks that a snapshot totals the portfolio by market value, and keeps cash apart from the stock positions.
+func TestBuildSnapshot(t *testing.T) {
+	tests := map[string]struct {
+		openPositions    []database.OpenStockPosition
+		expectedSnapshot database.PortfolioSnapshot
+	}{
+		"Revalued Positions": {
+			[]database.OpenStockPosition{
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("2")},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), Shares: types.MustParseDecimal("1.5")},
+			},
+			database.PortfolioSnapshot{
+				PK: "SNAPSHOT", SK: "SNAPSHOT#2022-04-11", Date: "2022-04-11",
+				TotalValue: types.MustParseDecimal("1100"), Cash: types.MustParseDecimal("500"), CostBasis: types.MustParseDecimal("500"),
+				Positions: []database.SnapshotPosition{
+					{Symbol: "AAPL", Shares: types.MustParseDecimal("2"), Value: types.MustParseDecimal("300"), PortfolioPercentage: types.MustParseDecimal("0.2727")},
+					{Symbol: "TSLA", Shares: types.MustParseDecimal("1.5"), Value: types.MustParseDecimal("300"), PortfolioPercentage: types.MustParseDecimal("0.2727")},
+				},
+			},
+		},
+		"Only Cash": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), CurrentValue: types.MustParseDecimal("1000")},
+			},
+			database.PortfolioSnapshot{
+				PK: "SNAPSHOT", SK: "SNAPSHOT#2022-04-11", Date: "2022-04-11",
+				TotalValue: types.MustParseDecimal("1000"), Cash: types.MustParseDecimal("1000"), Positions: []database.SnapshotPosition{},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			snapshot := BuildSnapshot(testCase.openPositions, "2022-04-11")
+			assert.Equal(t, testCase.expectedSnapshot, snapshot)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"Investing-API/common/calendar"
 	"Investing-API/common/database"
 	"Investing-API/common/types"
 	"math"
@@ -10,9 +11,16 @@ import (
 var dateTimeFormat = "2006-01-02"
 
 // CanRun checks whether the code can run on the given day.
-// API Data is only updated at midnight on each weekday, so the code should run on each following day.
-func CanRun(dayOfWeek time.Weekday) bool {
-	return 2 <= int(dayOfWeek) && int(dayOfWeek) <= 6
+// API Data is only updated at midnight after each trading day, so the code should run on each day following one. A day is a
+// trading day if either the New York or London exchange was open, as the portfolio can hold symbols listed on both.
+func CanRun(today time.Time) bool {
+	yesterday := today.AddDate(0, 0, -1)
+	for _, exchange := range []string{calendar.NYSE, calendar.LSE} {
+		if tradingCalendar, _ := calendar.For(exchange); tradingCalendar.IsTradingDay(yesterday) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetYesterdaysDate returns the formatted date of the previous day.
@@ -20,11 +28,11 @@ func GetYesterdaysDate(today time.Time) string {
 	return today.AddDate(0, 0, -1).Format(dateTimeFormat)
 }
 
-// RoundToPrecision takes a float value and rounds-up to the given precision.
+// RoundToPrecision takes a float value and rounds it, half away from zero, to the given precision.
+// Money is held in types.Decimal, which rounds exactly. This is for float statistics, such as returns.
 func RoundToPrecision(input float64, precision uint) float64 {
 	output := math.Pow(10, float64(precision))
-	rounded := int(input*output + math.Copysign(0.5, input*output))
-	return float64(rounded) / output
+	return math.Round(input*output) / output
 }
 
 // RemovePositionFromPortfolio removes the given index from a slice of portfolio positions.
@@ -35,22 +43,81 @@ func RemovePositionFromPortfolio(positions []database.OpenStockPosition, index u
 	return append(positions[:index], positions[index+1:]...)
 }
 
+// NewPosition creates the portfolio position of a trade in a symbol which isn't held yet. The trade's fees are part of its cost.
+func NewPosition(newTrade types.NewStockTrade) database.OpenStockPosition {
+	return database.OpenStockPosition{
+		SK:                newTrade.Symbol,
+		PurchaseValue:     newTrade.Cost(),
+		AveragePrice:      newTrade.Cost().Div(newTrade.Quantity, 2),
+		Shares:            newTrade.Quantity,
+		CurrentStockPrice: newTrade.Price.Round(2),
+	}
+}
+
 // CombinePositions adds the data of an incoming trade to an existing position. (New Average price, total value, shares quantity...)
+// The trade's fees are part of its cost.
 func CombinePositions(openPosition database.OpenStockPosition, newTrade types.NewStockTrade) database.OpenStockPosition {
-	openPosition.Shares = openPosition.Shares + newTrade.Quantity
-	openPosition.PurchaseValue = RoundToPrecision(openPosition.PurchaseValue+(newTrade.Price*float64(newTrade.Quantity)), 2)
-	openPosition.AveragePrice = RoundToPrecision(openPosition.PurchaseValue/float64(openPosition.Shares), 2)
+	openPosition.Shares = openPosition.Shares.Add(newTrade.Quantity)
+	openPosition.PurchaseValue = openPosition.PurchaseValue.Add(newTrade.Cost())
+	openPosition.AveragePrice = openPosition.PurchaseValue.Div(openPosition.Shares, 2)
 	return openPosition
 }
 
+// MarkToMarket values a position at the given share price, and works out its return on the purchase value.
+func MarkToMarket(openPosition database.OpenStockPosition, price types.Decimal) database.OpenStockPosition {
+	openPosition.CurrentStockPrice = price
+	openPosition.CurrentValue = price.Mul(openPosition.Shares).Round(2)
+	if !openPosition.PurchaseValue.IsZero() {
+		openPosition.PercentageReturn = openPosition.CurrentValue.Sub(openPosition.PurchaseValue).Div(openPosition.PurchaseValue, 4)
+	}
+	return openPosition
+}
+
+// revalueShares values a position's current number of shares at its last share price, once it has been marked to market, so a
+// trade doesn't leave its market value out of date until the next revaluation.
+func revalueShares(openPosition database.OpenStockPosition) database.OpenStockPosition {
+	if openPosition.CurrentValue.IsZero() {
+		return openPosition
+	}
+	return MarkToMarket(openPosition, openPosition.CurrentStockPrice)
+}
+
 // CalculatePortfolioRatio takes a list of open stock positions and calculates the ratio each one takes up in the portfolio.
 func CalculatePortfolioRatio(records []database.OpenStockPosition) []database.OpenStockPosition {
-	var totalPortfolioValue float64
+	var totalPortfolioValue types.Decimal
 	for _, record := range records {
-		totalPortfolioValue += record.PurchaseValue
+		totalPortfolioValue = totalPortfolioValue.Add(record.PurchaseValue)
+	}
+	// An empty portfolio has no ratios to calculate.
+	if totalPortfolioValue.IsZero() {
+		return records
 	}
 	for index, record := range records {
-		records[index].PortfolioPercentage = RoundToPrecision(record.PurchaseValue/totalPortfolioValue, 4)
+		records[index].PortfolioPercentage = record.PurchaseValue.Div(totalPortfolioValue, 4)
 	}
 	return records
 }
+
+// CalculateMarketRatio calculates the ratio each open position takes up in the portfolio by market value.
+func CalculateMarketRatio(records []database.OpenStockPosition) []database.OpenStockPosition {
+	var totalMarketValue types.Decimal
+	for _, record := range records {
+		totalMarketValue = totalMarketValue.Add(MarketValue(record))
+	}
+	// An empty portfolio has no ratios to calculate.
+	if totalMarketValue.IsZero() {
+		return records
+	}
+	for index := range records {
+		records[index].PortfolioPercentage = MarketValue(records[index]).Div(totalMarketValue, 4)
+	}
+	return records
+}
+
+// MarketValue returns the current value of a position. A position which has never been valued counts at its purchase value.
+func MarketValue(openPosition database.OpenStockPosition) types.Decimal {
+	if openPosition.CurrentValue.IsZero() {
+		return openPosition.PurchaseValue
+	}
+	return openPosition.CurrentValue
+}
@@ -10,35 +10,30 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// TestCanRun checks that only days following a weekday can run the code.
+// TestCanRun checks that only days following a trading day can run the code.
 // This is because the API data has an update delay of 24 hours.
 func TestCanRun(t *testing.T) {
-	var (
-		Monday    = time.Weekday(1) // Shouldn't be able to run - stock market closed on Sundays
-		Tuesday   = time.Weekday(2) // Should be able to run - stock market open on Mondays
-		Wednesday = time.Weekday(3) // Should be able to run - stock market open on Tuesdays
-		Thursday  = time.Weekday(4) // Should be able to run - stock market open on Wednesdays
-		Friday    = time.Weekday(5) // Should be able to run - stock market open on Thursdays
-		Saturday  = time.Weekday(6) // Should be able to run - stock market open on Fridays
-		Sunday    = time.Weekday(0) // Shouldn't be able to run - stock market closed on Saturday
-	)
-
 	tests := map[string]struct {
-		weekday time.Weekday
-		canRun  bool
+		today  string
+		canRun bool
 	}{
-		"Run on Monday":    {Monday, false},
-		"Run on Tuesday":   {Tuesday, true},
-		"Run on Wednesday": {Wednesday, true},
-		"Run on Thursday":  {Thursday, true},
-		"Run on Friday":    {Friday, true},
-		"Run on Saturday":  {Saturday, true},
-		"Run on Sunday":    {Sunday, false},
+		"Run on Monday":              {"2022-04-04", false}, // Stock market closed on Sundays
+		"Run on Tuesday":             {"2022-04-05", true},  // Stock market open on Mondays
+		"Run on Wednesday":           {"2022-04-06", true},
+		"Run on Thursday":            {"2022-04-07", true},
+		"Run on Friday":              {"2022-04-08", true},
+		"Run on Saturday":            {"2022-04-09", true},  // Stock market open on Fridays
+		"Run on Sunday":              {"2022-04-10", false}, // Stock market closed on Saturdays
+		"Run after Good Friday":      {"2022-04-16", false}, // Both New York & London closed
+		"Run after Easter Monday":    {"2022-04-19", true},  // Only London closed
+		"Run after Thanksgiving":     {"2022-11-25", true},  // Only New York closed
+		"Run after Christmas Monday": {"2022-12-27", false}, // Both closed for Christmas Day, observed
 	}
 
 	for name, testCase := range tests {
 		t.Run(name, func(t *testing.T) {
-			check := CanRun(testCase.weekday)
+			today, _ := time.Parse(dateTimeFormat, testCase.today)
+			check := CanRun(today)
 			assert.Equal(t, testCase.canRun, check)
 		})
 	}
@@ -82,27 +77,27 @@ func TestCombinePositions(t *testing.T) {
 			database.OpenStockPosition{
 				PK:                  "OPEN-POSITION",
 				SK:                  "AAPL",
-				PurchaseValue:       150.00,
-				PortfolioPercentage: 1.0000,
-				AveragePrice:        150.00,
-				PercentageReturn:    0.1000,
-				Shares:              1,
-				CurrentStockPrice:   150.00,
+				PurchaseValue:       types.MustParseDecimal("150.00"),
+				PortfolioPercentage: types.MustParseDecimal("1.0000"),
+				AveragePrice:        types.MustParseDecimal("150.00"),
+				PercentageReturn:    types.MustParseDecimal("0.1000"),
+				Shares:              types.MustParseDecimal("1"),
+				CurrentStockPrice:   types.MustParseDecimal("150.00"),
 			},
 			types.NewStockTrade{
 				Symbol:   "AAPL",
-				Quantity: 1,
-				Price:    200.00,
+				Quantity: types.MustParseDecimal("1"),
+				Price:    types.MustParseDecimal("200.00"),
 			},
 			database.OpenStockPosition{
 				PK:                  "OPEN-POSITION",
 				SK:                  "AAPL",
-				PurchaseValue:       350.00,
-				PortfolioPercentage: 1.0000,
-				AveragePrice:        175.00,
-				PercentageReturn:    0.1000,
-				Shares:              2,
-				CurrentStockPrice:   150.00,
+				PurchaseValue:       types.MustParseDecimal("350.00"),
+				PortfolioPercentage: types.MustParseDecimal("1.0000"),
+				AveragePrice:        types.MustParseDecimal("175.00"),
+				PercentageReturn:    types.MustParseDecimal("0.1000"),
+				Shares:              types.MustParseDecimal("2"),
+				CurrentStockPrice:   types.MustParseDecimal("150.00"),
 			},
 		},
 	}
@@ -126,6 +121,7 @@ func TestRoundToPrecision(t *testing.T) {
 		"High Precision":   {100, 5, 100.00000},
 		"Round Up Decimal": {52.389123, 2, 52.39},
 		"Round Up":         {52.9999, 1, 53.0},
+		"Beyond int64":     {1e20 + 0.004, 2, 1e20},
 	}
 
 	for name, testCase := range tests {
@@ -144,26 +140,26 @@ func TestCalculatePortfolioRatio(t *testing.T) {
 	}{
 		"Test 1": {
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 1000},
-				{SK: "AAPL", PurchaseValue: 100},
-				{SK: "TSLA", PurchaseValue: 100},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("100")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("100")},
 			},
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 1000, PortfolioPercentage: 0.8333},
-				{SK: "AAPL", PurchaseValue: 100, PortfolioPercentage: 0.0833},
-				{SK: "TSLA", PurchaseValue: 100, PortfolioPercentage: 0.0833},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("1000"), PortfolioPercentage: types.MustParseDecimal("0.8333")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("100"), PortfolioPercentage: types.MustParseDecimal("0.0833")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("100"), PortfolioPercentage: types.MustParseDecimal("0.0833")},
 			},
 		},
 		"Test 2": {
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 8612311.44},
-				{SK: "AAPL", PurchaseValue: 234424.40},
-				{SK: "TSLA", PurchaseValue: 1023.3},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("8612311.44")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("234424.40")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("1023.3")},
 			},
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 8612311.44, PortfolioPercentage: 0.9734},
-				{SK: "AAPL", PurchaseValue: 234424.40, PortfolioPercentage: 0.0265},
-				{SK: "TSLA", PurchaseValue: 1023.3, PortfolioPercentage: 0.0001},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("8612311.44"), PortfolioPercentage: types.MustParseDecimal("0.9734")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("234424.40"), PortfolioPercentage: types.MustParseDecimal("0.0265")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("1023.3"), PortfolioPercentage: types.MustParseDecimal("0.0001")},
 			},
 		},
 	}
@@ -176,6 +172,44 @@ func TestCalculatePortfolioRatio(t *testing.T) {
 	}
 }
 
+// TestCalculateMarketRatio checks that positions are weighted by market value, falling back to purchase value if never valued.
+func TestCalculateMarketRatio(t *testing.T) {
+	tests := map[string]struct {
+		openPositions          []database.OpenStockPosition
+		expectedPositionRatios []database.OpenStockPosition
+	}{
+		"Market Values": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("300")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("200")},
+			},
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("500"), CurrentValue: types.MustParseDecimal("500"), PortfolioPercentage: types.MustParseDecimal("0.5")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("300"), PortfolioPercentage: types.MustParseDecimal("0.3")},
+				{SK: "TSLA", PurchaseValue: types.MustParseDecimal("300"), CurrentValue: types.MustParseDecimal("200"), PortfolioPercentage: types.MustParseDecimal("0.2")},
+			},
+		},
+		"Never Valued": {
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("600")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("400")},
+			},
+			[]database.OpenStockPosition{
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("600"), PortfolioPercentage: types.MustParseDecimal("0.6")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("200"), CurrentValue: types.MustParseDecimal("400"), PortfolioPercentage: types.MustParseDecimal("0.4")},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Run(name, func(t *testing.T) {
+			calculatedRatios := CalculateMarketRatio(testCase.openPositions)
+			assert.Equal(t, testCase.expectedPositionRatios, calculatedRatios)
+		})
+	}
+}
+
 // TestRemoveElementFromSlice ensures that the correct position is removed from the open portfolio positions.
 func TestRemovePositionFromPortfolio(t *testing.T) {
 	tests := map[string]struct {
@@ -185,23 +219,23 @@ func TestRemovePositionFromPortfolio(t *testing.T) {
 	}{
 		"General Check": {
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 8612311.44},
-				{SK: "AAPL", PurchaseValue: 234424.40},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("8612311.44")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("234424.40")},
 			},
 			1,
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 8612311.44},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("8612311.44")},
 			},
 		},
 		"Invalid Index": {
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 8612311.44},
-				{SK: "AAPL", PurchaseValue: 234424.40},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("8612311.44")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("234424.40")},
 			},
 			5,
 			[]database.OpenStockPosition{
-				{SK: "CASH", PurchaseValue: 8612311.44},
-				{SK: "AAPL", PurchaseValue: 234424.40},
+				{SK: "CASH", PurchaseValue: types.MustParseDecimal("8612311.44")},
+				{SK: "AAPL", PurchaseValue: types.MustParseDecimal("234424.40")},
 			},
 		},
 	}